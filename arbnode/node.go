@@ -610,6 +610,9 @@ func createNodeImpl(
 			messagePruner = NewMessagePruner(txStreamer, inboxTracker, func() *MessagePrunerConfig { return &configFetcher.Get().MessagePruner })
 			confirmedNotifiers = append(confirmedNotifiers, messagePruner)
 		}
+		if execNode, ok := exec.(*gethexec.ExecutionNode); ok && execNode.StatePruner != nil {
+			confirmedNotifiers = append(confirmedNotifiers, execNode.StatePruner)
+		}
 
 		stakerObj, err = staker.NewStaker(l1Reader, wallet, bind.CallOpts{}, config.Staker, blockValidator, statelessBlockValidator, nil, confirmedNotifiers, deployInfo.ValidatorUtils, fatalErrChan)
 		if err != nil {
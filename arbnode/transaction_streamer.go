@@ -380,8 +380,13 @@ func dbKey(prefix []byte, pos uint64) []byte {
 
 // Note: if changed to acquire the mutex, some internal users may need to be updated to a non-locking version.
 func (s *TransactionStreamer) GetMessage(seqNum arbutil.MessageIndex) (*arbostypes.MessageWithMetadata, error) {
+	return ReadMessage(s.db, seqNum)
+}
+
+// ReadMessage reads a message stored in the arbitrum database.
+func ReadMessage(db ethdb.KeyValueReader, seqNum arbutil.MessageIndex) (*arbostypes.MessageWithMetadata, error) {
 	key := dbKey(messagePrefix, uint64(seqNum))
-	data, err := s.db.Get(key)
+	data, err := db.Get(key)
 	if err != nil {
 		return nil, err
 	}
@@ -396,7 +401,12 @@ func (s *TransactionStreamer) GetMessage(seqNum arbutil.MessageIndex) (*arbostyp
 
 // Note: if changed to acquire the mutex, some internal users may need to be updated to a non-locking version.
 func (s *TransactionStreamer) GetMessageCount() (arbutil.MessageIndex, error) {
-	posBytes, err := s.db.Get(messageCountKey)
+	return ReadMessageCount(s.db)
+}
+
+// ReadMessageCount reads the message count stored in the arbitrum database.
+func ReadMessageCount(db ethdb.KeyValueReader) (arbutil.MessageIndex, error) {
+	posBytes, err := db.Get(messageCountKey)
 	if err != nil {
 		return 0, err
 	}
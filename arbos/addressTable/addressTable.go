@@ -16,9 +16,11 @@ import (
 )
 
 type AddressTable struct {
-	backingStorage *storage.Storage
-	byAddress      *storage.Storage // 0 means item isn't in the table; n > 0 means it's in the table at slot n-1
-	numItems       storage.StorageBackedUint64
+	backingStorage        *storage.Storage
+	byAddress             *storage.Storage // 0 means item isn't in the table; n > 0 means it's in the table at slot n-1
+	numItems              storage.StorageBackedUint64
+	autoRegisterThreshold storage.StorageBackedUint64 // 0 means addresses are never registered automatically
+	uses                  *storage.Storage            // transactions sent to each address that isn't in the table yet
 }
 
 func Initialize(sto *storage.Storage) {
@@ -27,7 +29,14 @@ func Initialize(sto *storage.Storage) {
 
 func Open(sto *storage.Storage) *AddressTable {
 	numItems := sto.OpenStorageBackedUint64(0)
-	return &AddressTable{sto.WithoutCache(), sto.OpenSubStorage([]byte{}), numItems}
+	autoRegister := sto.OpenSubStorage([]byte{0})
+	return &AddressTable{
+		sto.WithoutCache(),
+		sto.OpenSubStorage([]byte{}),
+		numItems,
+		autoRegister.OpenStorageBackedUint64(0),
+		autoRegister.OpenSubStorage([]byte{}),
+	}
 }
 
 func (atab *AddressTable) Register(addr common.Address) (uint64, error) {
@@ -122,3 +131,40 @@ func (atab *AddressTable) Decompress(buf []byte) (common.Address, uint64, error)
 		return addr, numBytesRead, nil
 	}
 }
+
+// AutoRegisterThreshold gets how many transactions must be sent to an address before it's registered,
+// or 0 if addresses are never registered automatically
+func (atab *AddressTable) AutoRegisterThreshold() (uint64, error) {
+	return atab.autoRegisterThreshold.Get()
+}
+
+func (atab *AddressTable) SetAutoRegisterThreshold(threshold uint64) error {
+	return atab.autoRegisterThreshold.Set(threshold)
+}
+
+// RecordUse counts a transaction sent to addr, registering the address once it's been used as many times
+// as the auto-registration threshold. Returns whether the address was registered.
+func (atab *AddressTable) RecordUse(addr common.Address) (bool, error) {
+	threshold, err := atab.autoRegisterThreshold.Get()
+	if threshold == 0 || err != nil {
+		return false, err
+	}
+	exists, err := atab.AddressExists(addr)
+	if exists || err != nil {
+		return false, err
+	}
+	addrAsHash := common.BytesToHash(addr.Bytes())
+	uses, err := atab.uses.GetUint64(addrAsHash)
+	if err != nil {
+		return false, err
+	}
+	uses++
+	if uses < threshold {
+		return false, atab.uses.Set(addrAsHash, util.UintToHash(uses))
+	}
+	if err := atab.uses.Clear(addrAsHash); err != nil {
+		return false, err
+	}
+	_, err = atab.Register(addr)
+	return err == nil, err
+}
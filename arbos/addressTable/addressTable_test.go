@@ -134,6 +134,44 @@ func TestAddressTableCompressInTable(t *testing.T) {
 	}
 }
 
+func TestAddressTableAutoRegister(t *testing.T) {
+	sto := storage.NewMemoryBacked(burn.NewSystemBurner(nil, false))
+	Initialize(sto)
+	atab := Open(sto)
+	addr := common.BytesToAddress(crypto.Keccak256([]byte{})[:20])
+
+	registered, err := atab.RecordUse(addr)
+	Require(t, err)
+	if registered || size(t, atab) != 0 {
+		Fail(t, "registered an address while auto-registration is disabled")
+	}
+
+	Require(t, atab.SetAutoRegisterThreshold(3))
+	for i := 0; i < 2; i++ {
+		registered, err = atab.RecordUse(addr)
+		Require(t, err)
+		if registered {
+			Fail(t, "registered an address before it reached the threshold")
+		}
+	}
+	registered, err = atab.RecordUse(addr)
+	Require(t, err)
+	if !registered {
+		Fail(t, "didn't register an address that reached the threshold")
+	}
+	idx, found, err := atab.Lookup(addr)
+	Require(t, err)
+	if !found || idx != 0 {
+		Fail(t, "auto-registered address isn't in the table")
+	}
+
+	registered, err = atab.RecordUse(addr)
+	Require(t, err)
+	if registered || size(t, atab) != 1 {
+		Fail(t, "registered an address twice")
+	}
+}
+
 func size(t *testing.T, atab *AddressTable) uint64 {
 	size, err := atab.Size()
 	Require(t, err)
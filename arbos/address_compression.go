@@ -0,0 +1,128 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package arbos
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbos/addressTable"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+)
+
+// MessageAddressTable opens the address table that a block's address-compressed L2 messages are decoded against.
+// The statedb must be the state at the start of the block. Returns nil if the ArbOS version doesn't support them.
+func MessageAddressTable(statedb vm.StateDB) (*addressTable.AddressTable, error) {
+	state, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	if state.ArbOSVersion() < arbostypes.ArbosVersion_AddressCompression {
+		return nil, nil
+	}
+	return state.AddressTable(), nil
+}
+
+// AddressCompressor encodes signed transactions for the sequencer, replacing recipients that are in the
+// address table with their index. Since messages are decoded against the table as of the start of their block,
+// addresses registered while the block is being produced aren't compressed.
+type AddressCompressor struct {
+	table *addressTable.AddressTable
+	size  uint64
+}
+
+// NewAddressCompressor makes a compressor for the block about to be produced on top of statedb.
+// Returns nil if the ArbOS version doesn't support address-compressed messages.
+func NewAddressCompressor(statedb vm.StateDB) (*AddressCompressor, error) {
+	table, err := MessageAddressTable(statedb)
+	if table == nil || err != nil {
+		return nil, err
+	}
+	size, err := table.Size()
+	if err != nil {
+		return nil, err
+	}
+	return &AddressCompressor{table, size}, nil
+}
+
+// EncodeSignedTx encodes a signed transaction as an L2 message, starting with its kind.
+// The transaction is sent uncompressed if the compressor is nil or its recipient can't be compressed.
+func (c *AddressCompressor) EncodeSignedTx(tx *types.Transaction) ([]byte, error) {
+	if c != nil && tx.To() != nil {
+		index, exists, err := c.table.Lookup(*tx.To())
+		if err != nil {
+			return nil, err
+		}
+		withoutTo, ok := withRecipient(tx, nil)
+		if exists && index < c.size && ok {
+			txBytes, err := withoutTo.MarshalBinary()
+			if err != nil {
+				return nil, err
+			}
+			msg := rlp.AppendUint64([]byte{L2MessageKind_AddressCompressedTx}, index)
+			return append(msg, txBytes...), nil
+		}
+	}
+	txBytes, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{L2MessageKind_SignedTx}, txBytes...), nil
+}
+
+// parseAddressCompressedTx decodes a signed transaction whose recipient was replaced by its compressed form.
+// The transaction itself is encoded without a recipient, which is restored before the signature is checked.
+func parseAddressCompressedTx(data []byte, addresses *addressTable.AddressTable) (*types.Transaction, error) {
+	to, toSize, err := addresses.Decompress(data)
+	if err != nil {
+		return nil, err
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(data[toSize:]); err != nil {
+		return nil, err
+	}
+	if tx.To() != nil {
+		return nil, errors.New("address-compressed tx also has an uncompressed recipient")
+	}
+	tx, ok := withRecipient(tx, &to)
+	if !ok {
+		return nil, types.ErrTxTypeNotSupported
+	}
+	return tx, nil
+}
+
+// withRecipient copies a signed transaction, replacing its recipient but keeping its signature
+func withRecipient(tx *types.Transaction, to *common.Address) (*types.Transaction, bool) {
+	return copySignedTx(tx, func(txTo **common.Address, _ *[]byte) { *txTo = to })
+}
+
+// withData copies a signed transaction, replacing its calldata but keeping its signature
+func withData(tx *types.Transaction, data []byte) (*types.Transaction, bool) {
+	return copySignedTx(tx, func(_ **common.Address, txData *[]byte) { *txData = data })
+}
+
+// copySignedTx copies a signed transaction of a type that can be compressed, letting modify change its fields
+func copySignedTx(tx *types.Transaction, modify func(to **common.Address, data *[]byte)) (*types.Transaction, bool) {
+	switch inner := tx.GetInner().(type) {
+	case *types.LegacyTx:
+		copied := *inner
+		modify(&copied.To, &copied.Data)
+		return types.NewTx(&copied), true
+	case *types.AccessListTx:
+		copied := *inner
+		modify(&copied.To, &copied.Data)
+		return types.NewTx(&copied), true
+	case *types.DynamicFeeTx:
+		copied := *inner
+		modify(&copied.To, &copied.Data)
+		return types.NewTx(&copied), true
+	default:
+		return nil, false
+	}
+}
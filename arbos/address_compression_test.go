@@ -0,0 +1,107 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package arbos
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/offchainlabs/nitro/arbos/addressTable"
+	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/storage"
+)
+
+func TestAddressCompressedTx(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	Require(t, err)
+	chainId := params.ArbitrumDevTestChainConfig().ChainID
+	signer := types.LatestSignerForChainID(chainId)
+
+	registered := common.BytesToAddress([]byte{1})
+	lateRegistered := common.BytesToAddress([]byte{2})
+	unregistered := common.BytesToAddress([]byte{3})
+
+	table := addressTable.Open(storage.NewMemoryBacked(burn.NewSystemBurner(nil, false)))
+	_, err = table.Register(registered)
+	Require(t, err)
+	compressor := &AddressCompressor{table, 1}
+	// registered while the block is being produced, so it's not in the table messages are decoded against
+	_, err = table.Register(lateRegistered)
+	Require(t, err)
+
+	sign := func(to *common.Address) *types.Transaction {
+		tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   chainId,
+			Nonce:     1,
+			GasTipCap: common.Big0,
+			GasFeeCap: big.NewInt(params.GWei),
+			Gas:       100_000,
+			To:        to,
+			Value:     common.Big1,
+			Data:      []byte{0xab},
+		})
+		Require(t, err)
+		return tx
+	}
+	parse := func(msg []byte) *types.Transaction {
+		t.Helper()
+		txes, err := parseL2Message(bytes.NewReader(msg), common.Address{}, 0, nil, chainId, table, nil, 0)
+		Require(t, err)
+		if len(txes) != 1 {
+			Fail(t, "expected one tx but got", len(txes))
+		}
+		return txes[0]
+	}
+
+	tx := sign(&registered)
+	msg, err := compressor.EncodeSignedTx(tx)
+	Require(t, err)
+	if msg[0] != L2MessageKind_AddressCompressedTx {
+		Fail(t, "registered recipient wasn't compressed")
+	}
+	uncompressed, err := tx.MarshalBinary()
+	Require(t, err)
+	if len(msg) >= len(uncompressed) {
+		Fail(t, "compressed tx isn't smaller", len(msg), len(uncompressed))
+	}
+	parsed := parse(msg)
+	if parsed.Hash() != tx.Hash() {
+		Fail(t, "decompressed tx doesn't match the original")
+	}
+	sender, err := types.Sender(signer, parsed)
+	Require(t, err)
+	if sender != crypto.PubkeyToAddress(key.PublicKey) {
+		Fail(t, "decompressed tx has the wrong sender", sender)
+	}
+
+	for _, to := range []*common.Address{&lateRegistered, &unregistered, nil} {
+		tx := sign(to)
+		msg, err := compressor.EncodeSignedTx(tx)
+		Require(t, err)
+		if msg[0] != L2MessageKind_SignedTx {
+			Fail(t, "compressed a recipient that isn't in the table", to)
+		}
+		if parse(msg).Hash() != tx.Hash() {
+			Fail(t, "uncompressed tx doesn't round trip")
+		}
+	}
+
+	// a nil compressor never compresses, and nodes without an address table reject compressed txs
+	msg, err = (*AddressCompressor)(nil).EncodeSignedTx(tx)
+	Require(t, err)
+	if msg[0] != L2MessageKind_SignedTx {
+		Fail(t, "nil compressor compressed a tx")
+	}
+	compressed, err := compressor.EncodeSignedTx(tx)
+	Require(t, err)
+	if _, err := parseL2Message(bytes.NewReader(compressed), common.Address{}, 0, nil, chainId, nil, nil, 0); err == nil {
+		Fail(t, "parsed a compressed tx without an address table")
+	}
+}
@@ -22,12 +22,19 @@ import (
 	"github.com/offchainlabs/nitro/arbos/arbostypes"
 	"github.com/offchainlabs/nitro/arbos/blockhash"
 	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/feedistribution"
+	"github.com/offchainlabs/nitro/arbos/functiontable"
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
 	"github.com/offchainlabs/nitro/arbos/l2pricing"
 	"github.com/offchainlabs/nitro/arbos/merkleAccumulator"
+	"github.com/offchainlabs/nitro/arbos/pricehistory"
 	"github.com/offchainlabs/nitro/arbos/retryables"
+	"github.com/offchainlabs/nitro/arbos/scheduler"
+	"github.com/offchainlabs/nitro/arbos/statistics"
 	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/arbos/timelock"
 	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/util/arbmath"
 )
 
 // ArbosState contains ArbOS-related state. It is backed by ArbOS's storage in the persistent stateDB.
@@ -51,7 +58,14 @@ type ArbosState struct {
 	chainConfig            storage.StorageBackedBytes
 	genesisBlockNum        storage.StorageBackedUint64
 	infraFeeAccount        storage.StorageBackedAddress
-	brotliCompressionLevel storage.StorageBackedUint64 // brotli compression level used for pricing
+	brotliCompressionLevel storage.StorageBackedUint64      // brotli compression level used for pricing
+	filteredAddresses      *addressSet.AddressSet           // transactions from or to these addresses fail
+	scheduler              *scheduler.SchedulerState        // prepaid calls made when they come due
+	statistics             *statistics.Statistics           // running counts of transactions, contracts and retryables
+	ownerTimelock          *timelock.Timelock               // delay applied to chain owners' calls
+	feeDistribution        *feedistribution.FeeDistribution // recipients that fees are split between
+	priceHistory           *pricehistory.PriceHistory       // gas prices of recent blocks
+	functionTable          *functiontable.FunctionTable     // selector dictionaries for compressed messages
 	backingStorage         *storage.Storage
 	Burner                 burn.Burner
 }
@@ -85,6 +99,13 @@ func OpenArbosState(stateDB vm.StateDB, burner burn.Burner) (*ArbosState, error)
 		backingStorage.OpenStorageBackedUint64(uint64(genesisBlockNumOffset)),
 		backingStorage.OpenStorageBackedAddress(uint64(infraFeeAccountOffset)),
 		backingStorage.OpenStorageBackedUint64(uint64(brotliCompressionLevelOffset)),
+		addressSet.OpenAddressSet(backingStorage.OpenCachedSubStorage(filteredAddressesSubspace)),
+		scheduler.OpenScheduler(backingStorage.OpenCachedSubStorage(schedulerSubspace)),
+		statistics.OpenStatistics(backingStorage.OpenCachedSubStorage(statisticsSubspace)),
+		timelock.OpenTimelock(backingStorage.OpenCachedSubStorage(ownerTimelockSubspace)),
+		feedistribution.OpenFeeDistribution(backingStorage.OpenCachedSubStorage(feeDistributionSubspace)),
+		pricehistory.OpenPriceHistory(backingStorage.OpenCachedSubStorage(priceHistorySubspace)),
+		functiontable.OpenFunctionTable(backingStorage.OpenCachedSubStorage(functionTableSubspace)),
 		backingStorage,
 		burner,
 	}, nil
@@ -156,6 +177,15 @@ var (
 	sendMerkleSubspace   SubspaceID = []byte{5}
 	blockhashesSubspace  SubspaceID = []byte{6}
 	chainConfigSubspace  SubspaceID = []byte{7}
+
+	// The following subspaces are only initialized from ArbOS version 21 onwards
+	filteredAddressesSubspace SubspaceID = []byte{8}  // addresses whose transactions fail
+	schedulerSubspace         SubspaceID = []byte{9}  // calls scheduled through ArbScheduler, queued by due time
+	statisticsSubspace        SubspaceID = []byte{10} // counters read through ArbStatistics
+	ownerTimelockSubspace     SubspaceID = []byte{11} // owner calls queued behind the timelock delay
+	feeDistributionSubspace   SubspaceID = []byte{12} // recipients that fees are split between, by weight
+	priceHistorySubspace      SubspaceID = []byte{13} // L2 basefees and L1 prices of recent blocks, in a ring buffer
+	functionTableSubspace     SubspaceID = []byte{14} // selector dictionaries, the global one and one per account
 )
 
 // Returns a list of precompiles that only appear in Arbitrum chains (i.e. ArbOS precompiles) at the genesis block
@@ -197,6 +227,13 @@ func InitializeArbosState(stateDB vm.StateDB, burner burn.Burner, chainConfig *p
 	if desiredArbosVersion == 0 {
 		return nil, errors.New("cannot initialize to ArbOS version 0")
 	}
+	nativeToken, err := initMessage.NativeToken()
+	if err != nil {
+		return nil, err
+	}
+	if nativeToken != nil && desiredArbosVersion < arbostypes.ArbosVersion_NativeToken {
+		return nil, fmt.Errorf("a native token requires ArbOS version %v or later", arbostypes.ArbosVersion_NativeToken)
+	}
 
 	// Solidity requires call targets have code, but precompiles don't.
 	// To work around this, we give precompiles fake code.
@@ -225,7 +262,16 @@ func InitializeArbosState(stateDB vm.StateDB, burner burn.Burner, chainConfig *p
 	if desiredArbosVersion >= 2 {
 		initialRewardsRecipient = initialChainOwner
 	}
-	_ = l1pricing.InitializeL1PricingState(sto.OpenCachedSubStorage(l1PricingSubspace), initialRewardsRecipient, initMessage.InitialL1BaseFee)
+	initialL1BaseFee := initMessage.InitialL1BaseFee
+	if nativeToken != nil {
+		initialL1BaseFee = arbmath.BigDiv(arbmath.BigMul(initialL1BaseFee, nativeToken.InitialExchangeRate), arbostypes.NativeTokenExchangeRateScale)
+	}
+	_ = l1pricing.InitializeL1PricingState(sto.OpenCachedSubStorage(l1PricingSubspace), initialRewardsRecipient, initialL1BaseFee)
+	if nativeToken != nil {
+		l1p := l1pricing.OpenL1PricingState(sto.OpenCachedSubStorage(l1PricingSubspace))
+		_ = l1p.SetNativeTokenExchangeRate(nativeToken.InitialExchangeRate)
+		_ = l1p.SetNativeTokenDecimals(nativeToken.Decimals)
+	}
 	_ = l2pricing.InitializeL2PricingState(sto.OpenCachedSubStorage(l2PricingSubspace))
 	_ = retryables.InitializeRetryableState(sto.OpenCachedSubStorage(retryablesSubspace))
 	addressTable.Initialize(sto.OpenCachedSubStorage(addressTableSubspace))
@@ -315,8 +361,8 @@ func (state *ArbosState) UpgradeArbosVersion(
 			if !firstTime {
 				ensure(state.chainOwners.ClearList())
 			}
-		// ArbOS versions 12 through 19 are left to Orbit chains for custom upgrades.
-		// TODO: currently you can't get to ArbOS 20 without hitting the default case.
+		case 11, 12, 13, 14, 15, 16, 17, 18:
+			// ArbOS versions 12 through 19 are left to Orbit chains for custom upgrades.
 		case 19:
 			if !chainConfig.DebugMode() {
 				// This upgrade isn't finalized so we only want to support it for testing
@@ -328,6 +374,25 @@ func (state *ArbosState) UpgradeArbosVersion(
 			}
 			// Update Brotli compression level for fast compression from 0 to 1
 			ensure(state.SetBrotliCompressionLevel(1))
+		case 20:
+			// Upgrading to ArbOS 21, which initializes the subspaces its features need
+			if !chainConfig.DebugMode() {
+				// This upgrade isn't finalized so we only want to support it for testing
+				return fmt.Errorf(
+					"the chain is upgrading to unsupported ArbOS version %v, %w",
+					state.arbosVersion+1,
+					ErrFatalNodeOutOfDate,
+				)
+			}
+			ensure(addressSet.Initialize(state.backingStorage.OpenCachedSubStorage(filteredAddressesSubspace)))
+			ensure(scheduler.InitializeScheduler(state.backingStorage.OpenCachedSubStorage(schedulerSubspace)))
+			// Chains started before ArbOS 21 didn't give ArbScheduler code at genesis
+			stateDB.SetCode(scheduler.PrecompileAddress, []byte{byte(vm.INVALID)})
+			statistics.InitializeStatistics(state.backingStorage.OpenCachedSubStorage(statisticsSubspace))
+			timelock.InitializeTimelock(state.backingStorage.OpenCachedSubStorage(ownerTimelockSubspace))
+			feedistribution.InitializeFeeDistribution(state.backingStorage.OpenCachedSubStorage(feeDistributionSubspace))
+			pricehistory.InitializePriceHistory(state.backingStorage.OpenCachedSubStorage(priceHistorySubspace))
+			functiontable.InitializeFunctionTable(state.backingStorage.OpenCachedSubStorage(functionTableSubspace))
 		default:
 			return fmt.Errorf(
 				"the chain is upgrading to unsupported ArbOS version %v, %w",
@@ -420,6 +485,50 @@ func (state *ArbosState) ChainOwners() *addressSet.AddressSet {
 	return state.chainOwners
 }
 
+func (state *ArbosState) FilteredAddresses() *addressSet.AddressSet {
+	return state.filteredAddresses
+}
+
+// IsFilteredTransaction returns whether a transaction from or to the given addresses must fail.
+// The filter only applies from ArbOS version 21 onwards.
+func (state *ArbosState) IsFilteredTransaction(from common.Address, to *common.Address) (bool, error) {
+	if state.arbosVersion < arbostypes.ArbosVersion_AddressFilter {
+		return false, nil
+	}
+	filtered, err := state.filteredAddresses.IsMember(from)
+	if err != nil || filtered || to == nil {
+		return filtered, err
+	}
+	return state.filteredAddresses.IsMember(*to)
+}
+
+func (state *ArbosState) Scheduler() *scheduler.SchedulerState {
+	return state.scheduler
+}
+
+// Statistics returns the chain's running counters, which are only kept from ArbOS version 21 onwards
+func (state *ArbosState) Statistics() *statistics.Statistics {
+	return state.statistics
+}
+
+// OwnerTimelock returns the delay applied to chain owners' calls, which is only enforced from ArbOS version 21 onwards
+func (state *ArbosState) OwnerTimelock() *timelock.Timelock {
+	return state.ownerTimelock
+}
+
+func (state *ArbosState) FeeDistribution() *feedistribution.FeeDistribution {
+	return state.feeDistribution
+}
+
+func (state *ArbosState) PriceHistory() *pricehistory.PriceHistory {
+	return state.priceHistory
+}
+
+// FunctionTable returns the selector dictionaries used by selector-compressed messages, which exist from ArbOS version 21 onwards
+func (state *ArbosState) FunctionTable() *functiontable.FunctionTable {
+	return state.functionTable
+}
+
 func (state *ArbosState) SendMerkleAccumulator() *merkleAccumulator.MerkleAccumulator {
 	if state.sendMerkle == nil {
 		state.sendMerkle = merkleAccumulator.OpenMerkleAccumulator(state.backingStorage.OpenCachedSubStorage(sendMerkleSubspace))
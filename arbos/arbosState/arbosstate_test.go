@@ -8,6 +8,8 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
 	"github.com/offchainlabs/nitro/arbos/burn"
 	"github.com/offchainlabs/nitro/arbos/storage"
 	"github.com/offchainlabs/nitro/arbos/util"
@@ -91,3 +93,36 @@ func TestStorageSlots(t *testing.T) {
 		Fail(t, "page offset mismatch")
 	}
 }
+
+func TestFilteredAddresses(t *testing.T) {
+	state, statedb := NewArbosMemoryBackedArbOSState()
+	from := common.BytesToAddress([]byte{1})
+	to := common.BytesToAddress([]byte{2})
+	other := common.BytesToAddress([]byte{3})
+
+	checkFiltered := func(from common.Address, to *common.Address, expected bool) {
+		t.Helper()
+		filtered, err := state.IsFilteredTransaction(from, to)
+		Require(t, err)
+		if filtered != expected {
+			Fail(t, "filtered", filtered, "expected", expected, "from", from, "to", to)
+		}
+	}
+
+	if state.ArbOSVersion() >= arbostypes.ArbosVersion_AddressFilter {
+		Fail(t, "test chain starts with the address filter enabled")
+	}
+	checkFiltered(from, &to, false)
+
+	Require(t, state.UpgradeArbosVersion(arbostypes.ArbosVersion_AddressFilter, false, statedb, params.ArbitrumDevTestChainConfig()))
+	Require(t, state.FilteredAddresses().Add(from))
+
+	checkFiltered(from, &to, true)
+	checkFiltered(other, &to, false)
+	checkFiltered(other, nil, false)
+	Require(t, state.FilteredAddresses().Add(to))
+	checkFiltered(other, &to, true)
+	Require(t, state.FilteredAddresses().Remove(to, state.ArbOSVersion()))
+	checkFiltered(other, &to, false)
+	checkFiltered(from, nil, true)
+}
@@ -0,0 +1,313 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package arbosState
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/retryables"
+	"github.com/offchainlabs/nitro/statetransfer"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+var ErrMissingPreimage = errors.New("missing preimage for hashed trie key")
+
+type ExportConfig struct {
+	// Skip accounts and storage slots whose preimages aren't in the database, instead of failing.
+	// Preimages are only recorded by archive nodes.
+	AllowMissingPreimages bool
+}
+
+type ExportStats struct {
+	AddressTableEntries uint64
+	Retryables          uint64
+	ExpiredRetryables   uint64
+	Accounts            uint64
+	StorageSlots        uint64
+	MissingPreimages    uint64
+}
+
+// ExportArbosState writes the state at root, including the ArbOS address table and retryables,
+// in the init data format which InitializeArbosInDatabase imports.
+// The ArbOS state account itself isn't exported, as importing initializes it from scratch.
+// Retryables whose timeout is at or before timestamp, the time of the exported block, are dropped
+// as importing would drop them, and their escrow is credited to their beneficiary, as reaping them would have done.
+func ExportArbosState(ctx context.Context, stateDatabase state.Database, root common.Hash, timestamp uint64, nextBlockNumber uint64, writer *statetransfer.JsonInitDataWriter, config *ExportConfig) (*ExportStats, error) {
+	statedb, err := state.New(root, stateDatabase, nil)
+	if err != nil {
+		return nil, err
+	}
+	arbState, err := OpenArbosState(statedb, burn.NewSystemBurner(nil, true))
+	if err != nil {
+		return nil, err
+	}
+	stats := &ExportStats{}
+	writer.SetNextBlockNumber(nextBlockNumber)
+
+	addressWriter, err := writer.GetAddressTableWriter()
+	if err != nil {
+		return nil, err
+	}
+	addrTable := arbState.AddressTable()
+	addrTableSize, err := addrTable.Size()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < addrTableSize; i++ {
+		addr, exists, err := addrTable.LookupIndex(i)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("address table entry %v missing", i)
+		}
+		if err := addressWriter.Write(addr); err != nil {
+			return nil, err
+		}
+	}
+	if err := addressWriter.Close(); err != nil {
+		return nil, err
+	}
+	stats.AddressTableEntries = addrTableSize
+	log.Info("exported address table", "entries", addrTableSize)
+
+	// The callvalue of each retryable is re-escrowed on import, so it's removed from the escrow account's balance.
+	escrowed := make(map[common.Address]*big.Int)
+	refunds := make(map[common.Address]*big.Int)
+	retryableWriter, err := writer.GetRetryableDataWriter()
+	if err != nil {
+		return nil, err
+	}
+	retryableState := arbState.RetryableState()
+	err = retryableState.TimeoutQueue.ForEach(func(_ uint64, id common.Hash) (bool, error) {
+		escrowAddress := retryables.RetryableEscrowAddress(id)
+		if _, seen := escrowed[escrowAddress]; seen {
+			// retryables which were kept alive appear in the queue multiple times
+			return false, nil
+		}
+		retryable, err := retryableState.OpenRetryable(id, 0)
+		if err != nil || retryable == nil {
+			return false, err
+		}
+		data, err := exportRetryable(id, retryable)
+		if err != nil {
+			return false, err
+		}
+		if data.Timeout <= timestamp {
+			// expired but not yet reaped
+			escrowBalance := new(big.Int).Set(statedb.GetBalance(escrowAddress))
+			escrowed[escrowAddress] = escrowBalance
+			if refund, exists := refunds[data.Beneficiary]; exists {
+				refunds[data.Beneficiary] = arbmath.BigAdd(refund, escrowBalance)
+			} else {
+				refunds[data.Beneficiary] = escrowBalance
+			}
+			stats.ExpiredRetryables++
+			return ctx.Err() != nil, nil
+		}
+		escrowed[escrowAddress] = data.Callvalue
+		stats.Retryables++
+		return ctx.Err() != nil, retryableWriter.Write(data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err := retryableWriter.Close(); err != nil {
+		return nil, err
+	}
+	log.Info("exported retryables", "count", stats.Retryables, "expired", stats.ExpiredRetryables)
+
+	accountWriter, err := writer.GetAccountDataWriter()
+	if err != nil {
+		return nil, err
+	}
+	triedb := stateDatabase.TrieDB()
+	accountTrie, err := trie.NewStateTrie(trie.StateTrieID(root), triedb)
+	if err != nil {
+		return nil, err
+	}
+	it, err := accountTrie.NodeIterator(nil)
+	if err != nil {
+		return nil, err
+	}
+	for it.Next(true) {
+		if !it.Leaf() {
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		addrBytes := accountTrie.GetKey(it.LeafKey())
+		if addrBytes == nil {
+			stats.MissingPreimages++
+			if !config.AllowMissingPreimages {
+				return nil, fmt.Errorf("%w: account %v", ErrMissingPreimage, common.BytesToHash(it.LeafKey()))
+			}
+			continue
+		}
+		addr := common.BytesToAddress(addrBytes)
+		if addr == types.ArbosStateAddress {
+			continue
+		}
+		var account types.StateAccount
+		if err := rlp.DecodeBytes(it.LeafBlob(), &account); err != nil {
+			return nil, err
+		}
+		info := &statetransfer.AccountInitializationInfo{
+			Addr:       addr,
+			Nonce:      statedb.GetNonce(addr),
+			EthBalance: new(big.Int).Set(statedb.GetBalance(addr)),
+		}
+		if callvalue, isEscrow := escrowed[addr]; isEscrow {
+			info.EthBalance = arbmath.BigSub(info.EthBalance, callvalue)
+			if info.EthBalance.Sign() < 0 {
+				return nil, fmt.Errorf("retryable escrow %v holds less than the retryable's callvalue", addr)
+			}
+		}
+		if refund, isBeneficiary := refunds[addr]; isBeneficiary {
+			info.EthBalance = arbmath.BigAdd(info.EthBalance, refund)
+			delete(refunds, addr)
+		}
+		code := statedb.GetCode(addr)
+		if len(code) > 0 || account.Root != types.EmptyRootHash {
+			contractStorage, err := exportStorage(triedb, root, addr, account.Root, config, stats)
+			if err != nil {
+				return nil, err
+			}
+			info.ContractInfo = &statetransfer.AccountInitContractInfo{
+				Code:            code,
+				ContractStorage: contractStorage,
+			}
+		}
+		if info.Nonce == 0 && info.EthBalance.Sign() == 0 && info.ContractInfo == nil {
+			continue
+		}
+		if err := accountWriter.Write(info); err != nil {
+			return nil, err
+		}
+		stats.Accounts++
+		if stats.Accounts%100_000 == 0 {
+			log.Info("exporting accounts", "count", stats.Accounts, "storageSlots", stats.StorageSlots)
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	// beneficiaries of expired retryables which had no account of their own
+	beneficiaries := make([]common.Address, 0, len(refunds))
+	for addr := range refunds {
+		beneficiaries = append(beneficiaries, addr)
+	}
+	sort.Slice(beneficiaries, func(i, j int) bool {
+		return bytes.Compare(beneficiaries[i].Bytes(), beneficiaries[j].Bytes()) < 0
+	})
+	for _, addr := range beneficiaries {
+		if refunds[addr].Sign() == 0 {
+			continue
+		}
+		info := &statetransfer.AccountInitializationInfo{
+			Addr:       addr,
+			EthBalance: refunds[addr],
+		}
+		if err := accountWriter.Write(info); err != nil {
+			return nil, err
+		}
+		stats.Accounts++
+	}
+	if err := accountWriter.Close(); err != nil {
+		return nil, err
+	}
+	log.Info("exported accounts", "count", stats.Accounts, "storageSlots", stats.StorageSlots, "missingPreimages", stats.MissingPreimages)
+	return stats, nil
+}
+
+func exportRetryable(id common.Hash, retryable *retryables.Retryable) (*statetransfer.InitializationDataForRetryable, error) {
+	timeout, err := retryable.CalculateTimeout()
+	if err != nil {
+		return nil, err
+	}
+	from, err := retryable.From()
+	if err != nil {
+		return nil, err
+	}
+	to, err := retryable.To()
+	if err != nil {
+		return nil, err
+	}
+	callvalue, err := retryable.Callvalue()
+	if err != nil {
+		return nil, err
+	}
+	beneficiary, err := retryable.Beneficiary()
+	if err != nil {
+		return nil, err
+	}
+	calldata, err := retryable.Calldata()
+	if err != nil {
+		return nil, err
+	}
+	data := &statetransfer.InitializationDataForRetryable{
+		Id:          id,
+		Timeout:     timeout,
+		From:        from,
+		Callvalue:   callvalue,
+		Beneficiary: beneficiary,
+		Calldata:    calldata,
+	}
+	if to != nil {
+		data.To = *to
+	}
+	return data, nil
+}
+
+func exportStorage(triedb *trie.Database, root common.Hash, addr common.Address, storageRoot common.Hash, config *ExportConfig, stats *ExportStats) (map[common.Hash]common.Hash, error) {
+	contractStorage := make(map[common.Hash]common.Hash)
+	if storageRoot == types.EmptyRootHash {
+		return contractStorage, nil
+	}
+	storageTrie, err := trie.NewStateTrie(trie.StorageTrieID(root, crypto.Keccak256Hash(addr.Bytes()), storageRoot), triedb)
+	if err != nil {
+		return nil, err
+	}
+	it, err := storageTrie.NodeIterator(nil)
+	if err != nil {
+		return nil, err
+	}
+	for it.Next(true) {
+		if !it.Leaf() {
+			continue
+		}
+		key := storageTrie.GetKey(it.LeafKey())
+		if key == nil {
+			stats.MissingPreimages++
+			if !config.AllowMissingPreimages {
+				return nil, fmt.Errorf("%w: storage slot %v of %v", ErrMissingPreimage, common.BytesToHash(it.LeafKey()), addr)
+			}
+			continue
+		}
+		_, content, _, err := rlp.Split(it.LeafBlob())
+		if err != nil {
+			return nil, err
+		}
+		contractStorage[common.BytesToHash(key)] = common.BytesToHash(content)
+		stats.StorageSlots++
+	}
+	return contractStorage, it.Error()
+}
@@ -0,0 +1,134 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package arbosState
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/retryables"
+	"github.com/offchainlabs/nitro/statetransfer"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	prand := testhelpers.NewPseudoRandomDataSource(t, 1)
+	chainConfig := params.ArbitrumDevTestChainConfig()
+
+	// build a source state with preimages recorded, as an archive node would
+	raw := rawdb.NewMemoryDatabase()
+	stateDatabase := state.NewDatabaseWithConfig(raw, &trie.Config{Preimages: true})
+	statedb, err := state.New(common.Hash{}, stateDatabase, nil)
+	Require(t, err)
+	arbState, err := InitializeArbosState(statedb, burn.NewSystemBurner(nil, false), chainConfig, arbostypes.TestInitMessage)
+	Require(t, err)
+
+	addresses := []common.Address{prand.GetAddress(), prand.GetAddress(), prand.GetAddress()}
+	for _, addr := range addresses {
+		_, err := arbState.AddressTable().Register(addr)
+		Require(t, err)
+	}
+	retryable := pseudorandomRetryableInitForTesting(prand)
+	statedb.AddBalance(retryables.RetryableEscrowAddress(retryable.Id), retryable.Callvalue)
+	_, err = arbState.RetryableState().CreateRetryable(retryable.Id, retryable.Timeout, retryable.From, &retryable.To, retryable.Callvalue, retryable.Beneficiary, retryable.Calldata)
+	Require(t, err)
+	// a retryable which times out at the exported block but hasn't been reaped
+	exportTime := uint64(1_000_000)
+	expired := pseudorandomRetryableInitForTesting(prand)
+	expired.Timeout = exportTime
+	statedb.AddBalance(retryables.RetryableEscrowAddress(expired.Id), expired.Callvalue)
+	_, err = arbState.RetryableState().CreateRetryable(expired.Id, expired.Timeout, expired.From, &expired.To, expired.Callvalue, expired.Beneficiary, expired.Calldata)
+	Require(t, err)
+	var accounts []statetransfer.AccountInitializationInfo
+	for i := 0; i < 8; i++ {
+		account := pseudorandomAccountInitInfoForTesting(prand)
+		account.AggregatorInfo = nil
+		account.AggregatorToPay = nil
+		statedb.SetBalance(account.Addr, account.EthBalance)
+		statedb.SetNonce(account.Addr, account.Nonce)
+		statedb.SetCode(account.Addr, account.ContractInfo.Code)
+		for k, v := range account.ContractInfo.ContractStorage {
+			statedb.SetState(account.Addr, k, v)
+		}
+		accounts = append(accounts, account)
+	}
+	root, err := statedb.Commit(0, true)
+	Require(t, err)
+	Require(t, stateDatabase.TrieDB().Commit(root, true))
+
+	exportDir := t.TempDir()
+	writer, err := statetransfer.NewJsonInitDataWriter(exportDir)
+	Require(t, err)
+	stats, err := ExportArbosState(context.Background(), stateDatabase, root, exportTime, 42, writer, &ExportConfig{})
+	Require(t, err)
+	initPath, err := writer.Close()
+	Require(t, err)
+	if stats.Retryables != 1 || stats.ExpiredRetryables != 1 || stats.AddressTableEntries != uint64(len(addresses)) {
+		Fail(t, "unexpected export stats", stats)
+	}
+	Require(t, statetransfer.VerifyJsonInitDataChecksums(exportDir))
+
+	// import the export into a fresh database and check it matches
+	reader, err := statetransfer.NewJsonInitDataReader(initPath)
+	Require(t, err)
+	nextBlockNumber, err := reader.GetNextBlockNumber()
+	Require(t, err)
+	if nextBlockNumber != 42 {
+		Fail(t, "unexpected next block number", nextBlockNumber)
+	}
+	importedRaw := rawdb.NewMemoryDatabase()
+	importedRoot, err := InitializeArbosInDatabase(importedRaw, reader, chainConfig, arbostypes.TestInitMessage, 0, 0)
+	Require(t, err)
+	importedDb, err := state.New(importedRoot, state.NewDatabase(importedRaw), nil)
+	Require(t, err)
+	importedArbState, err := OpenArbosState(importedDb, &burn.SystemBurner{})
+	Require(t, err)
+	checkAddressTable(importedArbState, addresses, t)
+	checkRetryables(importedArbState, []statetransfer.InitializationDataForRetryable{retryable}, t)
+	checkAccounts(importedDb, importedArbState, accounts, t)
+	escrow := retryables.RetryableEscrowAddress(retryable.Id)
+	if importedDb.GetBalance(escrow).Cmp(retryable.Callvalue) != 0 {
+		Fail(t, "escrow balance", importedDb.GetBalance(escrow), "expected", retryable.Callvalue)
+	}
+	if importedDb.GetBalance(escrow).Cmp(statedb.GetBalance(escrow)) != 0 {
+		Fail(t, "escrow balance changed by export")
+	}
+	expiredRetryable, err := importedArbState.RetryableState().OpenRetryable(expired.Id, 0)
+	Require(t, err)
+	if expiredRetryable != nil {
+		Fail(t, "expired retryable was exported")
+	}
+	if balance := importedDb.GetBalance(retryables.RetryableEscrowAddress(expired.Id)); balance.Sign() != 0 {
+		Fail(t, "expired retryable's escrow still holds", balance)
+	}
+	if balance := importedDb.GetBalance(expired.Beneficiary); balance.Cmp(expired.Callvalue) != 0 {
+		Fail(t, "beneficiary balance", balance, "expected", expired.Callvalue)
+	}
+	for _, account := range accounts {
+		for k, v := range account.ContractInfo.ContractStorage {
+			if got := importedDb.GetState(account.Addr, k); got != v {
+				Fail(t, "storage mismatch for", account.Addr, "key", k, "got", got, "expected", v)
+			}
+		}
+	}
+
+	// corrupting an exported file must be caught by the checksums
+	accountsPath := path.Join(exportDir, "accounts.json")
+	data, err := os.ReadFile(accountsPath)
+	Require(t, err)
+	Require(t, os.WriteFile(accountsPath, bytes.Replace(data, []byte("0x"), []byte("0X"), 1), 0644))
+	if statetransfer.VerifyJsonInitDataChecksums(exportDir) == nil {
+		Fail(t, "checksum verification didn't detect a modified file")
+	}
+}
@@ -0,0 +1,458 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package arbosState
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/l2pricing"
+)
+
+type InspectConfig struct {
+	// The most retryables and address table entries to include, or 0 for all of them.
+	MaxEntries uint64
+}
+
+// StateDump is a readable snapshot of the ArbOS state, meant to be marshalled as JSON.
+type StateDump struct {
+	ArbOSVersion           uint64                `json:"arbosVersion"`
+	ScheduledUpgrade       ScheduledUpgradeDump  `json:"scheduledUpgrade"`
+	ChainId                *big.Int              `json:"chainId"`
+	GenesisBlockNum        uint64                `json:"genesisBlockNum"`
+	BrotliCompressionLevel uint64                `json:"brotliCompressionLevel"`
+	ChainOwners            []common.Address      `json:"chainOwners"`
+	NetworkFeeAccount      common.Address        `json:"networkFeeAccount"`
+	InfraFeeAccount        common.Address        `json:"infraFeeAccount"`
+	L1Pricing              L1PricingDump         `json:"l1Pricing"`
+	L2Pricing              L2PricingDump         `json:"l2Pricing"`
+	Retryables             RetryablesDump        `json:"retryables"`
+	AddressTable           AddressTableDump      `json:"addressTable"`
+	SendMerkleAccumulator  MerkleAccumulatorDump `json:"sendMerkleAccumulator"`
+	Blockhashes            BlockhashesDump       `json:"blockhashes"`
+}
+
+type ScheduledUpgradeDump struct {
+	Version   uint64 `json:"version"`
+	Timestamp uint64 `json:"timestamp"`
+}
+
+type L1PricingDump struct {
+	PayRewardsTo            common.Address                     `json:"payRewardsTo"`
+	EquilibrationUnits      *big.Int                           `json:"equilibrationUnits"`
+	Inertia                 uint64                             `json:"inertia"`
+	PerUnitReward           uint64                             `json:"perUnitReward"`
+	LastUpdateTime          uint64                             `json:"lastUpdateTime"`
+	FundsDueForRewards      *big.Int                           `json:"fundsDueForRewards"`
+	UnitsSinceUpdate        uint64                             `json:"unitsSinceUpdate"`
+	PricePerUnit            *big.Int                           `json:"pricePerUnit"`
+	LastSurplus             *big.Int                           `json:"lastSurplus"`
+	PerBatchGasCost         int64                              `json:"perBatchGasCost"`
+	AmortizedCostCapBips    uint64                             `json:"amortizedCostCapBips"`
+	L1FeesAvailable         *big.Int                           `json:"l1FeesAvailable"`
+	NativeTokenExchangeRate *big.Int                           `json:"nativeTokenExchangeRate"`
+	BlobBaseFeeEstimate     *big.Int                           `json:"blobBaseFeeEstimate"`
+	TotalFundsDue           *big.Int                           `json:"totalFundsDue"`
+	BatchPosters            map[common.Address]BatchPosterDump `json:"batchPosters"`
+}
+
+// BatchPosterDump describes a batch poster and the fee collector it's paid through.
+type BatchPosterDump struct {
+	PayTo    common.Address `json:"payTo"`
+	FundsDue *big.Int       `json:"fundsDue"`
+}
+
+type L2PricingDump struct {
+	BaseFeeWei          *big.Int                 `json:"baseFeeWei"`
+	MinBaseFeeWei       *big.Int                 `json:"minBaseFeeWei"`
+	SpeedLimitPerSecond uint64                   `json:"speedLimitPerSecond"`
+	PerBlockGasLimit    uint64                   `json:"perBlockGasLimit"`
+	GasBacklog          uint64                   `json:"gasBacklog"`
+	PricingInertia      uint64                   `json:"pricingInertia"`
+	BacklogTolerance    uint64                   `json:"backlogTolerance"`
+	ResourceConstraints []ResourceConstraintDump `json:"resourceConstraints"`
+}
+
+type ResourceConstraintDump struct {
+	Target  uint64 `json:"target"`
+	Inertia uint64 `json:"inertia"`
+	Backlog uint64 `json:"backlog"`
+}
+
+type RetryablesDump struct {
+	Count     uint64                        `json:"count"`
+	Truncated bool                          `json:"truncated,omitempty"`
+	Tickets   map[common.Hash]RetryableDump `json:"tickets"`
+}
+
+type RetryableDump struct {
+	NumTries    uint64          `json:"numTries"`
+	From        common.Address  `json:"from"`
+	To          *common.Address `json:"to"`
+	Callvalue   *big.Int        `json:"callvalue"`
+	Beneficiary common.Address  `json:"beneficiary"`
+	Timeout     uint64          `json:"timeout"`
+	Calldata    hexutil.Bytes   `json:"calldata"`
+}
+
+type AddressTableDump struct {
+	Size      uint64           `json:"size"`
+	Truncated bool             `json:"truncated,omitempty"`
+	Entries   []common.Address `json:"entries"`
+}
+
+type MerkleAccumulatorDump struct {
+	Size     uint64        `json:"size"`
+	Root     common.Hash   `json:"root"`
+	Partials []common.Hash `json:"partials"`
+}
+
+type BlockhashesDump struct {
+	L1BlockNumber uint64                 `json:"l1BlockNumber"`
+	Hashes        map[uint64]common.Hash `json:"hashes"`
+}
+
+// InspectArbosState reads the ArbOS state without modifying it
+func InspectArbosState(stateDB vm.StateDB, config *InspectConfig) (*StateDump, error) {
+	arbState, err := OpenArbosState(stateDB, burn.NewSystemBurner(nil, true))
+	if err != nil {
+		return nil, err
+	}
+	dump := &StateDump{ArbOSVersion: arbState.ArbOSVersion()}
+	dump.ScheduledUpgrade.Version, dump.ScheduledUpgrade.Timestamp, err = arbState.GetScheduledUpgrade()
+	if err != nil {
+		return nil, err
+	}
+	dump.ChainId, err = arbState.ChainId()
+	if err != nil {
+		return nil, err
+	}
+	dump.GenesisBlockNum, err = arbState.GenesisBlockNum()
+	if err != nil {
+		return nil, err
+	}
+	dump.BrotliCompressionLevel, err = arbState.BrotliCompressionLevel()
+	if err != nil {
+		return nil, err
+	}
+	dump.ChainOwners, err = arbState.ChainOwners().AllMembers(math.MaxUint64)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(dump.ChainOwners, func(i, j int) bool {
+		return bytes.Compare(dump.ChainOwners[i].Bytes(), dump.ChainOwners[j].Bytes()) < 0
+	})
+	dump.NetworkFeeAccount, err = arbState.NetworkFeeAccount()
+	if err != nil {
+		return nil, err
+	}
+	dump.InfraFeeAccount, err = arbState.InfraFeeAccount()
+	if err != nil {
+		return nil, err
+	}
+	if err := inspectL1Pricing(arbState, &dump.L1Pricing); err != nil {
+		return nil, err
+	}
+	if err := inspectL2Pricing(arbState, &dump.L2Pricing); err != nil {
+		return nil, err
+	}
+	if err := inspectRetryables(arbState, &dump.Retryables, config); err != nil {
+		return nil, err
+	}
+	if err := inspectAddressTable(arbState, &dump.AddressTable, config); err != nil {
+		return nil, err
+	}
+	acc := &dump.SendMerkleAccumulator
+	acc.Size, acc.Root, acc.Partials, err = arbState.SendMerkleAccumulator().StateForExport()
+	if err != nil {
+		return nil, err
+	}
+	if err := inspectBlockhashes(arbState, &dump.Blockhashes); err != nil {
+		return nil, err
+	}
+	return dump, nil
+}
+
+func inspectL1Pricing(arbState *ArbosState, dump *L1PricingDump) error {
+	ps := arbState.L1PricingState()
+	var err error
+	if dump.PayRewardsTo, err = ps.PayRewardsTo(); err != nil {
+		return err
+	}
+	if dump.EquilibrationUnits, err = ps.EquilibrationUnits(); err != nil {
+		return err
+	}
+	if dump.Inertia, err = ps.Inertia(); err != nil {
+		return err
+	}
+	if dump.PerUnitReward, err = ps.PerUnitReward(); err != nil {
+		return err
+	}
+	if dump.LastUpdateTime, err = ps.LastUpdateTime(); err != nil {
+		return err
+	}
+	if dump.FundsDueForRewards, err = ps.FundsDueForRewards(); err != nil {
+		return err
+	}
+	if dump.UnitsSinceUpdate, err = ps.UnitsSinceUpdate(); err != nil {
+		return err
+	}
+	if dump.PricePerUnit, err = ps.PricePerUnit(); err != nil {
+		return err
+	}
+	if dump.LastSurplus, err = ps.LastSurplus(); err != nil {
+		return err
+	}
+	if dump.PerBatchGasCost, err = ps.PerBatchGasCost(); err != nil {
+		return err
+	}
+	if dump.AmortizedCostCapBips, err = ps.AmortizedCostCapBips(); err != nil {
+		return err
+	}
+	if dump.L1FeesAvailable, err = ps.L1FeesAvailable(); err != nil {
+		return err
+	}
+	if dump.NativeTokenExchangeRate, err = ps.NativeTokenExchangeRate(); err != nil {
+		return err
+	}
+	if dump.BlobBaseFeeEstimate, err = ps.BlobBaseFeeEstimate(); err != nil {
+		return err
+	}
+	posterTable := ps.BatchPosterTable()
+	if dump.TotalFundsDue, err = posterTable.TotalFundsDue(); err != nil {
+		return err
+	}
+	posters, err := posterTable.AllPosters(math.MaxUint64)
+	if err != nil {
+		return err
+	}
+	dump.BatchPosters = make(map[common.Address]BatchPosterDump, len(posters))
+	for _, addr := range posters {
+		poster, err := posterTable.OpenPoster(addr, false)
+		if err != nil {
+			return err
+		}
+		payTo, err := poster.PayTo()
+		if err != nil {
+			return err
+		}
+		fundsDue, err := poster.FundsDue()
+		if err != nil {
+			return err
+		}
+		dump.BatchPosters[addr] = BatchPosterDump{PayTo: payTo, FundsDue: fundsDue}
+	}
+	return nil
+}
+
+func inspectL2Pricing(arbState *ArbosState, dump *L2PricingDump) error {
+	ps := arbState.L2PricingState()
+	var err error
+	if dump.BaseFeeWei, err = ps.BaseFeeWei(); err != nil {
+		return err
+	}
+	if dump.MinBaseFeeWei, err = ps.MinBaseFeeWei(); err != nil {
+		return err
+	}
+	if dump.SpeedLimitPerSecond, err = ps.SpeedLimitPerSecond(); err != nil {
+		return err
+	}
+	if dump.PerBlockGasLimit, err = ps.PerBlockGasLimit(); err != nil {
+		return err
+	}
+	if dump.GasBacklog, err = ps.GasBacklog(); err != nil {
+		return err
+	}
+	if dump.PricingInertia, err = ps.PricingInertia(); err != nil {
+		return err
+	}
+	if dump.BacklogTolerance, err = ps.BacklogTolerance(); err != nil {
+		return err
+	}
+	for kind := l2pricing.ResourceKind(0); kind < l2pricing.NumResourceKinds; kind++ {
+		constraint := ps.ResourceConstraint(kind)
+		var info ResourceConstraintDump
+		if info.Target, err = constraint.Target(); err != nil {
+			return err
+		}
+		if info.Inertia, err = constraint.Inertia(); err != nil {
+			return err
+		}
+		if info.Backlog, err = constraint.Backlog(); err != nil {
+			return err
+		}
+		dump.ResourceConstraints = append(dump.ResourceConstraints, info)
+	}
+	return nil
+}
+
+func inspectRetryables(arbState *ArbosState, dump *RetryablesDump, config *InspectConfig) error {
+	retryableState := arbState.RetryableState()
+	dump.Tickets = make(map[common.Hash]RetryableDump)
+	return retryableState.TimeoutQueue.ForEach(func(_ uint64, id common.Hash) (bool, error) {
+		if _, seen := dump.Tickets[id]; seen {
+			// retryables which were kept alive appear in the queue multiple times
+			return false, nil
+		}
+		retryable, err := retryableState.OpenRetryable(id, 0)
+		if err != nil || retryable == nil {
+			return false, err
+		}
+		dump.Count++
+		if config.MaxEntries != 0 && uint64(len(dump.Tickets)) >= config.MaxEntries {
+			dump.Truncated = true
+			return false, nil
+		}
+		data, err := exportRetryable(id, retryable)
+		if err != nil {
+			return false, err
+		}
+		numTries, err := retryable.NumTries()
+		if err != nil {
+			return false, err
+		}
+		to, err := retryable.To()
+		if err != nil {
+			return false, err
+		}
+		dump.Tickets[id] = RetryableDump{
+			NumTries:    numTries,
+			From:        data.From,
+			To:          to,
+			Callvalue:   data.Callvalue,
+			Beneficiary: data.Beneficiary,
+			Timeout:     data.Timeout,
+			Calldata:    data.Calldata,
+		}
+		return false, nil
+	})
+}
+
+func inspectAddressTable(arbState *ArbosState, dump *AddressTableDump, config *InspectConfig) error {
+	addrTable := arbState.AddressTable()
+	size, err := addrTable.Size()
+	if err != nil {
+		return err
+	}
+	dump.Size = size
+	if config.MaxEntries != 0 && size > config.MaxEntries {
+		size = config.MaxEntries
+		dump.Truncated = true
+	}
+	dump.Entries = make([]common.Address, 0, size)
+	for i := uint64(0); i < size; i++ {
+		addr, exists, err := addrTable.LookupIndex(i)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("address table entry %v missing", i)
+		}
+		dump.Entries = append(dump.Entries, addr)
+	}
+	return nil
+}
+
+func inspectBlockhashes(arbState *ArbosState, dump *BlockhashesDump) error {
+	blockhashes := arbState.Blockhashes()
+	number, err := blockhashes.L1BlockNumber()
+	if err != nil {
+		return err
+	}
+	dump.L1BlockNumber = number
+	dump.Hashes = make(map[uint64]common.Hash)
+	oldest := uint64(0)
+	if number > 256 {
+		oldest = number - 256
+	}
+	for i := oldest; i < number; i++ {
+		hash, err := blockhashes.BlockHash(i)
+		if err != nil {
+			return err
+		}
+		dump.Hashes[i] = hash
+	}
+	return nil
+}
+
+// StateDiff is a field whose value differs between two dumps.
+// A field missing from one of them has an empty value there.
+type StateDiff struct {
+	Path string `json:"path"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// DiffStateDumps lists the fields that differ between two dumps, sorted by path
+func DiffStateDumps(from, to *StateDump) ([]StateDiff, error) {
+	fromFields, err := flattenDump(from)
+	if err != nil {
+		return nil, err
+	}
+	toFields, err := flattenDump(to)
+	if err != nil {
+		return nil, err
+	}
+	diffs := []StateDiff{}
+	for path, value := range fromFields {
+		if toValue := toFields[path]; toValue != value {
+			diffs = append(diffs, StateDiff{path, value, toValue})
+		}
+	}
+	for path, value := range toFields {
+		if _, exists := fromFields[path]; !exists {
+			diffs = append(diffs, StateDiff{path, "", value})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Path < diffs[j].Path
+	})
+	return diffs, nil
+}
+
+// flattenDump maps the path of each leaf in the dump's JSON form to its value
+func flattenDump(dump *StateDump) (map[string]string, error) {
+	encoded, err := json.Marshal(dump)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var tree interface{}
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+	fields := make(map[string]string)
+	flattenJson("", tree, fields)
+	return fields, nil
+}
+
+func flattenJson(path string, node interface{}, fields map[string]string) {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		for key, child := range node {
+			if path != "" {
+				key = path + "." + key
+			}
+			flattenJson(key, child, fields)
+		}
+	case []interface{}:
+		for i, child := range node {
+			flattenJson(path+"["+strconv.Itoa(i)+"]", child, fields)
+		}
+	case nil:
+		fields[path] = "null"
+	default:
+		fields[path] = fmt.Sprint(node)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package arbosState
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestInspectAndDiff(t *testing.T) {
+	prand := testhelpers.NewPseudoRandomDataSource(t, 1)
+	arbState, statedb := NewArbosMemoryBackedArbOSState()
+
+	addr := prand.GetAddress()
+	_, err := arbState.AddressTable().Register(addr)
+	Require(t, err)
+	retryable := pseudorandomRetryableInitForTesting(prand)
+	_, err = arbState.RetryableState().CreateRetryable(retryable.Id, retryable.Timeout, retryable.From, &retryable.To, retryable.Callvalue, retryable.Beneficiary, retryable.Calldata)
+	Require(t, err)
+
+	before, err := InspectArbosState(statedb, &InspectConfig{})
+	Require(t, err)
+	if before.ArbOSVersion != arbState.ArbOSVersion() {
+		Fail(t, "wrong version", before.ArbOSVersion)
+	}
+	if before.AddressTable.Size != 1 || before.AddressTable.Entries[0] != addr {
+		Fail(t, "wrong address table", before.AddressTable)
+	}
+	ticket, exists := before.Retryables.Tickets[retryable.Id]
+	if before.Retryables.Count != 1 || !exists || ticket.Beneficiary != retryable.Beneficiary {
+		Fail(t, "wrong retryables", before.Retryables)
+	}
+	if _, err := json.Marshal(before); err != nil {
+		Fail(t, "dump isn't marshallable", err)
+	}
+
+	diffs, err := DiffStateDumps(before, before)
+	Require(t, err)
+	if len(diffs) != 0 {
+		Fail(t, "dump differs from itself", diffs)
+	}
+
+	Require(t, arbState.L2PricingState().SetMinBaseFeeWei(big.NewInt(12345)))
+	_, err = arbState.AddressTable().Register(prand.GetAddress())
+	Require(t, err)
+	after, err := InspectArbosState(statedb, &InspectConfig{MaxEntries: 1})
+	Require(t, err)
+	if !after.AddressTable.Truncated || len(after.AddressTable.Entries) != 1 {
+		Fail(t, "address table wasn't truncated", after.AddressTable)
+	}
+
+	diffs, err = DiffStateDumps(before, after)
+	Require(t, err)
+	changed := make(map[string]StateDiff)
+	for _, diff := range diffs {
+		changed[diff.Path] = diff
+	}
+	if changed["l2Pricing.minBaseFeeWei"].To != "12345" {
+		Fail(t, "min base fee change missing", diffs)
+	}
+	if changed["addressTable.size"].To != "2" || changed["addressTable.truncated"].From != "" {
+		Fail(t, "address table change missing", diffs)
+	}
+	if len(diffs) != 3 {
+		Fail(t, "unexpected diffs", diffs)
+	}
+}
@@ -35,6 +35,19 @@ const (
 const MaxL2MessageSize = 256 * 1024
 
 const ArbosVersion_FixRedeemGas = uint64(11)
+const ArbosVersion_AddressFilter = uint64(21)
+const ArbosVersion_NativeToken = uint64(21)
+const ArbosVersion_ScheduledCalls = uint64(21)
+const ArbosVersion_MultiDimensionalPricing = uint64(21)
+const ArbosVersion_Statistics = uint64(21)
+const ArbosVersion_AddressCompression = uint64(21)
+const ArbosVersion_OwnerTimelock = uint64(21)
+const ArbosVersion_RetryableExtensions = uint64(21)
+const ArbosVersion_BLS12381Precompiles = uint64(21)
+const ArbosVersion_BlobPricing = uint64(21)
+const ArbosVersion_FeeDistribution = uint64(21)
+const ArbosVersion_PriceHistory = uint64(21)
+const ArbosVersion_SelectorCompression = uint64(21)
 
 type L1IncomingMessageHeader struct {
 	Kind        uint8          `json:"kind"`
@@ -293,6 +306,9 @@ func (msg *L1IncomingMessage) ParseInitMessage() (*ParsedInitMessage, error) {
 			if err != nil {
 				return nil, fmt.Errorf("failed to parse init message, err: %w, message data: %v", err, string(msg.L2msg))
 			}
+			if _, err := ParseNativeTokenConfig(serializedChainConfig); err != nil {
+				return nil, fmt.Errorf("invalid native token in init message: %w", err)
+			}
 			return &ParsedInitMessage{chainId, basefee, &chainConfig, serializedChainConfig}, nil
 		}
 	}
@@ -335,3 +351,21 @@ func ParseBatchPostingReportMessageFields(rd io.Reader) (*big.Int, common.Addres
 	}
 	return batchTimestamp.Big(), batchPosterAddr, dataHash, batchNumBig.Uint64(), l1BaseFee.Big(), extraGas, nil
 }
+
+// ParseBatchPostingReportBlobFields reads the blob gas used by the batch and the blob base fee,
+// which follow the other fields in reports of batches posted as blobs.
+// Reports of batches posted as calldata don't have them, and zero is returned.
+func ParseBatchPostingReportBlobFields(rd io.Reader) (uint64, *big.Int, error) {
+	blobGasUsed, err := util.Uint64FromReader(rd)
+	if errors.Is(err, io.EOF) {
+		return 0, common.Big0, nil
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	blobBaseFee, err := util.HashFromReader(rd)
+	if err != nil {
+		return 0, nil, err
+	}
+	return blobGasUsed, blobBaseFee.Big(), nil
+}
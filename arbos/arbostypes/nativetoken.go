@@ -0,0 +1,96 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package arbostypes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// NativeTokenExchangeRateScale is the fixed point scale of native token exchange rates,
+// so a rate equal to the scale means one unit of the native token is worth one parent chain wei.
+var NativeTokenExchangeRateScale = big.NewInt(1e18)
+
+// EthDecimals is the number of decimals balances on this chain are kept in, whatever its native token.
+const EthDecimals = 18
+
+// MaxNativeTokenDecimals bounds the decimals of a native token, so rescaled deposits can't overflow.
+const MaxNativeTokenDecimals = 36
+
+// NativeTokenConfig describes a chain whose native currency is an ERC-20 token bridged from the parent chain.
+// The parent chain's inbox delivers deposits in the token's own decimals, and they're rescaled to EthDecimals
+// when parsed. Costs incurred on the parent chain are converted into the native token through an exchange rate
+// which starts at InitialExchangeRate and can be updated by the chain owner.
+type NativeTokenConfig struct {
+	Address             common.Address `json:"NativeToken"`
+	Decimals            uint64         `json:"NativeTokenDecimals"`
+	InitialExchangeRate *big.Int       `json:"NativeTokenInitialExchangeRate"`
+}
+
+// ParseNativeTokenConfig reads the native token settings from the "arbitrum" section of a serialized chain config.
+// Returns nil if the chain pays fees in the parent chain's currency.
+func ParseNativeTokenConfig(serializedChainConfig []byte) (*NativeTokenConfig, error) {
+	if len(serializedChainConfig) == 0 {
+		return nil, nil
+	}
+	var chainConfig struct {
+		Arbitrum struct {
+			NativeToken                    *common.Address
+			NativeTokenDecimals            *uint64
+			NativeTokenInitialExchangeRate *big.Int
+		} `json:"arbitrum"`
+	}
+	if err := json.Unmarshal(serializedChainConfig, &chainConfig); err != nil {
+		return nil, err
+	}
+	params := chainConfig.Arbitrum
+	if params.NativeToken == nil {
+		if params.NativeTokenDecimals != nil || params.NativeTokenInitialExchangeRate != nil {
+			return nil, errors.New("native token decimals or exchange rate set without a native token")
+		}
+		return nil, nil
+	}
+	if *params.NativeToken == (common.Address{}) {
+		return nil, errors.New("native token can't be the zero address")
+	}
+	if params.NativeTokenInitialExchangeRate == nil || params.NativeTokenInitialExchangeRate.Sign() <= 0 {
+		return nil, fmt.Errorf("native token %v requires a positive initial exchange rate", *params.NativeToken)
+	}
+	decimals := uint64(EthDecimals)
+	if params.NativeTokenDecimals != nil {
+		decimals = *params.NativeTokenDecimals
+	}
+	if decimals == 0 || decimals > MaxNativeTokenDecimals {
+		return nil, fmt.Errorf("native token %v has %v decimals but must have between 1 and %v", *params.NativeToken, decimals, MaxNativeTokenDecimals)
+	}
+	return &NativeTokenConfig{
+		Address:             *params.NativeToken,
+		Decimals:            decimals,
+		InitialExchangeRate: params.NativeTokenInitialExchangeRate,
+	}, nil
+}
+
+// NativeToken returns the native token settings of the chain being initialized, or nil if it pays fees in ETH.
+func (msg *ParsedInitMessage) NativeToken() (*NativeTokenConfig, error) {
+	return ParseNativeTokenConfig(msg.SerializedChainConfig)
+}
+
+// ScaleNativeTokenDeposit converts a deposit of the native token, delivered in the token's own decimals,
+// into the EthDecimals units balances are kept in. Amounts which don't divide evenly are rounded down.
+func ScaleNativeTokenDeposit(amount *big.Int, decimals uint64) *big.Int {
+	switch {
+	case decimals < EthDecimals:
+		scale := new(big.Int).Exp(big.NewInt(10), new(big.Int).SetUint64(EthDecimals-decimals), nil)
+		return new(big.Int).Mul(amount, scale)
+	case decimals > EthDecimals:
+		scale := new(big.Int).Exp(big.NewInt(10), new(big.Int).SetUint64(decimals-EthDecimals), nil)
+		return new(big.Int).Div(amount, scale)
+	default:
+		return amount
+	}
+}
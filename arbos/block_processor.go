@@ -35,6 +35,7 @@ var ArbRetryableTxAddress common.Address
 var ArbSysAddress common.Address
 var InternalTxStartBlockMethodID [4]byte
 var InternalTxBatchPostingReportMethodID [4]byte
+var InternalTxBatchPostingReportWithBlobsMethodID [4]byte
 var RedeemScheduledEventID common.Hash
 var L2ToL1TransactionEventID common.Hash
 var L2ToL1TxEventID common.Hash
@@ -124,6 +125,8 @@ type SequencingHooks struct {
 	PreTxFilter             func(*params.ChainConfig, *types.Header, *state.StateDB, *arbosState.ArbosState, *types.Transaction, *arbitrum_types.ConditionalOptions, common.Address, *L1Info) error
 	PostTxFilter            func(*types.Header, *arbosState.ArbosState, *types.Transaction, common.Address, uint64, *core.ExecutionResult) error
 	ConditionalOptionsForTx []*arbitrum_types.ConditionalOptions
+	// Only for simulations: lets user txs with a zero gas fee cap run without paying the base fee, as eth_call does
+	NoBaseFee bool
 }
 
 func NoopSequencingHooks() *SequencingHooks {
@@ -137,6 +140,7 @@ func NoopSequencingHooks() *SequencingHooks {
 			return nil
 		},
 		nil,
+		false,
 	}
 }
 
@@ -149,8 +153,25 @@ func ProduceBlock(
 	chainConfig *params.ChainConfig,
 	batchFetcher arbostypes.FallibleBatchFetcher,
 ) (*types.Block, types.Receipts, error) {
+	state, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	depositDecimals, err := state.L1PricingState().DepositDecimals(state.ArbOSVersion())
+	if err != nil {
+		return nil, nil, err
+	}
+	addresses, err := MessageAddressTable(statedb)
+	if err != nil {
+		return nil, nil, err
+	}
+	functions, err := MessageFunctionTable(statedb)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	var batchFetchErr error
-	txes, err := ParseL2Transactions(message, chainConfig.ChainID, func(batchNum uint64, batchHash common.Hash) []byte {
+	txes, err := ParseL2Transactions(message, chainConfig.ChainID, state.ArbOSVersion(), depositDecimals, addresses, functions, func(batchNum uint64, batchHash common.Hash) []byte {
 		data, err := batchFetcher(batchNum)
 		if err != nil {
 			batchFetchErr = err
@@ -239,14 +260,21 @@ func ProduceBlockAdvanced(
 			tx = redeems[0]
 			redeems = redeems[1:]
 
-			retry, ok := (tx.GetInner()).(*types.ArbitrumRetryTx)
-			if !ok {
-				return nil, nil, errors.New("retryable tx is somehow not a retryable")
-			}
-			retryable, _ := state.RetryableState().OpenRetryable(retry.TicketId, time)
-			if retryable == nil {
-				// retryable was already deleted
-				continue
+			switch inner := tx.GetInner().(type) {
+			case *types.ArbitrumRetryTx:
+				retryable, _ := state.RetryableState().OpenRetryable(inner.TicketId, time)
+				if retryable == nil {
+					// retryable was already deleted
+					continue
+				}
+			case *types.ArbitrumContractTx:
+				due, _ := state.Scheduler().IsDue(inner.RequestId)
+				if !due {
+					// scheduled call is somehow no longer due
+					continue
+				}
+			default:
+				return nil, nil, errors.New("scheduled tx is somehow neither a retryable nor a scheduled call")
 			}
 		} else {
 			tx = txes[0]
@@ -280,6 +308,19 @@ func ProduceBlockAdvanced(
 				return nil, nil, err
 			}
 
+			if isUserTx && tx.Type() != types.ArbitrumDepositTxType && tx.Type() != types.ArbitrumSubmitRetryableTxType {
+				// Filtered transactions are dropped like other invalid ones, without a receipt.
+				// Deposits and retryable submissions can't be dropped, as they mint funds from L1,
+				// so the tx processor fails them after the funds are minted.
+				filtered, err := state.IsFilteredTransaction(sender, tx.To())
+				if err != nil {
+					return nil, nil, err
+				}
+				if filtered {
+					return nil, nil, ErrFilteredAddress
+				}
+			}
+
 			if err = hooks.PreTxFilter(chainConfig, header, statedb, state, tx, options, sender, l1Info); err != nil {
 				return nil, nil, err
 			}
@@ -336,7 +377,7 @@ func ProduceBlockAdvanced(
 				header,
 				tx,
 				&header.GasUsed,
-				vm.Config{},
+				vm.Config{NoBaseFee: hooks.NoBaseFee},
 				func(result *core.ExecutionResult) error {
 					return hooks.PostTxFilter(header, state, tx, sender, dataGas, result)
 				},
@@ -403,6 +444,8 @@ func ProduceBlockAdvanced(
 				switch inner := scheduledTx.GetInner().(type) {
 				case *types.ArbitrumRetryTx:
 					txGasUsed = arbmath.SaturatingUSub(txGasUsed, inner.Gas)
+				case *types.ArbitrumContractTx:
+					// scheduled calls pay for their own gas out of their escrow
 				default:
 					log.Warn("Unexpected type of scheduled tx", "type", scheduledTx.Type())
 				}
@@ -0,0 +1,173 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package feedistribution
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+// FeeKind is a stream of fees that may be split between recipients.
+type FeeKind uint8
+
+const (
+	FeeKindNetwork   FeeKind = iota // the basefee above the infra fee, otherwise paid to the network fee account
+	FeeKindInfra                    // the infra fee, otherwise paid to the infra fee account
+	FeeKindL1Surplus                // the L1 pricer's surplus, otherwise kept to lower the L1 price
+	NumFeeKinds
+)
+
+// MaxRecipients bounds the work done splitting each transaction's fees
+const MaxRecipients = 8
+
+var (
+	ErrInvalidFeeKind    = errors.New("invalid fee kind")
+	ErrTooManyRecipients = errors.New("too many fee recipients")
+	ErrInvalidRecipient  = errors.New("fee recipients must be distinct non-zero addresses")
+	ErrInvalidWeights    = errors.New("fee recipient weights must be positive and add up to at most 10000 basis points")
+	errCorruptTable      = errors.New("fee distribution table has more recipients than the maximum")
+)
+
+func (kind FeeKind) Valid() bool {
+	return kind < NumFeeKinds
+}
+
+// Recipient receives a share of a kind of fee, in basis points.
+type Recipient struct {
+	Address common.Address
+	Weight  arbmath.Bips
+}
+
+// Payment is a recipient's share of an amount of fees.
+type Payment struct {
+	To     common.Address
+	Amount *big.Int
+}
+
+// FeeDistribution holds, for each kind of fee, the recipients it's split between.
+// Whatever isn't assigned to a recipient goes where the fee would otherwise go, so an empty table changes nothing.
+//
+// Each kind's table is a count followed by the recipients' addresses and weights.
+type FeeDistribution struct {
+	backingStorage *storage.Storage
+}
+
+const (
+	countOffset uint64 = iota
+	firstRecipientOffset
+)
+
+func InitializeFeeDistribution(sto *storage.Storage) {
+	// no need to do anything, every table starts empty
+}
+
+func OpenFeeDistribution(sto *storage.Storage) *FeeDistribution {
+	return &FeeDistribution{sto}
+}
+
+func (d *FeeDistribution) table(kind FeeKind) *storage.Storage {
+	return d.backingStorage.OpenCachedSubStorage([]byte{byte(kind)})
+}
+
+// Recipients gets the recipients a kind of fee is split between
+func (d *FeeDistribution) Recipients(kind FeeKind) ([]Recipient, error) {
+	if !kind.Valid() {
+		return nil, ErrInvalidFeeKind
+	}
+	table := d.table(kind)
+	count, err := table.GetUint64ByUint64(countOffset)
+	if err != nil {
+		return nil, err
+	}
+	if count > MaxRecipients {
+		return nil, errCorruptTable
+	}
+	recipients := make([]Recipient, count)
+	for i := uint64(0); i < count; i++ {
+		address, err := table.GetByUint64(firstRecipientOffset + 2*i)
+		if err != nil {
+			return nil, err
+		}
+		weight, err := table.GetUint64ByUint64(firstRecipientOffset + 2*i + 1)
+		if err != nil {
+			return nil, err
+		}
+		recipients[i] = Recipient{common.BytesToAddress(address.Bytes()), arbmath.Bips(weight)}
+	}
+	return recipients, nil
+}
+
+// SetRecipients replaces the recipients a kind of fee is split between
+func (d *FeeDistribution) SetRecipients(kind FeeKind, recipients []Recipient) error {
+	if !kind.Valid() {
+		return ErrInvalidFeeKind
+	}
+	if len(recipients) > MaxRecipients {
+		return ErrTooManyRecipients
+	}
+	seen := make(map[common.Address]bool, len(recipients))
+	total := arbmath.Bips(0)
+	for _, recipient := range recipients {
+		if recipient.Address == (common.Address{}) || seen[recipient.Address] {
+			return ErrInvalidRecipient
+		}
+		seen[recipient.Address] = true
+		if recipient.Weight <= 0 || recipient.Weight > arbmath.OneInBips {
+			return ErrInvalidWeights
+		}
+		total += recipient.Weight
+	}
+	if total > arbmath.OneInBips {
+		return ErrInvalidWeights
+	}
+
+	table := d.table(kind)
+	oldCount, err := table.GetUint64ByUint64(countOffset)
+	if err != nil {
+		return err
+	}
+	for i, recipient := range recipients {
+		offset := firstRecipientOffset + 2*uint64(i)
+		if err := table.SetByUint64(offset, util.AddressToHash(recipient.Address)); err != nil {
+			return err
+		}
+		if err := table.SetUint64ByUint64(offset+1, uint64(recipient.Weight)); err != nil {
+			return err
+		}
+	}
+	for i := uint64(len(recipients)); i < oldCount; i++ {
+		if err := table.ClearByUint64(firstRecipientOffset + 2*i); err != nil {
+			return err
+		}
+		if err := table.ClearByUint64(firstRecipientOffset + 2*i + 1); err != nil {
+			return err
+		}
+	}
+	return table.SetUint64ByUint64(countOffset, uint64(len(recipients)))
+}
+
+// Split divides an amount of a kind of fee between its recipients,
+// returning their non-zero shares and the remainder that isn't assigned to any of them
+func (d *FeeDistribution) Split(kind FeeKind, amount *big.Int) ([]Payment, *big.Int, error) {
+	recipients, err := d.Recipients(kind)
+	if err != nil || len(recipients) == 0 || amount.Sign() <= 0 {
+		return nil, amount, err
+	}
+	payments := []Payment{}
+	remainder := new(big.Int).Set(amount)
+	for _, recipient := range recipients {
+		share := arbmath.BigMulByBips(amount, recipient.Weight)
+		if share.Sign() <= 0 {
+			continue
+		}
+		payments = append(payments, Payment{recipient.Address, share})
+		remainder.Sub(remainder, share)
+	}
+	return payments, remainder, nil
+}
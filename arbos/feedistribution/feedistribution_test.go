@@ -0,0 +1,96 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package feedistribution
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/util/arbmath"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestFeeDistributionSplit(t *testing.T) {
+	sto := storage.NewMemoryBacked(burn.NewSystemBurner(nil, false))
+	InitializeFeeDistribution(sto)
+	distribution := OpenFeeDistribution(sto)
+	first := common.BytesToAddress([]byte{1})
+	second := common.BytesToAddress([]byte{2})
+
+	payments, remainder, err := distribution.Split(FeeKindNetwork, big.NewInt(1000))
+	Require(t, err)
+	if len(payments) != 0 || remainder.Int64() != 1000 {
+		Fail(t, "an empty table split fees", payments, remainder)
+	}
+
+	Require(t, distribution.SetRecipients(FeeKindNetwork, []Recipient{{first, 6000}, {second, 2500}}))
+	payments, remainder, err = distribution.Split(FeeKindNetwork, big.NewInt(1000))
+	Require(t, err)
+	if len(payments) != 2 || payments[0].To != first || payments[0].Amount.Int64() != 600 ||
+		payments[1].To != second || payments[1].Amount.Int64() != 250 || remainder.Int64() != 150 {
+		Fail(t, "wrong split", payments, remainder)
+	}
+	payments, remainder, err = distribution.Split(FeeKindInfra, big.NewInt(1000))
+	Require(t, err)
+	if len(payments) != 0 || remainder.Int64() != 1000 {
+		Fail(t, "another kind's table split fees", payments, remainder)
+	}
+
+	// shrinking the table clears the removed recipients
+	Require(t, distribution.SetRecipients(FeeKindNetwork, []Recipient{{second, arbmath.OneInBips}}))
+	recipients, err := OpenFeeDistribution(sto).Recipients(FeeKindNetwork)
+	Require(t, err)
+	if len(recipients) != 1 || recipients[0].Address != second || recipients[0].Weight != arbmath.OneInBips {
+		Fail(t, "wrong recipients", recipients)
+	}
+	address, err := sto.OpenCachedSubStorage([]byte{byte(FeeKindNetwork)}).GetByUint64(firstRecipientOffset + 2)
+	Require(t, err)
+	if address != (common.Hash{}) {
+		Fail(t, "removed recipient wasn't cleared")
+	}
+	Require(t, distribution.SetRecipients(FeeKindNetwork, nil))
+	recipients, err = distribution.Recipients(FeeKindNetwork)
+	Require(t, err)
+	if len(recipients) != 0 {
+		Fail(t, "table wasn't emptied", recipients)
+	}
+}
+
+func TestFeeDistributionValidation(t *testing.T) {
+	distribution := OpenFeeDistribution(storage.NewMemoryBacked(burn.NewSystemBurner(nil, false)))
+	first := common.BytesToAddress([]byte{1})
+	second := common.BytesToAddress([]byte{2})
+
+	check := func(kind FeeKind, recipients []Recipient, expected error) {
+		t.Helper()
+		if err := distribution.SetRecipients(kind, recipients); !errors.Is(err, expected) {
+			Fail(t, "expected", expected, "but got", err)
+		}
+	}
+	check(NumFeeKinds, nil, ErrInvalidFeeKind)
+	check(FeeKindInfra, []Recipient{{first, 6000}, {second, 4001}}, ErrInvalidWeights)
+	check(FeeKindInfra, []Recipient{{first, 0}}, ErrInvalidWeights)
+	check(FeeKindInfra, []Recipient{{first, 1}, {first, 1}}, ErrInvalidRecipient)
+	check(FeeKindInfra, []Recipient{{common.Address{}, 1}}, ErrInvalidRecipient)
+	tooMany := make([]Recipient, MaxRecipients+1)
+	for i := range tooMany {
+		tooMany[i] = Recipient{common.BytesToAddress([]byte{byte(i + 1)}), 1}
+	}
+	check(FeeKindInfra, tooMany, ErrTooManyRecipients)
+	check(FeeKindL1Surplus, tooMany[:MaxRecipients], nil)
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
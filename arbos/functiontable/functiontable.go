@@ -0,0 +1,103 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package functiontable
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/arbos/util"
+)
+
+// FunctionTable holds dictionaries of calldata prefixes, each a 4-byte selector optionally followed by common
+// leading arguments. Selector-compressed L2 messages replace a transaction's prefix with its index in a table.
+//
+// Every account has its own table, which only it can add to, and the chain owner manages the global table.
+// Tables are append-only so that an index keeps referring to the same prefix once it's been used.
+type FunctionTable struct {
+	tables *storage.Storage // owner => its size at offset 0 and its entries in substorages keyed by index
+}
+
+// GlobalOwner is the owner of the global table. No one can send transactions from it.
+var GlobalOwner = common.Address{}
+
+const MaxEntries = 256
+const MaxArgsPrefixSize = 128
+const MaxEntrySize = 4 + MaxArgsPrefixSize
+
+var ErrTableFull = fmt.Errorf("function tables can't have more than %v entries", MaxEntries)
+var ErrInvalidEntry = fmt.Errorf("function table entries must be a 4-byte selector and at most %v bytes of arguments", MaxArgsPrefixSize)
+
+const sizeOffset uint64 = 0
+
+func InitializeFunctionTable(sto *storage.Storage) {
+	// no need to do anything, all tables start empty
+}
+
+func OpenFunctionTable(sto *storage.Storage) *FunctionTable {
+	return &FunctionTable{sto.OpenCachedSubStorage([]byte{})}
+}
+
+func (ft *FunctionTable) table(owner common.Address) *storage.Storage {
+	return ft.tables.OpenSubStorage(owner.Bytes())
+}
+
+// Size returns how many entries an owner's table has
+func (ft *FunctionTable) Size(owner common.Address) (uint64, error) {
+	return ft.table(owner).GetUint64ByUint64(sizeOffset)
+}
+
+// Get returns the entry at an index of an owner's table
+func (ft *FunctionTable) Get(owner common.Address, index uint64) ([]byte, bool, error) {
+	table := ft.table(owner)
+	size, err := table.GetUint64ByUint64(sizeOffset)
+	if index >= size || err != nil {
+		return nil, false, err
+	}
+	entry := table.OpenStorageBackedBytes(entryKey(index))
+	value, err := entry.Get()
+	return value, err == nil, err
+}
+
+// Append adds entries to the end of an owner's table, returning the new size
+func (ft *FunctionTable) Append(owner common.Address, entries [][]byte) (uint64, error) {
+	table := ft.table(owner)
+	size, err := table.GetUint64ByUint64(sizeOffset)
+	if err != nil {
+		return 0, err
+	}
+	if uint64(len(entries)) > MaxEntries-size {
+		return size, ErrTableFull
+	}
+	for _, value := range entries {
+		if len(value) < 4 || len(value) > MaxEntrySize {
+			return size, ErrInvalidEntry
+		}
+	}
+	for _, value := range entries {
+		entry := table.OpenStorageBackedBytes(entryKey(size))
+		if err := entry.Set(value); err != nil {
+			return size, err
+		}
+		size++
+	}
+	return size, table.SetUint64ByUint64(sizeOffset, size)
+}
+
+// DecodeUpload parses the argument of ArbFunctionTable.upload, an RLP list of entries
+func DecodeUpload(buf []byte) ([][]byte, error) {
+	var entries [][]byte
+	if err := rlp.DecodeBytes(buf, &entries); err != nil {
+		return nil, errors.New("function table uploads must be an RLP list of entries")
+	}
+	return entries, nil
+}
+
+func entryKey(index uint64) []byte {
+	return util.UintToHash(index).Bytes()
+}
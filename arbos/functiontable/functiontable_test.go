@@ -0,0 +1,84 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package functiontable
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestFunctionTable(t *testing.T) {
+	sto := storage.NewMemoryBacked(burn.NewSystemBurner(nil, false))
+	InitializeFunctionTable(sto)
+	functions := OpenFunctionTable(sto)
+
+	alice := common.HexToAddress("0xA11CE")
+	transfer := []byte{0xa9, 0x05, 0x9c, 0xbb}
+	transferToBob := append(append([]byte{}, transfer...), common.HexToHash("0xB0B").Bytes()...)
+
+	upload, err := rlp.EncodeToBytes([][]byte{transfer, transferToBob})
+	Require(t, err)
+	entries, err := DecodeUpload(upload)
+	Require(t, err)
+	size, err := functions.Append(alice, entries)
+	Require(t, err)
+	if size != 2 {
+		Fail(t, "wrong size after upload", size)
+	}
+	size, err = functions.Append(alice, [][]byte{transfer})
+	Require(t, err)
+	if size != 3 {
+		Fail(t, "upload didn't append", size)
+	}
+
+	// reopen the tables to check they were persisted
+	functions = OpenFunctionTable(sto)
+	for index, expected := range [][]byte{transfer, transferToBob, transfer} {
+		entry, exists, err := functions.Get(alice, uint64(index))
+		Require(t, err)
+		if !exists || !bytes.Equal(entry, expected) {
+			Fail(t, "wrong entry at index", index, entry)
+		}
+	}
+	if _, exists, err := functions.Get(alice, 3); exists || err != nil {
+		Fail(t, "entry past the end of the table exists", err)
+	}
+	if size, err := functions.Size(GlobalOwner); size != 0 || err != nil {
+		Fail(t, "other tables aren't empty", size, err)
+	}
+
+	if _, err := functions.Append(alice, [][]byte{{1, 2, 3}}); !errors.Is(err, ErrInvalidEntry) {
+		Fail(t, "accepted an entry without a full selector", err)
+	}
+	if _, err := functions.Append(alice, [][]byte{make([]byte, MaxEntrySize+1)}); !errors.Is(err, ErrInvalidEntry) {
+		Fail(t, "accepted an entry that's too long", err)
+	}
+	if _, err := functions.Append(alice, make([][]byte, MaxEntries-2)); !errors.Is(err, ErrTableFull) {
+		Fail(t, "overfilled a table", err)
+	}
+	if size, err := functions.Size(alice); size != 3 || err != nil {
+		Fail(t, "rejected uploads changed the table", size, err)
+	}
+	if _, err := DecodeUpload([]byte{0x01}); err == nil {
+		Fail(t, "decoded an upload that isn't a list")
+	}
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
@@ -36,7 +36,7 @@ func TestSerializeAndParseL1Message(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	txes, err := ParseL2Transactions(newMsg, chainId, nil)
+	txes, err := ParseL2Transactions(newMsg, chainId, 0, arbostypes.EthDecimals, nil, nil, nil)
 	if err != nil {
 		t.Error(err)
 	}
@@ -44,3 +44,40 @@ func TestSerializeAndParseL1Message(t *testing.T) {
 		Fail(t, "unexpected tx count")
 	}
 }
+
+func TestParseNativeTokenDeposit(t *testing.T) {
+	chainId := big.NewInt(6345634)
+	requestId := common.BigToHash(big.NewInt(3))
+	to := common.BigToAddress(big.NewInt(1789))
+	amount := big.NewInt(2500000)
+	header := arbostypes.L1IncomingMessageHeader{
+		Kind:        arbostypes.L1MessageType_EthDeposit,
+		Poster:      common.BigToAddress(big.NewInt(4684)),
+		BlockNumber: 864513,
+		Timestamp:   8794561564,
+		RequestId:   &requestId,
+		L1BaseFee:   big.NewInt(10000000000000),
+	}
+	msg := &arbostypes.L1IncomingMessage{
+		Header: &header,
+		L2msg:  append(to.Bytes(), common.BigToHash(amount).Bytes()...),
+	}
+
+	checkDeposit := func(decimals uint64, expected *big.Int) {
+		t.Helper()
+		txes, err := ParseL2Transactions(msg, chainId, arbostypes.ArbosVersion_NativeToken, decimals, nil, nil, nil)
+		Require(t, err)
+		if len(txes) != 1 {
+			Fail(t, "unexpected tx count", len(txes))
+		}
+		if *txes[0].To() != to || txes[0].Value().Cmp(expected) != 0 {
+			Fail(t, "unexpected deposit of", txes[0].Value(), "to", txes[0].To(), "with", decimals, "decimals")
+		}
+	}
+
+	// a 6 decimal token deposit of 2.5 tokens
+	checkDeposit(6, big.NewInt(2500000000000000000))
+	checkDeposit(arbostypes.EthDecimals, amount)
+	// amounts below the chain's precision are rounded down
+	checkDeposit(24, big.NewInt(2))
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
 	"github.com/offchainlabs/nitro/arbos/util"
 )
 
@@ -80,17 +81,40 @@ func ApplyInternalTxUpdate(tx *types.ArbitrumInternalTx, state *arbosState.Arbos
 			state.Restrict(state.Blockhashes().RecordNewL1Block(l1BlockNumber-1, prevHash, state.ArbOSVersion()))
 		}
 
+		if state.ArbOSVersion() >= arbostypes.ArbosVersion_PriceHistory {
+			l1PricePerUnit, err := state.L1PricingState().PricePerUnit()
+			state.Restrict(err)
+			state.Restrict(state.PriceHistory().RecordBlock(evm.Context.BlockNumber.Uint64(), l2BaseFee, l1PricePerUnit))
+		}
+
 		currentTime := evm.Context.Time
 
 		// Try to reap 2 retryables
 		_ = state.RetryableState().TryToReapOneRetryable(currentTime, evm, util.TracingDuringEVM)
 		_ = state.RetryableState().TryToReapOneRetryable(currentTime, evm, util.TracingDuringEVM)
 
-		state.L2PricingState().UpdatePricingModel(l2BaseFee, timePassed, false)
+		if state.ArbOSVersion() >= arbostypes.ArbosVersion_ScheduledCalls {
+			// Collect the scheduled calls that are due, which are run right after this tx
+			blockNumber := evm.Context.BlockNumber.Uint64()
+			baseFee := evm.Context.BaseFee
+			state.Restrict(state.Scheduler().CollectDueCalls(blockNumber, currentTime, baseFee, evm, util.TracingDuringEVM))
+		}
+
+		if state.ArbOSVersion() >= arbostypes.ArbosVersion_MultiDimensionalPricing {
+			state.L2PricingState().UpdateMultiDimensionalPricingModel(timePassed)
+		} else {
+			state.L2PricingState().UpdatePricingModel(l2BaseFee, timePassed, false)
+		}
 
 		return state.UpgradeArbosVersionIfNecessary(currentTime, evm.StateDB, evm.ChainConfig())
-	case InternalTxBatchPostingReportMethodID:
-		inputs, err := util.UnpackInternalTxDataBatchPostingReport(tx.Data)
+	case InternalTxBatchPostingReportMethodID, InternalTxBatchPostingReportWithBlobsMethodID:
+		var inputs map[string]interface{}
+		var err error
+		if selector == InternalTxBatchPostingReportWithBlobsMethodID {
+			inputs, err = util.UnpackInternalTxDataBatchPostingReportWithBlobs(tx.Data)
+		} else {
+			inputs, err = util.UnpackInternalTxDataBatchPostingReport(tx.Data)
+		}
 		if err != nil {
 			return err
 		}
@@ -98,6 +122,12 @@ func ApplyInternalTxUpdate(tx *types.ArbitrumInternalTx, state *arbosState.Arbos
 		batchPosterAddress := util.SafeMapGet[common.Address](inputs, "batchPosterAddress")
 		batchDataGas := util.SafeMapGet[uint64](inputs, "batchDataGas")
 		l1BaseFeeWei := util.SafeMapGet[*big.Int](inputs, "l1BaseFeeWei")
+		blobGasUsed := uint64(0)
+		blobBaseFeeWei := common.Big0
+		if selector == InternalTxBatchPostingReportWithBlobsMethodID {
+			blobGasUsed = util.SafeMapGet[uint64](inputs, "blobGasUsed")
+			blobBaseFeeWei = util.SafeMapGet[*big.Int](inputs, "blobBaseFeeWei")
+		}
 
 		l1p := state.L1PricingState()
 		perBatchGas, err := l1p.PerBatchGasCost()
@@ -105,7 +135,17 @@ func ApplyInternalTxUpdate(tx *types.ArbitrumInternalTx, state *arbosState.Arbos
 			log.Warn("L1Pricing PerBatchGas failed", "err", err)
 		}
 		gasSpent := arbmath.SaturatingAdd(perBatchGas, arbmath.SaturatingCast(batchDataGas))
+		// the batch poster pays in the parent chain's currency but is reimbursed in the native token
+		l1BaseFeeWei, err = l1p.ParentChainToNative(l1BaseFeeWei, state.ArbOSVersion())
+		if err != nil {
+			return err
+		}
+		blobBaseFeeWei, err = l1p.ParentChainToNative(blobBaseFeeWei, state.ArbOSVersion())
+		if err != nil {
+			return err
+		}
 		weiSpent := arbmath.BigMulByUint(l1BaseFeeWei, arbmath.SaturatingUCast(gasSpent))
+		blobWeiSpent := arbmath.BigMulByUint(blobBaseFeeWei, blobGasUsed)
 		err = l1p.UpdateForBatchPosterSpending(
 			evm.StateDB,
 			evm,
@@ -115,6 +155,8 @@ func ApplyInternalTxUpdate(tx *types.ArbitrumInternalTx, state *arbosState.Arbos
 			batchPosterAddress,
 			weiSpent,
 			l1BaseFeeWei,
+			blobWeiSpent,
+			blobBaseFeeWei,
 			util.TracingDuringEVM,
 		)
 		if err != nil {
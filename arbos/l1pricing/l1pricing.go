@@ -22,6 +22,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
 	"github.com/offchainlabs/nitro/arbos/storage"
 	"github.com/offchainlabs/nitro/arbos/util"
 )
@@ -45,6 +46,13 @@ type L1PricingState struct {
 	perBatchGasCost      storage.StorageBackedInt64   // introduced in ArbOS version 3
 	amortizedCostCapBips storage.StorageBackedUint64  // in basis points; introduced in ArbOS version 3
 	l1FeesAvailable      storage.StorageBackedBigUint
+	// native token per parent chain wei, scaled by arbostypes.NativeTokenExchangeRateScale;
+	// zero if the chain pays fees in the parent chain's currency
+	nativeTokenExchangeRate storage.StorageBackedBigUint
+	// decimals the parent chain's inbox delivers native token deposits in; zero if there's no native token
+	nativeTokenDecimals storage.StorageBackedUint64
+	// the blob base fee paid for the last batch posted as blobs, in the native token; introduced in ArbOS version 21
+	blobBaseFeeEstimate storage.StorageBackedBigUint
 }
 
 var (
@@ -69,6 +77,9 @@ const (
 	perBatchGasCostOffset
 	amortizedCostCapBipsOffset
 	l1FeesAvailableOffset
+	nativeTokenExchangeRateOffset
+	nativeTokenDecimalsOffset
+	blobBaseFeeEstimateOffset
 )
 
 const (
@@ -131,6 +142,9 @@ func OpenL1PricingState(sto *storage.Storage) *L1PricingState {
 		sto.OpenStorageBackedInt64(perBatchGasCostOffset),
 		sto.OpenStorageBackedUint64(amortizedCostCapBipsOffset),
 		sto.OpenStorageBackedBigUint(l1FeesAvailableOffset),
+		sto.OpenStorageBackedBigUint(nativeTokenExchangeRateOffset),
+		sto.OpenStorageBackedUint64(nativeTokenDecimalsOffset),
+		sto.OpenStorageBackedBigUint(blobBaseFeeEstimateOffset),
 	}
 }
 
@@ -254,6 +268,69 @@ func (ps *L1PricingState) SetL1FeesAvailable(val *big.Int) error {
 	return ps.l1FeesAvailable.SetChecked(val)
 }
 
+func (ps *L1PricingState) NativeTokenExchangeRate() (*big.Int, error) {
+	return ps.nativeTokenExchangeRate.Get()
+}
+
+func (ps *L1PricingState) SetNativeTokenExchangeRate(rate *big.Int) error {
+	return ps.nativeTokenExchangeRate.SetChecked(rate)
+}
+
+func (ps *L1PricingState) NativeTokenDecimals() (uint64, error) {
+	return ps.nativeTokenDecimals.Get()
+}
+
+func (ps *L1PricingState) SetNativeTokenDecimals(decimals uint64) error {
+	return ps.nativeTokenDecimals.Set(decimals)
+}
+
+// DepositDecimals gets the decimals deposits are delivered in by the parent chain's inbox,
+// which is arbostypes.EthDecimals unless the chain has a native token.
+func (ps *L1PricingState) DepositDecimals(arbosVersion uint64) (uint64, error) {
+	if arbosVersion < arbostypes.ArbosVersion_NativeToken {
+		return arbostypes.EthDecimals, nil
+	}
+	decimals, err := ps.NativeTokenDecimals()
+	if err != nil || decimals == 0 {
+		return arbostypes.EthDecimals, err
+	}
+	return decimals, nil
+}
+
+func (ps *L1PricingState) BlobBaseFeeEstimate() (*big.Int, error) {
+	return ps.blobBaseFeeEstimate.Get()
+}
+
+func (ps *L1PricingState) SetBlobBaseFeeEstimate(fee *big.Int) error {
+	return ps.blobBaseFeeEstimate.SetChecked(fee)
+}
+
+// ParentChainToNative converts an amount of the parent chain's currency into the chain's native token.
+// Chains which pay fees in the parent chain's currency have no exchange rate, and the amount is returned unchanged,
+// as it is before ArbOS version 21, without reading the exchange rate.
+func (ps *L1PricingState) ParentChainToNative(amount *big.Int, arbosVersion uint64) (*big.Int, error) {
+	if arbosVersion < arbostypes.ArbosVersion_NativeToken {
+		return amount, nil
+	}
+	rate, err := ps.NativeTokenExchangeRate()
+	if err != nil || rate.Sign() == 0 {
+		return amount, err
+	}
+	return am.BigDiv(am.BigMul(amount, rate), arbostypes.NativeTokenExchangeRateScale), nil
+}
+
+// NativeToParentChain is the inverse of ParentChainToNative.
+func (ps *L1PricingState) NativeToParentChain(amount *big.Int, arbosVersion uint64) (*big.Int, error) {
+	if arbosVersion < arbostypes.ArbosVersion_NativeToken {
+		return amount, nil
+	}
+	rate, err := ps.NativeTokenExchangeRate()
+	if err != nil || rate.Sign() == 0 {
+		return amount, err
+	}
+	return am.BigDiv(am.BigMul(amount, arbostypes.NativeTokenExchangeRateScale), rate), nil
+}
+
 func (ps *L1PricingState) AddToL1FeesAvailable(delta *big.Int) (*big.Int, error) {
 	old, err := ps.L1FeesAvailable()
 	if err != nil {
@@ -290,7 +367,10 @@ func (ps *L1PricingState) TransferFromL1FeesAvailable(
 	return updated, nil
 }
 
-// UpdateForBatchPosterSpending updates the pricing model based on a payment by a batch poster
+// UpdateForBatchPosterSpending updates the pricing model based on a payment by a batch poster.
+// The batch poster's spending on calldata and on blobs is reported separately:
+// the former is subject to the amortized cost cap, while blobs are reimbursed in full,
+// since they're paid for whole even when the batch doesn't fill them.
 func (ps *L1PricingState) UpdateForBatchPosterSpending(
 	statedb vm.StateDB,
 	evm *vm.EVM,
@@ -299,6 +379,8 @@ func (ps *L1PricingState) UpdateForBatchPosterSpending(
 	batchPoster common.Address,
 	weiSpent *big.Int,
 	l1Basefee *big.Int,
+	blobWeiSpent *big.Int,
+	blobBaseFee *big.Int,
 	scenario util.TracingScenario,
 ) error {
 	if arbosVersion < 10 {
@@ -369,6 +451,13 @@ func (ps *L1PricingState) UpdateForBatchPosterSpending(
 		}
 	}
 
+	if arbosVersion >= arbostypes.ArbosVersion_BlobPricing && blobWeiSpent.Sign() > 0 {
+		weiSpent = am.BigAdd(weiSpent, blobWeiSpent)
+		if err := ps.SetBlobBaseFeeEstimate(blobBaseFee); err != nil {
+			return err
+		}
+	}
+
 	dueToPoster, err := posterState.FundsDue()
 	if err != nil {
 		return err
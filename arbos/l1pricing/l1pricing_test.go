@@ -9,6 +9,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
 	"github.com/offchainlabs/nitro/arbos/burn"
 	"github.com/offchainlabs/nitro/arbos/storage"
 )
@@ -32,3 +33,38 @@ func TestL1PriceUpdate(t *testing.T) {
 		Fail(t)
 	}
 }
+
+func TestNativeTokenConversion(t *testing.T) {
+	sto := storage.NewMemoryBacked(burn.NewSystemBurner(nil, false))
+	Require(t, InitializeL1PricingState(sto, common.Address{}, big.NewInt(params.GWei)))
+	ps := OpenL1PricingState(sto)
+
+	// without an exchange rate amounts are already in the native currency
+	amount := big.NewInt(3 * params.GWei)
+	version := arbostypes.ArbosVersion_NativeToken
+	converted, err := ps.ParentChainToNative(amount, version)
+	Require(t, err)
+	if converted.Cmp(amount) != 0 {
+		Fail(t, "unexpected conversion without an exchange rate", converted)
+	}
+
+	// the native token is worth a quarter of the parent chain's currency
+	Require(t, ps.SetNativeTokenExchangeRate(big.NewInt(4e18)))
+	converted, err = ps.ParentChainToNative(amount, version)
+	Require(t, err)
+	if converted.Cmp(big.NewInt(12*params.GWei)) != 0 {
+		Fail(t, "unexpected conversion to the native token", converted)
+	}
+	back, err := ps.NativeToParentChain(converted, version)
+	Require(t, err)
+	if back.Cmp(amount) != 0 {
+		Fail(t, "unexpected conversion to the parent chain's currency", back)
+	}
+
+	// older ArbOS versions never convert
+	converted, err = ps.ParentChainToNative(amount, version-1)
+	Require(t, err)
+	if converted.Cmp(amount) != 0 {
+		Fail(t, "converted before the native token version", converted)
+	}
+}
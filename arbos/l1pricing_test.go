@@ -183,7 +183,7 @@ func _testL1PricingFundsDue(t *testing.T, testParams *l1PricingTest, expectedRes
 	version := arbosSt.ArbOSVersion()
 	scenario := util.TracingDuringEVM
 	err = l1p.UpdateForBatchPosterSpending(
-		evm.StateDB, evm, version, 1, 3, firstPoster, arbmath.UintToBig(testParams.fundsSpent), arbmath.UintToBig(testParams.l1BasefeeGwei*params.GWei), scenario,
+		evm.StateDB, evm, version, 1, 3, firstPoster, arbmath.UintToBig(testParams.fundsSpent), arbmath.UintToBig(testParams.l1BasefeeGwei*params.GWei), common.Big0, common.Big0, scenario,
 	)
 	Require(t, err)
 	rewardRecipientBalance := evm.StateDB.GetBalance(rewardAddress)
@@ -231,15 +231,63 @@ func TestUpdateTimeUpgradeBehavior(t *testing.T) {
 	}
 	stateCheck(t, statedb, false, "uh oh, nothing should have happened", func() {
 		Require(t, l1p.UpdateForBatchPosterSpending(
-			evm.StateDB, evm, 1, 1, 1, poster, common.Big1, amount, util.TracingDuringEVM,
+			evm.StateDB, evm, 1, 1, 1, poster, common.Big1, amount, common.Big0, common.Big0, util.TracingDuringEVM,
 		))
 	})
 
 	Require(t, l1p.UpdateForBatchPosterSpending(
-		evm.StateDB, evm, 3, 1, 1, poster, common.Big1, amount, util.TracingDuringEVM,
+		evm.StateDB, evm, 3, 1, 1, poster, common.Big1, amount, common.Big0, common.Big0, util.TracingDuringEVM,
 	))
 }
 
+func TestBlobSpendingReimbursed(t *testing.T) {
+	evm := newMockEVMForTesting()
+	arbosSt, err := arbosState.OpenArbosState(evm.StateDB, burn.NewSystemBurner(nil, false))
+	Require(t, err)
+	l1p := arbosSt.L1PricingState()
+	// a cap of one basis point of the calldata price would all but eliminate the reimbursement
+	Require(t, l1p.SetAmortizedCostCapBips(1))
+	poolAddress := l1pricing.L1PricerFundsPoolAddress
+	funds := big.NewInt(1e18)
+	util.MintBalance(&poolAddress, funds, evm, util.TracingBeforeEVM, "test")
+	Require(t, l1p.SetL1FeesAvailable(funds))
+
+	l1BaseFee := big.NewInt(params.GWei)
+	blobBaseFee := big.NewInt(7)
+	blobWeiSpent := arbmath.BigMulByUint(blobBaseFee, 1<<17) // one blob
+	report := func(version uint64, time uint64) common.Address {
+		poster := common.Address{byte(version)}
+		payTo := common.Address{byte(version), 1}
+		_, err := l1p.BatchPosterTable().AddPoster(poster, payTo)
+		Require(t, err)
+		Require(t, l1p.SetUnitsSinceUpdate(1000))
+		Require(t, l1p.UpdateForBatchPosterSpending(
+			evm.StateDB, evm, version, time, time, poster, common.Big0, l1BaseFee, blobWeiSpent, blobBaseFee, util.TracingDuringEVM,
+		))
+		return payTo
+	}
+
+	payTo := report(20, 1)
+	if evm.StateDB.GetBalance(payTo).Sign() != 0 {
+		Fail(t, "blob spending was reimbursed before ArbOS 21")
+	}
+	estimate, err := l1p.BlobBaseFeeEstimate()
+	Require(t, err)
+	if estimate.Sign() != 0 {
+		Fail(t, "blob base fee was recorded before ArbOS 21", estimate)
+	}
+
+	payTo = report(21, 2)
+	if !arbmath.BigEquals(evm.StateDB.GetBalance(payTo), blobWeiSpent) {
+		Fail(t, "blob spending wasn't reimbursed in full", evm.StateDB.GetBalance(payTo), blobWeiSpent)
+	}
+	estimate, err = l1p.BlobBaseFeeEstimate()
+	Require(t, err)
+	if !arbmath.BigEquals(estimate, blobBaseFee) {
+		Fail(t, "wrong blob base fee estimate", estimate)
+	}
+}
+
 func TestL1PriceEquilibrationUp(t *testing.T) {
 	_testL1PriceEquilibration(t, big.NewInt(1_000_000_000), big.NewInt(5_000_000_000))
 }
@@ -283,6 +331,8 @@ func _testL1PriceEquilibration(t *testing.T, initialL1BasefeeEstimate *big.Int,
 			bpAddr,
 			arbmath.BigMulByUint(equilibriumL1BasefeeEstimate, unitsToAdd),
 			equilibriumL1BasefeeEstimate,
+			common.Big0,
+			common.Big0,
 			util.TracingBeforeEVM,
 		)
 		Require(t, err)
@@ -0,0 +1,123 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package l2pricing
+
+import (
+	"errors"
+
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+// ResourceKind is a dimension of usage that's priced separately from the gas pool.
+// The gas pool continues to price computation.
+type ResourceKind uint8
+
+const (
+	ResourceKindCodeGrowth    ResourceKind = iota // bytes of code deployed by contract creation transactions
+	ResourceKindHistoryGrowth                     // bytes of calldata and logs added to the chain's history
+	NumResourceKinds
+)
+
+var ErrInvalidResourceKind = errors.New("invalid resource kind")
+
+// ResourceConstraint tracks the backlog of one kind of resource.
+// Each second, the backlog is paid off by the target, and the basefee rises exponentially
+// with the remaining backlog, more slowly the larger the inertia.
+// A constraint with a zero target is disabled.
+type ResourceConstraint struct {
+	target  storage.StorageBackedUint64
+	inertia storage.StorageBackedUint64
+	backlog storage.StorageBackedUint64
+}
+
+const (
+	constraintTargetOffset uint64 = iota
+	constraintInertiaOffset
+	constraintBacklogOffset
+)
+
+func openResourceConstraint(sto *storage.Storage) *ResourceConstraint {
+	return &ResourceConstraint{
+		sto.OpenStorageBackedUint64(constraintTargetOffset),
+		sto.OpenStorageBackedUint64(constraintInertiaOffset),
+		sto.OpenStorageBackedUint64(constraintBacklogOffset),
+	}
+}
+
+func (kind ResourceKind) Valid() bool {
+	return kind < NumResourceKinds
+}
+
+// ResourceConstraint opens the constraint for a kind of resource, which must be valid
+func (ps *L2PricingState) ResourceConstraint(kind ResourceKind) *ResourceConstraint {
+	return openResourceConstraint(ps.resourceConstraints.OpenCachedSubStorage([]byte{byte(kind)}))
+}
+
+// AddResourceUsage adds the units of a resource used by a transaction to its constraint's backlog
+func (ps *L2PricingState) AddResourceUsage(kind ResourceKind, units uint64) error {
+	if !kind.Valid() {
+		return ErrInvalidResourceKind
+	}
+	constraint := ps.ResourceConstraint(kind)
+	target, err := constraint.Target()
+	if err != nil || target == 0 || units == 0 {
+		return err
+	}
+	backlog, err := constraint.Backlog()
+	if err != nil {
+		return err
+	}
+	return constraint.backlog.Set(arbmath.SaturatingUAdd(backlog, units))
+}
+
+func (ps *L2PricingState) SetResourceConstraint(kind ResourceKind, target, inertia uint64) error {
+	if !kind.Valid() {
+		return ErrInvalidResourceKind
+	}
+	if target != 0 && inertia == 0 {
+		return errors.New("an enabled resource constraint must have a non-zero inertia")
+	}
+	constraint := ps.ResourceConstraint(kind)
+	if err := constraint.target.Set(target); err != nil {
+		return err
+	}
+	if err := constraint.inertia.Set(inertia); err != nil {
+		return err
+	}
+	if target == 0 {
+		// a disabled constraint doesn't accumulate a backlog
+		return constraint.backlog.Set(0)
+	}
+	return nil
+}
+
+func (c *ResourceConstraint) Target() (uint64, error) {
+	return c.target.Get()
+}
+
+func (c *ResourceConstraint) Inertia() (uint64, error) {
+	return c.inertia.Get()
+}
+
+func (c *ResourceConstraint) Backlog() (uint64, error) {
+	return c.backlog.Get()
+}
+
+// updateExponent pays off the backlog for the time passed, returning the constraint's contribution to the basefee
+func (c *ResourceConstraint) updateExponent(timePassed uint64) arbmath.Bips {
+	target, _ := c.Target()
+	if target == 0 {
+		return 0
+	}
+	inertia, _ := c.Inertia()
+	backlog, _ := c.Backlog()
+	backlog = arbmath.SaturatingUSub(backlog, arbmath.SaturatingUMul(timePassed, target))
+	_ = c.backlog.Set(backlog)
+	divisor := arbmath.SaturatingUMul(inertia, target)
+	if backlog == 0 || divisor == 0 {
+		return 0
+	}
+	return arbmath.NaturalToBips(arbmath.SaturatingCast(backlog)) / arbmath.Bips(arbmath.SaturatingCast(divisor))
+}
@@ -18,6 +18,7 @@ type L2PricingState struct {
 	gasBacklog          storage.StorageBackedUint64
 	pricingInertia      storage.StorageBackedUint64
 	backlogTolerance    storage.StorageBackedUint64
+	resourceConstraints *storage.Storage // only used from ArbOS version 21 onwards
 }
 
 const (
@@ -30,6 +31,8 @@ const (
 	backlogToleranceOffset
 )
 
+var resourceConstraintsKey = []byte{0}
+
 const GethBlockGasLimit = 1 << 50
 
 func InitializeL2PricingState(sto *storage.Storage) error {
@@ -52,6 +55,7 @@ func OpenL2PricingState(sto *storage.Storage) *L2PricingState {
 		sto.OpenStorageBackedUint64(gasBacklogOffset),
 		sto.OpenStorageBackedUint64(pricingInertiaOffset),
 		sto.OpenStorageBackedUint64(backlogToleranceOffset),
+		sto.OpenCachedSubStorage(resourceConstraintsKey),
 	}
 }
 
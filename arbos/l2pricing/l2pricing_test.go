@@ -87,6 +87,70 @@ func TestPricingModelExp(t *testing.T) {
 	}
 }
 
+func TestResourceConstraintPricing(t *testing.T) {
+	pricing := PricingForTest(t)
+	minPrice := getMinPrice(t, pricing)
+	history := ResourceKindHistoryGrowth
+
+	// disabled constraints neither accumulate a backlog nor affect the price
+	Require(t, pricing.AddResourceUsage(history, 1_000_000))
+	pricing.UpdateMultiDimensionalPricingModel(1)
+	if getPrice(t, pricing) != minPrice || getBacklog(t, pricing, history) != 0 {
+		Fail(t, "disabled constraint affected pricing")
+	}
+
+	if pricing.SetResourceConstraint(history, 1000, 0) == nil {
+		Fail(t, "enabled a constraint without inertia")
+	}
+	if pricing.SetResourceConstraint(NumResourceKinds, 1000, 10) == nil {
+		Fail(t, "set a constraint of an invalid kind")
+	}
+	Require(t, pricing.SetResourceConstraint(history, 1000, 10))
+
+	// using the target each second is a steady-state
+	for seconds := uint64(1); seconds < 4; seconds++ {
+		Require(t, pricing.AddResourceUsage(history, seconds*1000))
+		pricing.UpdateMultiDimensionalPricingModel(seconds)
+		if getPrice(t, pricing) != minPrice {
+			Fail(t, "price changed when it shouldn't have")
+		}
+	}
+
+	// exceeding the target raises the price, even though the gas pool is full
+	Require(t, pricing.AddResourceUsage(history, 100_000))
+	pricing.UpdateMultiDimensionalPricingModel(1)
+	price := getPrice(t, pricing)
+	if price <= minPrice {
+		Fail(t, "price should have risen")
+	}
+	if getBacklog(t, pricing, history) != 99_000 {
+		Fail(t, "unexpected backlog", getBacklog(t, pricing, history))
+	}
+
+	// the backlog is paid off over time
+	pricing.UpdateMultiDimensionalPricingModel(50)
+	if newPrice := getPrice(t, pricing); newPrice >= price || newPrice <= minPrice {
+		Fail(t, "price should have fallen but not to the minimum", newPrice)
+	}
+	pricing.UpdateMultiDimensionalPricingModel(50)
+	if getPrice(t, pricing) != minPrice {
+		Fail(t, "price should have returned to the minimum")
+	}
+
+	// disabling the constraint clears its backlog
+	Require(t, pricing.AddResourceUsage(history, 100_000))
+	Require(t, pricing.SetResourceConstraint(history, 0, 0))
+	if getBacklog(t, pricing, history) != 0 {
+		Fail(t, "disabled constraint kept its backlog")
+	}
+}
+
+func getBacklog(t *testing.T, pricing *L2PricingState, kind ResourceKind) uint64 {
+	value, err := pricing.ResourceConstraint(kind).Backlog()
+	Require(t, err)
+	return value
+}
+
 func getPrice(t *testing.T, pricing *L2PricingState) uint64 {
 	value, err := pricing.BaseFeeWei()
 	Require(t, err)
@@ -36,16 +36,37 @@ func (ps *L2PricingState) AddToGasPool(gas int64) error {
 
 // UpdatePricingModel updates the pricing model with info from the last block
 func (ps *L2PricingState) UpdatePricingModel(l2BaseFee *big.Int, timePassed uint64, debug bool) {
+	ps.setBaseFeeFromExponent(ps.gasPoolExponent(timePassed))
+}
+
+// UpdateMultiDimensionalPricingModel updates the pricing model with info from the last block,
+// combining the exponents of the gas pool and of each enabled resource constraint into one basefee
+func (ps *L2PricingState) UpdateMultiDimensionalPricingModel(timePassed uint64) {
+	exponentBips := ps.gasPoolExponent(timePassed)
+	for kind := ResourceKind(0); kind < NumResourceKinds; kind++ {
+		constraintBips := ps.ResourceConstraint(kind).updateExponent(timePassed)
+		exponentBips = arbmath.Bips(arbmath.SaturatingAdd(int64(exponentBips), int64(constraintBips)))
+	}
+	ps.setBaseFeeFromExponent(exponentBips)
+}
+
+func (ps *L2PricingState) gasPoolExponent(timePassed uint64) arbmath.Bips {
 	speedLimit, _ := ps.SpeedLimitPerSecond()
 	_ = ps.AddToGasPool(int64(timePassed * speedLimit))
 	inertia, _ := ps.PricingInertia()
 	tolerance, _ := ps.BacklogTolerance()
 	backlog, _ := ps.GasBacklog()
-	minBaseFee, _ := ps.MinBaseFeeWei()
-	baseFee := minBaseFee
 	if backlog > tolerance*speedLimit {
 		excess := int64(backlog - tolerance*speedLimit)
-		exponentBips := arbmath.NaturalToBips(excess) / arbmath.Bips(inertia*speedLimit)
+		return arbmath.NaturalToBips(excess) / arbmath.Bips(inertia*speedLimit)
+	}
+	return 0
+}
+
+func (ps *L2PricingState) setBaseFeeFromExponent(exponentBips arbmath.Bips) {
+	minBaseFee, _ := ps.MinBaseFeeWei()
+	baseFee := minBaseFee
+	if exponentBips > 0 {
 		baseFee = arbmath.BigMulByBips(minBaseFee, arbmath.ApproxExpBasisPoints(exponentBips))
 	}
 	_ = ps.SetBaseFeeWei(baseFee)
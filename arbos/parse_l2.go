@@ -12,21 +12,36 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/offchainlabs/nitro/arbos/addressTable"
 	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbos/functiontable"
 	"github.com/offchainlabs/nitro/arbos/util"
 	"github.com/offchainlabs/nitro/util/arbmath"
 )
 
 type InfallibleBatchFetcher func(batchNum uint64, batchHash common.Hash) []byte
 
-func ParseL2Transactions(msg *arbostypes.L1IncomingMessage, chainId *big.Int, batchFetcher InfallibleBatchFetcher) (types.Transactions, error) {
+// ParseL2Transactions decodes the transactions of an incoming message. Deposits are delivered by the parent chain's
+// inbox in depositDecimals, which is arbostypes.EthDecimals unless the chain has a native token. Address-compressed
+// transactions are decompressed with the address table from MessageAddressTable, and are rejected if it's nil.
+// The ArbOS version is that of the chain before the message, which determines the format of batch posting reports.
+// Likewise, selector-compressed transactions need the function tables from MessageFunctionTable.
+func ParseL2Transactions(
+	msg *arbostypes.L1IncomingMessage,
+	chainId *big.Int,
+	arbosVersion uint64,
+	depositDecimals uint64,
+	addresses *addressTable.AddressTable,
+	functions *functiontable.FunctionTable,
+	batchFetcher InfallibleBatchFetcher,
+) (types.Transactions, error) {
 	if len(msg.L2msg) > arbostypes.MaxL2MessageSize {
 		// ignore the message if l2msg is too large
 		return nil, errors.New("message too large")
 	}
 	switch msg.Header.Kind {
 	case arbostypes.L1MessageType_L2Message:
-		return parseL2Message(bytes.NewReader(msg.L2msg), msg.Header.Poster, msg.Header.Timestamp, msg.Header.RequestId, chainId, 0)
+		return parseL2Message(bytes.NewReader(msg.L2msg), msg.Header.Poster, msg.Header.Timestamp, msg.Header.RequestId, chainId, addresses, functions, 0)
 	case arbostypes.L1MessageType_Initialize:
 		return nil, errors.New("ParseL2Transactions encounted initialize message (should've been handled explicitly at genesis)")
 	case arbostypes.L1MessageType_EndOfBlock:
@@ -54,7 +69,7 @@ func ParseL2Transactions(msg *arbostypes.L1IncomingMessage, chainId *big.Int, ba
 		})
 		return types.Transactions{deposit, tx}, nil
 	case arbostypes.L1MessageType_SubmitRetryable:
-		tx, err := parseSubmitRetryableMessage(bytes.NewReader(msg.L2msg), msg.Header, chainId)
+		tx, err := parseSubmitRetryableMessage(bytes.NewReader(msg.L2msg), msg.Header, chainId, depositDecimals)
 		if err != nil {
 			return nil, err
 		}
@@ -62,7 +77,7 @@ func ParseL2Transactions(msg *arbostypes.L1IncomingMessage, chainId *big.Int, ba
 	case arbostypes.L1MessageType_BatchForGasEstimation:
 		return nil, errors.New("L1 message type BatchForGasEstimation is unimplemented")
 	case arbostypes.L1MessageType_EthDeposit:
-		tx, err := parseEthDepositMessage(bytes.NewReader(msg.L2msg), msg.Header, chainId)
+		tx, err := parseEthDepositMessage(bytes.NewReader(msg.L2msg), msg.Header, chainId, depositDecimals)
 		if err != nil {
 			return nil, err
 		}
@@ -71,7 +86,7 @@ func ParseL2Transactions(msg *arbostypes.L1IncomingMessage, chainId *big.Int, ba
 		log.Debug("ignoring rollup event message")
 		return types.Transactions{}, nil
 	case arbostypes.L1MessageType_BatchPostingReport:
-		tx, err := parseBatchPostingReportMessage(bytes.NewReader(msg.L2msg), chainId, msg.BatchGasCost, batchFetcher)
+		tx, err := parseBatchPostingReportMessage(bytes.NewReader(msg.L2msg), chainId, arbosVersion, msg.BatchGasCost, batchFetcher)
 		if err != nil {
 			return nil, err
 		}
@@ -95,6 +110,8 @@ const (
 	L2MessageKind_Heartbeat          = 6 // deprecated
 	L2MessageKind_SignedCompressedTx = 7
 	// 8 is reserved for BLS signed batch
+	L2MessageKind_AddressCompressedTx  = 9  // a signed tx whose recipient is replaced by its index in the address table
+	L2MessageKind_SelectorCompressedTx = 10 // a signed tx whose calldata prefix is replaced by its index in a function table
 )
 
 // Warning: this does not validate the day of the week or if DST is being observed
@@ -108,7 +125,16 @@ func parseTimeOrPanic(format string, value string) time.Time {
 
 var HeartbeatsDisabledAt = uint64(parseTimeOrPanic(time.RFC1123, "Mon, 08 Aug 2022 16:00:00 GMT").Unix())
 
-func parseL2Message(rd io.Reader, poster common.Address, timestamp uint64, requestId *common.Hash, chainId *big.Int, depth int) (types.Transactions, error) {
+func parseL2Message(
+	rd io.Reader,
+	poster common.Address,
+	timestamp uint64,
+	requestId *common.Hash,
+	chainId *big.Int,
+	addresses *addressTable.AddressTable,
+	functions *functiontable.FunctionTable,
+	depth int,
+) (types.Transactions, error) {
 	var l2KindBuf [1]byte
 	if _, err := rd.Read(l2KindBuf[:]); err != nil {
 		return nil, err
@@ -148,7 +174,7 @@ func parseL2Message(rd io.Reader, poster common.Address, timestamp uint64, reque
 				subRequestId := crypto.Keccak256Hash(requestId[:], arbmath.U256Bytes(index))
 				nextRequestId = &subRequestId
 			}
-			nestedSegments, err := parseL2Message(bytes.NewReader(nextMsg), poster, timestamp, nextRequestId, chainId, depth+1)
+			nestedSegments, err := parseL2Message(bytes.NewReader(nextMsg), poster, timestamp, nextRequestId, chainId, addresses, functions, depth+1)
 			if err != nil {
 				return nil, err
 			}
@@ -179,6 +205,34 @@ func parseL2Message(rd io.Reader, poster common.Address, timestamp uint64, reque
 		return nil, nil
 	case L2MessageKind_SignedCompressedTx:
 		return nil, errors.New("L2 message kind SignedCompressedTx is unimplemented")
+	case L2MessageKind_AddressCompressedTx:
+		if addresses == nil {
+			return nil, errors.New("L2 message kind AddressCompressedTx isn't supported before ArbOS 21")
+		}
+		// Safe to read in its entirety, as all input readers are limited
+		readBytes, err := io.ReadAll(rd)
+		if err != nil {
+			return nil, err
+		}
+		newTx, err := parseAddressCompressedTx(readBytes, addresses)
+		if err != nil {
+			return nil, err
+		}
+		return types.Transactions{newTx}, nil
+	case L2MessageKind_SelectorCompressedTx:
+		if addresses == nil || functions == nil {
+			return nil, errors.New("L2 message kind SelectorCompressedTx isn't supported before ArbOS 21")
+		}
+		// Safe to read in its entirety, as all input readers are limited
+		readBytes, err := io.ReadAll(rd)
+		if err != nil {
+			return nil, err
+		}
+		newTx, err := parseSelectorCompressedTx(readBytes, addresses, functions)
+		if err != nil {
+			return nil, err
+		}
+		return types.Transactions{newTx}, nil
 	default:
 		// ignore invalid message kind
 		return nil, fmt.Errorf("unkown L2 message kind %v", l2KindBuf[0])
@@ -268,7 +322,7 @@ func parseUnsignedTx(rd io.Reader, poster common.Address, requestId *common.Hash
 	return types.NewTx(inner), nil
 }
 
-func parseEthDepositMessage(rd io.Reader, header *arbostypes.L1IncomingMessageHeader, chainId *big.Int) (*types.Transaction, error) {
+func parseEthDepositMessage(rd io.Reader, header *arbostypes.L1IncomingMessageHeader, chainId *big.Int, depositDecimals uint64) (*types.Transaction, error) {
 	to, err := util.AddressFromReader(rd)
 	if err != nil {
 		return nil, err
@@ -285,12 +339,12 @@ func parseEthDepositMessage(rd io.Reader, header *arbostypes.L1IncomingMessageHe
 		L1RequestId: *header.RequestId,
 		From:        header.Poster,
 		To:          to,
-		Value:       balance.Big(),
+		Value:       arbostypes.ScaleNativeTokenDeposit(balance.Big(), depositDecimals),
 	}
 	return types.NewTx(tx), nil
 }
 
-func parseSubmitRetryableMessage(rd io.Reader, header *arbostypes.L1IncomingMessageHeader, chainId *big.Int) (*types.Transaction, error) {
+func parseSubmitRetryableMessage(rd io.Reader, header *arbostypes.L1IncomingMessageHeader, chainId *big.Int, depositDecimals uint64) (*types.Transaction, error) {
 	retryTo, err := util.AddressFrom256FromReader(rd)
 	if err != nil {
 		return nil, err
@@ -357,7 +411,7 @@ func parseSubmitRetryableMessage(rd io.Reader, header *arbostypes.L1IncomingMess
 		RequestId:        *header.RequestId,
 		From:             header.Poster,
 		L1BaseFee:        header.L1BaseFee,
-		DepositValue:     depositValue.Big(),
+		DepositValue:     arbostypes.ScaleNativeTokenDeposit(depositValue.Big(), depositDecimals),
 		GasFeeCap:        maxFeePerGas.Big(),
 		Gas:              gasLimitBig.Uint64(),
 		RetryTo:          pRetryTo,
@@ -370,11 +424,22 @@ func parseSubmitRetryableMessage(rd io.Reader, header *arbostypes.L1IncomingMess
 	return types.NewTx(tx), err
 }
 
-func parseBatchPostingReportMessage(rd io.Reader, chainId *big.Int, msgBatchGasCost *uint64, batchFetcher InfallibleBatchFetcher) (*types.Transaction, error) {
+func parseBatchPostingReportMessage(
+	rd io.Reader, chainId *big.Int, arbosVersion uint64, msgBatchGasCost *uint64, batchFetcher InfallibleBatchFetcher,
+) (*types.Transaction, error) {
 	batchTimestamp, batchPosterAddr, batchHash, batchNum, l1BaseFee, extraGas, err := arbostypes.ParseBatchPostingReportMessageFields(rd)
 	if err != nil {
 		return nil, err
 	}
+	var blobGasUsed uint64
+	blobBaseFee := common.Big0
+	if arbosVersion >= arbostypes.ArbosVersion_BlobPricing {
+		blobGasUsed, blobBaseFee, err = arbostypes.ParseBatchPostingReportBlobFields(rd)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// a batch posted as blobs still pays for its calldata, and its blobs are reported on top
 	var batchDataGas uint64
 	if msgBatchGasCost != nil {
 		batchDataGas = *msgBatchGasCost
@@ -384,9 +449,16 @@ func parseBatchPostingReportMessage(rd io.Reader, chainId *big.Int, msgBatchGasC
 	}
 	batchDataGas = arbmath.SaturatingUAdd(batchDataGas, extraGas)
 
-	data, err := util.PackInternalTxDataBatchPostingReport(
-		batchTimestamp, batchPosterAddr, batchNum, batchDataGas, l1BaseFee,
-	)
+	var data []byte
+	if blobGasUsed > 0 {
+		data, err = util.PackInternalTxDataBatchPostingReportWithBlobs(
+			batchTimestamp, batchPosterAddr, batchNum, batchDataGas, l1BaseFee, blobGasUsed, blobBaseFee,
+		)
+	} else {
+		data, err = util.PackInternalTxDataBatchPostingReport(
+			batchTimestamp, batchPosterAddr, batchNum, batchDataGas, l1BaseFee,
+		)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,135 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package pricehistory
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+// HistoryLength is how many blocks' prices are kept
+const HistoryLength = 256
+
+var ErrInvalidBlockCount = errors.New("block count must be between 1 and the number of blocks with recorded prices")
+
+// PriceHistory is a ring buffer of the L2 basefee and L1 price per unit at the start of each of the last
+// HistoryLength blocks, which ArbOS records from version 21 onwards.
+//
+// Each block's prices share a slot, with the basefee in the upper 128 bits and the L1 price in the lower 128,
+// so recording them costs a single write per block. Prices that don't fit are saturated.
+type PriceHistory struct {
+	backingStorage *storage.Storage
+	firstBlock     storage.StorageBackedUint64 // the oldest block recorded since the history was last contiguous
+	nextBlock      storage.StorageBackedUint64 // one more than the latest block recorded, or 0 if none has been
+}
+
+const (
+	firstBlockOffset uint64 = iota
+	nextBlockOffset
+	entriesOffset
+)
+
+// PriceStats summarizes a price over a range of blocks
+type PriceStats struct {
+	Min     *big.Int
+	Max     *big.Int
+	Average *big.Int
+}
+
+func InitializePriceHistory(sto *storage.Storage) {
+	// no need to do anything, the history starts empty
+}
+
+func OpenPriceHistory(sto *storage.Storage) *PriceHistory {
+	return &PriceHistory{
+		sto.WithoutCache(),
+		sto.OpenStorageBackedUint64(firstBlockOffset),
+		sto.OpenStorageBackedUint64(nextBlockOffset),
+	}
+}
+
+// RecordBlock records the prices at the start of a block. Blocks already recorded are ignored,
+// and skipping blocks restarts the history, since the skipped blocks' entries would be stale.
+func (h *PriceHistory) RecordBlock(blockNumber uint64, l2BaseFee, l1PricePerUnit *big.Int) error {
+	nextBlock, err := h.nextBlock.Get()
+	if err != nil {
+		return err
+	}
+	if nextBlock != 0 && blockNumber < nextBlock {
+		return nil
+	}
+	if nextBlock == 0 || blockNumber > nextBlock {
+		if err := h.firstBlock.Set(blockNumber); err != nil {
+			return err
+		}
+	}
+	entry := common.BytesToHash(append(saturatingUint128(l2BaseFee), saturatingUint128(l1PricePerUnit)...))
+	if err := h.backingStorage.SetByUint64(entriesOffset+blockNumber%HistoryLength, entry); err != nil {
+		return err
+	}
+	return h.nextBlock.Set(blockNumber + 1)
+}
+
+// RecordedBlocks gets how many of the latest blocks have prices recorded
+func (h *PriceHistory) RecordedBlocks() (uint64, error) {
+	firstBlock, err := h.firstBlock.Get()
+	if err != nil {
+		return 0, err
+	}
+	nextBlock, err := h.nextBlock.Get()
+	if err != nil || nextBlock == 0 {
+		return 0, err
+	}
+	return arbmath.MinInt(nextBlock-firstBlock, HistoryLength), nil
+}
+
+// Stats gets the minimum, maximum, and average L2 basefee and L1 price per unit over the latest blocks recorded
+func (h *PriceHistory) Stats(blocks uint64) (PriceStats, PriceStats, error) {
+	recorded, err := h.RecordedBlocks()
+	if err != nil {
+		return PriceStats{}, PriceStats{}, err
+	}
+	if blocks == 0 || blocks > recorded {
+		return PriceStats{}, PriceStats{}, ErrInvalidBlockCount
+	}
+	nextBlock, err := h.nextBlock.Get()
+	if err != nil {
+		return PriceStats{}, PriceStats{}, err
+	}
+	l2BaseFees := make([]*big.Int, 0, blocks)
+	l1Prices := make([]*big.Int, 0, blocks)
+	for number := nextBlock - blocks; number < nextBlock; number++ {
+		entry, err := h.backingStorage.GetByUint64(entriesOffset + number%HistoryLength)
+		if err != nil {
+			return PriceStats{}, PriceStats{}, err
+		}
+		l2BaseFees = append(l2BaseFees, new(big.Int).SetBytes(entry[:16]))
+		l1Prices = append(l1Prices, new(big.Int).SetBytes(entry[16:]))
+	}
+	return summarize(l2BaseFees), summarize(l1Prices), nil
+}
+
+func summarize(prices []*big.Int) PriceStats {
+	stats := PriceStats{prices[0], prices[0], new(big.Int)}
+	for _, price := range prices {
+		stats.Min = arbmath.BigMin(stats.Min, price)
+		stats.Max = arbmath.BigMax(stats.Max, price)
+		stats.Average.Add(stats.Average, price)
+	}
+	stats.Average = arbmath.BigDivByUint(stats.Average, uint64(len(prices)))
+	return stats
+}
+
+var maxUint128 = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 128), common.Big1)
+
+func saturatingUint128(value *big.Int) []byte {
+	if value.Sign() < 0 {
+		value = common.Big0
+	}
+	return common.LeftPadBytes(arbmath.BigMin(value, maxUint128).Bytes(), 16)
+}
@@ -0,0 +1,77 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package pricehistory
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestPriceHistoryStats(t *testing.T) {
+	sto := storage.NewMemoryBacked(burn.NewSystemBurner(nil, false))
+	InitializePriceHistory(sto)
+	history := OpenPriceHistory(sto)
+
+	if _, _, err := history.Stats(1); !errors.Is(err, ErrInvalidBlockCount) {
+		Fail(t, "got stats from an empty history", err)
+	}
+
+	// record blocks 100 through 399, with the basefee rising by one per block and the L1 price falling
+	for number := uint64(100); number < 400; number++ {
+		Require(t, history.RecordBlock(number, big.NewInt(int64(number)), big.NewInt(int64(1000-number))))
+	}
+	// recording a block again changes nothing
+	Require(t, history.RecordBlock(399, big.NewInt(0), big.NewInt(0)))
+
+	recorded, err := history.RecordedBlocks()
+	Require(t, err)
+	if recorded != HistoryLength {
+		Fail(t, "wrong number of recorded blocks", recorded)
+	}
+	l2, l1, err := history.Stats(4)
+	Require(t, err)
+	if l2.Min.Int64() != 396 || l2.Max.Int64() != 399 || l2.Average.Int64() != 397 {
+		Fail(t, "wrong basefee stats", l2)
+	}
+	if l1.Min.Int64() != 601 || l1.Max.Int64() != 604 || l1.Average.Int64() != 602 {
+		Fail(t, "wrong L1 price stats", l1)
+	}
+	l2, _, err = history.Stats(HistoryLength)
+	Require(t, err)
+	if l2.Min.Int64() != 400-HistoryLength || l2.Max.Int64() != 399 {
+		Fail(t, "wrong basefee stats over the whole history", l2)
+	}
+	if _, _, err := history.Stats(HistoryLength + 1); !errors.Is(err, ErrInvalidBlockCount) {
+		Fail(t, "got stats beyond the history", err)
+	}
+
+	// skipping blocks restarts the history
+	huge := new(big.Int).Lsh(big.NewInt(1), 200)
+	Require(t, history.RecordBlock(500, huge, big.NewInt(7)))
+	recorded, err = history.RecordedBlocks()
+	Require(t, err)
+	if recorded != 1 {
+		Fail(t, "skipping blocks didn't restart the history", recorded)
+	}
+	l2, l1, err = history.Stats(1)
+	Require(t, err)
+	if l2.Max.Cmp(maxUint128) != 0 || l1.Max.Int64() != 7 {
+		Fail(t, "prices weren't saturated to 128 bits", l2, l1)
+	}
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
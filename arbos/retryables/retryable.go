@@ -174,6 +174,10 @@ func (retryable *Retryable) Beneficiary() (common.Address, error) {
 	return retryable.beneficiary.Get()
 }
 
+func (retryable *Retryable) SetBeneficiary(beneficiary common.Address) error {
+	return retryable.beneficiary.Set(beneficiary)
+}
+
 func (retryable *Retryable) CalculateTimeout() (uint64, error) {
 	timeout, err := retryable.timeout.Get()
 	if err != nil {
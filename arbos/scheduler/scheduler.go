@@ -0,0 +1,572 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package scheduler
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+// PrecompileAddress is where the ArbScheduler precompile lives
+var PrecompileAddress = common.HexToAddress("0x72")
+
+const InitialMaxGasPerBlock = 4_000_000
+const MaxChecksPerBlock = 16       // bounds the calls looked at in each phase at the start of each block
+const MaxBucketChecksPerBlock = 64 // bounds the buckets looked at at the start of each block
+const TimeBucketSeconds = 60       // calls waiting for a timestamp are grouped by the minute
+const BlockBucketSize = 16         // calls waiting for a block number are grouped by 16 blocks
+
+var ErrCallIsDue = errors.New("scheduled call is already being executed")
+
+// SchedulerState tracks calls contracts have asked ArbOS to make on their behalf in a future block.
+// Each call's value and gas are prepaid into an escrow account when it's scheduled. Calls waiting for
+// their timestamp or block number are kept in buckets ordered by when they become due, so that calls
+// scheduled far in the future are never looked at before then. Once both are reached, a call moves
+// to the ready queue, where it waits for room in a block's gas limit and a basefee within its fee cap.
+type SchedulerState struct {
+	calls          *storage.Storage
+	Queue          *storage.Queue // calls whose timestamp and block number have been reached
+	dueQueue       *storage.Queue // calls collected at the start of the current block
+	timeBuckets    *bucketQueue   // calls waiting for their timestamp
+	blockBuckets   *bucketQueue   // calls waiting for their block number
+	nextId         storage.StorageBackedUint64
+	maxGasPerBlock storage.StorageBackedUint64
+}
+
+var (
+	queueKey        = []byte{0}
+	dueQueueKey     = []byte{1}
+	calldataKey     = []byte{2}
+	timeBucketsKey  = []byte{3}
+	blockBucketsKey = []byte{4}
+)
+
+const (
+	nextIdOffset uint64 = iota
+	maxGasPerBlockOffset
+)
+
+func InitializeScheduler(sto *storage.Storage) error {
+	if err := storage.InitializeQueue(sto.OpenCachedSubStorage(queueKey)); err != nil {
+		return err
+	}
+	if err := storage.InitializeQueue(sto.OpenCachedSubStorage(dueQueueKey)); err != nil {
+		return err
+	}
+	return sto.SetUint64ByUint64(maxGasPerBlockOffset, InitialMaxGasPerBlock)
+}
+
+func OpenScheduler(sto *storage.Storage) *SchedulerState {
+	return &SchedulerState{
+		sto,
+		storage.OpenQueue(sto.OpenCachedSubStorage(queueKey)),
+		storage.OpenQueue(sto.OpenCachedSubStorage(dueQueueKey)),
+		openBucketQueue(sto.OpenCachedSubStorage(timeBucketsKey), TimeBucketSeconds),
+		openBucketQueue(sto.OpenCachedSubStorage(blockBucketsKey), BlockBucketSize),
+		sto.OpenStorageBackedUint64(nextIdOffset),
+		sto.OpenStorageBackedUint64(maxGasPerBlockOffset),
+	}
+}
+
+// bucketQueue orders calls by the timestamp or block number at which they become due.
+// Calls are grouped into buckets of a fixed size, each of which is a queue, and a bucket
+// is only emptied once all of it has been reached. This delays a call by less than a bucket,
+// which is allowed as calls are never run before they're due.
+type bucketQueue struct {
+	sto        *storage.Storage
+	bucketSize uint64
+	next       storage.StorageBackedUint64 // the earliest bucket that hasn't been emptied
+	pending    storage.StorageBackedUint64 // the number of calls across all buckets
+}
+
+func openBucketQueue(sto *storage.Storage, bucketSize uint64) *bucketQueue {
+	return &bucketQueue{
+		sto,
+		bucketSize,
+		sto.OpenStorageBackedUint64(0),
+		sto.OpenStorageBackedUint64(1),
+	}
+}
+
+func (b *bucketQueue) bucket(index uint64) *storage.Storage {
+	return b.sto.OpenSubStorage(arbmath.UintToBytes(index))
+}
+
+// put adds a call that becomes due at the given position, which is after the current one
+func (b *bucketQueue) put(id common.Hash, due uint64, current uint64) error {
+	pending, err := b.pending.Get()
+	if err != nil {
+		return err
+	}
+	next, err := b.next.Get()
+	if err != nil {
+		return err
+	}
+	if pending == 0 && next < current/b.bucketSize {
+		// nothing is waiting, so skip the empty buckets up to the current one
+		next = current / b.bucketSize
+		if err := b.next.Set(next); err != nil {
+			return err
+		}
+	}
+	index := arbmath.MaxInt(due/b.bucketSize, next)
+	sto := b.bucket(index)
+	nextPutOffset, err := sto.GetUint64ByUint64(0)
+	if err != nil {
+		return err
+	}
+	if nextPutOffset == 0 {
+		if err := storage.InitializeQueue(sto); err != nil {
+			return err
+		}
+	}
+	if err := storage.OpenQueue(sto).Put(id); err != nil {
+		return err
+	}
+	return b.pending.Set(pending + 1)
+}
+
+// pop removes a call from the earliest bucket which has been entirely reached at the current position.
+// Returns nil if there is none, or if the given number of bucket checks has been used up.
+func (b *bucketQueue) pop(current uint64, checks *int) (*common.Hash, error) {
+	pending, err := b.pending.Get()
+	if err != nil || pending == 0 {
+		return nil, err
+	}
+	next, err := b.next.Get()
+	if err != nil {
+		return nil, err
+	}
+	for *checks > 0 && arbmath.SaturatingUMul(next+1, b.bucketSize) <= arbmath.SaturatingUAdd(current, 1) {
+		*checks--
+		sto := b.bucket(next)
+		id, err := storage.OpenQueue(sto).Get()
+		if err != nil {
+			return nil, err
+		}
+		if id != nil {
+			return id, b.pending.Set(pending - 1)
+		}
+		// the bucket is empty, so clear its queue's offsets and move on to the next one
+		if err := sto.ClearByUint64(0); err != nil {
+			return nil, err
+		}
+		if err := sto.ClearByUint64(1); err != nil {
+			return nil, err
+		}
+		next++
+		if err := b.next.Set(next); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+type ScheduledCall struct {
+	id             common.Hash // not backed by storage; this key determines where it lives in storage
+	backingStorage *storage.Storage
+	from           storage.StorageBackedAddress
+	to             storage.StorageBackedAddress
+	callvalue      storage.StorageBackedBigUint
+	gas            storage.StorageBackedUint64
+	maxFeePerGas   storage.StorageBackedBigUint
+	notBeforeBlock storage.StorageBackedUint64
+	notBeforeTime  storage.StorageBackedUint64
+	due            storage.StorageBackedUint64
+	calldata       storage.StorageBackedBytes
+}
+
+const (
+	fromOffset uint64 = iota
+	toOffset
+	callvalueOffset
+	gasOffset
+	maxFeePerGasOffset
+	notBeforeBlockOffset
+	notBeforeTimeOffset
+	dueOffset
+)
+
+func (s *SchedulerState) openCall(id common.Hash) *ScheduledCall {
+	sto := s.calls.OpenSubStorage(id.Bytes())
+	return &ScheduledCall{
+		id,
+		sto,
+		sto.OpenStorageBackedAddress(fromOffset),
+		sto.OpenStorageBackedAddress(toOffset),
+		sto.OpenStorageBackedBigUint(callvalueOffset),
+		sto.OpenStorageBackedUint64(gasOffset),
+		sto.OpenStorageBackedBigUint(maxFeePerGasOffset),
+		sto.OpenStorageBackedUint64(notBeforeBlockOffset),
+		sto.OpenStorageBackedUint64(notBeforeTimeOffset),
+		sto.OpenStorageBackedUint64(dueOffset),
+		sto.OpenStorageBackedBytes(calldataKey),
+	}
+}
+
+// ScheduleCall records a call to be made once both the block number and timestamp are reached.
+// The caller is responsible for moving the call's value and prepaid gas into the call's escrow account.
+func (s *SchedulerState) ScheduleCall(
+	from common.Address,
+	to common.Address,
+	callvalue *big.Int,
+	gas uint64, // we assume the gas is non-zero, as a zero gas marks a deleted call
+	maxFeePerGas *big.Int,
+	notBeforeBlock uint64,
+	notBeforeTime uint64,
+	calldata []byte,
+	blockNumber uint64, // the current block
+	currentTime uint64,
+) (*ScheduledCall, error) {
+	nextId, err := s.nextId.Increment()
+	if err != nil {
+		return nil, err
+	}
+	id := ScheduledCallId(nextId - 1)
+	call := s.openCall(id)
+	_ = call.from.Set(from)
+	_ = call.to.Set(to)
+	_ = call.callvalue.SetChecked(callvalue)
+	_ = call.gas.Set(gas)
+	_ = call.maxFeePerGas.SetChecked(maxFeePerGas)
+	_ = call.notBeforeBlock.Set(notBeforeBlock)
+	_ = call.notBeforeTime.Set(notBeforeTime)
+	_ = call.due.Set(0)
+	_ = call.calldata.Set(calldata)
+
+	// the call waits for its timestamp first, then its block number
+	if notBeforeTime > currentTime {
+		return call, s.timeBuckets.put(id, notBeforeTime, currentTime)
+	}
+	if notBeforeBlock > blockNumber {
+		return call, s.blockBuckets.put(id, notBeforeBlock, blockNumber)
+	}
+	return call, s.Queue.Put(id)
+}
+
+// OpenCall returns the scheduled call with the given id, or nil if there isn't one
+func (s *SchedulerState) OpenCall(id common.Hash) (*ScheduledCall, error) {
+	call := s.openCall(id)
+	gas, err := call.gas.Get()
+	if gas == 0 || err != nil {
+		return nil, err
+	}
+	return call, nil
+}
+
+// IsDue returns whether the call was collected at the start of the current block and is about to run
+func (s *SchedulerState) IsDue(id common.Hash) (bool, error) {
+	call, err := s.OpenCall(id)
+	if call == nil || err != nil {
+		return false, err
+	}
+	due, err := call.due.Get()
+	return due != 0, err
+}
+
+// CancelCall deletes a call that hasn't run yet, returning its escrowed funds to the scheduler
+func (s *SchedulerState) CancelCall(id common.Hash, evm *vm.EVM, scenario util.TracingScenario) error {
+	call, err := s.OpenCall(id)
+	if call == nil || err != nil {
+		return err
+	}
+	due, err := call.due.Get()
+	if err != nil {
+		return err
+	}
+	if due != 0 {
+		return ErrCallIsDue
+	}
+	if err := s.releaseEscrow(call, evm, scenario); err != nil {
+		return err
+	}
+	// the call's id stays in its queue, and is discarded once it reaches the front
+	return call.clear()
+}
+
+func (s *SchedulerState) releaseEscrow(call *ScheduledCall, evm *vm.EVM, scenario util.TracingScenario) error {
+	from, err := call.From()
+	if err != nil {
+		return err
+	}
+	escrow := ScheduledCallEscrowAddress(call.id)
+	amount := evm.StateDB.GetBalance(escrow)
+	return util.TransferBalance(&escrow, &from, amount, evm, scenario, "escrow")
+}
+
+func (call *ScheduledCall) clear() error {
+	// we ignore returned errors for the same reason DeleteRetryable does: the final ClearBytes will fail as well
+	sto := call.backingStorage
+	_ = sto.ClearByUint64(fromOffset)
+	_ = sto.ClearByUint64(toOffset)
+	_ = sto.ClearByUint64(callvalueOffset)
+	_ = sto.ClearByUint64(gasOffset)
+	_ = sto.ClearByUint64(maxFeePerGasOffset)
+	_ = sto.ClearByUint64(notBeforeBlockOffset)
+	_ = sto.ClearByUint64(notBeforeTimeOffset)
+	_ = sto.ClearByUint64(dueOffset)
+	return call.calldata.Clear()
+}
+
+// CollectDueCalls is run at the start of each block. It deletes the calls run in the previous block,
+// then moves the calls whose timestamp and block number have been reached to the ready queue, in the
+// order they became due. Up to MaxChecksPerBlock ready calls are then checked, collecting those whose
+// fee cap covers the basefee; the others are moved to the back of the ready queue. The first call
+// collected may exceed the per-block gas limit, ensuring no call can be stuck behind a lowered limit.
+// The escrow of each collected call is released to its scheduler, who then pays for the call's gas.
+func (s *SchedulerState) CollectDueCalls(
+	blockNumber uint64,
+	currentTime uint64,
+	baseFee *big.Int,
+	evm *vm.EVM,
+	scenario util.TracingScenario,
+) error {
+	for {
+		id, err := s.dueQueue.Get()
+		if err != nil {
+			return err
+		}
+		if id == nil {
+			break
+		}
+		if err := s.openCall(*id).clear(); err != nil {
+			return err
+		}
+	}
+
+	bucketChecks := MaxBucketChecksPerBlock
+	for i := 0; i < MaxChecksPerBlock; i++ {
+		id, err := s.timeBuckets.pop(currentTime, &bucketChecks)
+		if err != nil {
+			return err
+		}
+		if id == nil {
+			break
+		}
+		call, err := s.OpenCall(*id)
+		if err != nil {
+			return err
+		}
+		if call == nil {
+			continue // the call has been canceled
+		}
+		notBeforeBlock, err := call.NotBeforeBlock()
+		if err != nil {
+			return err
+		}
+		if notBeforeBlock > blockNumber {
+			err = s.blockBuckets.put(*id, notBeforeBlock, blockNumber)
+		} else {
+			err = s.Queue.Put(*id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for i := 0; i < MaxChecksPerBlock; i++ {
+		id, err := s.blockBuckets.pop(blockNumber, &bucketChecks)
+		if err != nil {
+			return err
+		}
+		if id == nil {
+			break
+		}
+		// the call's timestamp was reached before it was put in a block bucket
+		if err := s.Queue.Put(*id); err != nil {
+			return err
+		}
+	}
+
+	gasLeft, err := s.maxGasPerBlock.Get()
+	if err != nil {
+		return err
+	}
+	pending, err := s.Queue.Size()
+	if err != nil {
+		return err
+	}
+	checks := arbmath.MinInt(pending, MaxChecksPerBlock)
+	collected := 0
+
+	for i := uint64(0); i < checks; i++ {
+		id, err := s.Queue.Peek()
+		if err != nil || id == nil {
+			return err
+		}
+		call, err := s.OpenCall(*id)
+		if err != nil {
+			return err
+		}
+		if call == nil {
+			// The call has been canceled, so discard the peeked entry
+			if _, err := s.Queue.Get(); err != nil {
+				return err
+			}
+			continue
+		}
+		maxFeePerGas, err := call.MaxFeePerGas()
+		if err != nil {
+			return err
+		}
+		if arbmath.BigLessThan(maxFeePerGas, baseFee) {
+			// check the call again once the rest of the ready calls have been looked at
+			if _, err := s.Queue.Get(); err != nil {
+				return err
+			}
+			if err := s.Queue.Put(*id); err != nil {
+				return err
+			}
+			continue
+		}
+		gas, err := call.Gas()
+		if err != nil {
+			return err
+		}
+		if gas > gasLeft && collected > 0 {
+			// keep the call at the front of the queue for the next block
+			break
+		}
+		if _, err := s.Queue.Get(); err != nil {
+			return err
+		}
+		if err := s.releaseEscrow(call, evm, scenario); err != nil {
+			return err
+		}
+		if err := call.due.Set(1); err != nil {
+			return err
+		}
+		if err := s.dueQueue.Put(*id); err != nil {
+			return err
+		}
+		gasLeft = arbmath.SaturatingUSub(gasLeft, gas)
+		collected++
+	}
+	return nil
+}
+
+// DueCalls makes the transactions for the calls collected at the start of the current block
+func (s *SchedulerState) DueCalls(chainId *big.Int, baseFee *big.Int) ([]*types.ArbitrumContractTx, error) {
+	ids := []common.Hash{}
+	err := s.dueQueue.ForEach(func(_ uint64, id common.Hash) (bool, error) {
+		ids = append(ids, id)
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	txs := []*types.ArbitrumContractTx{}
+	for _, id := range ids {
+		call, err := s.OpenCall(id)
+		if err != nil {
+			return nil, err
+		}
+		if call == nil {
+			continue
+		}
+		tx, err := call.MakeTx(chainId, baseFee)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+func (s *SchedulerState) MaxGasPerBlock() (uint64, error) {
+	return s.maxGasPerBlock.Get()
+}
+
+func (s *SchedulerState) SetMaxGasPerBlock(limit uint64) error {
+	return s.maxGasPerBlock.Set(limit)
+}
+
+func (call *ScheduledCall) Id() common.Hash {
+	return call.id
+}
+
+func (call *ScheduledCall) From() (common.Address, error) {
+	return call.from.Get()
+}
+
+func (call *ScheduledCall) To() (common.Address, error) {
+	return call.to.Get()
+}
+
+func (call *ScheduledCall) Callvalue() (*big.Int, error) {
+	return call.callvalue.Get()
+}
+
+func (call *ScheduledCall) Gas() (uint64, error) {
+	return call.gas.Get()
+}
+
+func (call *ScheduledCall) MaxFeePerGas() (*big.Int, error) {
+	return call.maxFeePerGas.Get()
+}
+
+func (call *ScheduledCall) NotBeforeBlock() (uint64, error) {
+	return call.notBeforeBlock.Get()
+}
+
+func (call *ScheduledCall) NotBeforeTime() (uint64, error) {
+	return call.notBeforeTime.Get()
+}
+
+func (call *ScheduledCall) Calldata() ([]byte, error) {
+	return call.calldata.Get()
+}
+
+func (call *ScheduledCall) MakeTx(chainId *big.Int, gasFeeCap *big.Int) (*types.ArbitrumContractTx, error) {
+	from, err := call.From()
+	if err != nil {
+		return nil, err
+	}
+	to, err := call.To()
+	if err != nil {
+		return nil, err
+	}
+	callvalue, err := call.Callvalue()
+	if err != nil {
+		return nil, err
+	}
+	gas, err := call.Gas()
+	if err != nil {
+		return nil, err
+	}
+	calldata, err := call.Calldata()
+	if err != nil {
+		return nil, err
+	}
+	return &types.ArbitrumContractTx{
+		ChainId:   chainId,
+		RequestId: call.id,
+		From:      from,
+		GasFeeCap: gasFeeCap,
+		Gas:       gas,
+		To:        &to,
+		Value:     callvalue,
+		Data:      calldata,
+	}, nil
+}
+
+func ScheduledCallId(index uint64) common.Hash {
+	return crypto.Keccak256Hash([]byte("scheduled call"), arbmath.UintToBytes(index))
+}
+
+func ScheduledCallEscrowAddress(id common.Hash) common.Address {
+	return common.BytesToAddress(crypto.Keccak256([]byte("scheduled call escrow"), id.Bytes()))
+}
+
+// ScheduledCallPrepayment is the amount that must be escrowed when scheduling a call
+func ScheduledCallPrepayment(callvalue *big.Int, gas uint64, maxFeePerGas *big.Int) *big.Int {
+	return arbmath.BigAdd(callvalue, arbmath.BigMulByUint(maxFeePerGas, gas))
+}
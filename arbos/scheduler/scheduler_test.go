@@ -0,0 +1,203 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package scheduler
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestScheduledCallLifecycle(t *testing.T) {
+	statedb := storage.NewMemoryBackedStateDB()
+	sto := storage.NewGeth(statedb, burn.NewSystemBurner(nil, false))
+	Require(t, InitializeScheduler(sto))
+	sched := OpenScheduler(sto)
+	evm := vm.NewEVM(vm.BlockContext{}, vm.TxContext{}, statedb, &params.ChainConfig{}, vm.Config{})
+	chainId := big.NewInt(412346)
+
+	from := common.BytesToAddress([]byte{1})
+	to := common.BytesToAddress([]byte{2})
+	callvalue := big.NewInt(5)
+	maxFeePerGas := big.NewInt(params.GWei)
+	lowBaseFee := big.NewInt(params.GWei / 10)
+	highBaseFee := big.NewInt(2 * params.GWei)
+
+	schedule := func(notBeforeBlock, notBeforeTime uint64) (common.Hash, *big.Int) {
+		t.Helper()
+		call, err := sched.ScheduleCall(from, to, callvalue, 100_000, maxFeePerGas, notBeforeBlock, notBeforeTime, []byte{0xab}, 1, 1)
+		Require(t, err)
+		prepayment := ScheduledCallPrepayment(callvalue, 100_000, maxFeePerGas)
+		statedb.AddBalance(ScheduledCallEscrowAddress(call.Id()), prepayment)
+		return call.Id(), prepayment
+	}
+	collect := func(blockNumber, currentTime uint64, baseFee *big.Int) {
+		t.Helper()
+		Require(t, sched.CollectDueCalls(blockNumber, currentTime, baseFee, evm, util.TracingDuringEVM))
+	}
+	checkDue := func(expected ...common.Hash) {
+		t.Helper()
+		txs, err := sched.DueCalls(chainId, lowBaseFee)
+		Require(t, err)
+		if len(txs) != len(expected) {
+			Fail(t, "expected", len(expected), "due calls but got", len(txs))
+		}
+		for i, tx := range txs {
+			if tx.RequestId != expected[i] || tx.From != from || *tx.To != to || tx.Value.Cmp(callvalue) != 0 {
+				Fail(t, "unexpected due call", tx)
+			}
+			due, err := sched.IsDue(tx.RequestId)
+			Require(t, err)
+			if !due {
+				Fail(t, "collected call isn't due")
+			}
+		}
+	}
+
+	byBlock, prepayment := schedule(20, 0)
+	byTime, _ := schedule(0, 130)
+	if byBlock == byTime {
+		Fail(t, "scheduled calls share an id")
+	}
+
+	collect(5, 50, lowBaseFee)
+	checkDue()
+
+	// block 20 is in the bucket of blocks 16 to 31, which is only emptied once all of it is reached
+	collect(30, 50, lowBaseFee)
+	checkDue()
+
+	// the block has been reached, but the basefee is above the call's fee cap
+	collect(31, 50, highBaseFee)
+	checkDue()
+	size, err := sched.Queue.Size()
+	Require(t, err)
+	if size != 1 {
+		Fail(t, "call whose block was reached isn't ready", size)
+	}
+
+	collect(32, 50, lowBaseFee)
+	checkDue(byBlock)
+	if statedb.GetBalance(from).Cmp(prepayment) != 0 {
+		Fail(t, "escrow wasn't released to the scheduler")
+	}
+	if err := sched.CancelCall(byBlock, evm, util.TracingDuringEVM); err != ErrCallIsDue {
+		Fail(t, "canceled a call that's being executed", err)
+	}
+
+	Require(t, sched.CancelCall(byTime, evm, util.TracingDuringEVM))
+	if statedb.GetBalance(from).Cmp(new(big.Int).Mul(prepayment, big.NewInt(2))) != 0 {
+		Fail(t, "canceled call wasn't refunded")
+	}
+	call, err := sched.OpenCall(byTime)
+	Require(t, err)
+	if call != nil {
+		Fail(t, "canceled call still exists")
+	}
+
+	// the next block deletes the executed call and discards the canceled one
+	collect(33, 200, lowBaseFee)
+	checkDue()
+	call, err = sched.OpenCall(byBlock)
+	Require(t, err)
+	if call != nil {
+		Fail(t, "executed call wasn't deleted")
+	}
+	size, err = sched.Queue.Size()
+	Require(t, err)
+	if size != 0 {
+		Fail(t, "queue wasn't emptied", size)
+	}
+}
+
+func TestScheduledCallsOrderedByDueTime(t *testing.T) {
+	statedb := storage.NewMemoryBackedStateDB()
+	sto := storage.NewGeth(statedb, burn.NewSystemBurner(nil, false))
+	Require(t, InitializeScheduler(sto))
+	sched := OpenScheduler(sto)
+	evm := vm.NewEVM(vm.BlockContext{}, vm.TxContext{}, statedb, &params.ChainConfig{}, vm.Config{})
+	startTime := uint64(1_000_000)
+
+	// many calls far in the future mustn't delay one that's due sooner
+	for i := 0; i < 4*MaxChecksPerBlock; i++ {
+		_, err := sched.ScheduleCall(common.Address{}, common.Address{}, common.Big0, 100_000, common.Big1, 0, startTime+3600, nil, 1, startTime)
+		Require(t, err)
+	}
+	soon, err := sched.ScheduleCall(common.Address{}, common.Address{}, common.Big0, 100_000, common.Big1, 0, startTime+30, nil, 1, startTime)
+	Require(t, err)
+
+	for block, time := uint64(2), startTime; time < startTime+2*TimeBucketSeconds; block, time = block+1, time+1 {
+		Require(t, sched.CollectDueCalls(block, time, common.Big1, evm, util.TracingDuringEVM))
+		txs, err := sched.DueCalls(common.Big1, common.Big1)
+		Require(t, err)
+		if len(txs) == 0 {
+			continue
+		}
+		if len(txs) != 1 || txs[0].RequestId != soon.Id() {
+			Fail(t, "unexpected calls collected at time", time, txs)
+		}
+		if time < startTime+30 {
+			Fail(t, "call collected before it was due")
+		}
+		return
+	}
+	Fail(t, "call wasn't collected within two buckets of being due")
+}
+
+func TestScheduledCallsGasLimit(t *testing.T) {
+	statedb := storage.NewMemoryBackedStateDB()
+	sto := storage.NewGeth(statedb, burn.NewSystemBurner(nil, false))
+	Require(t, InitializeScheduler(sto))
+	sched := OpenScheduler(sto)
+	evm := vm.NewEVM(vm.BlockContext{}, vm.TxContext{}, statedb, &params.ChainConfig{}, vm.Config{})
+	Require(t, sched.SetMaxGasPerBlock(250_000))
+
+	ids := []common.Hash{}
+	for i := 0; i < 3; i++ {
+		call, err := sched.ScheduleCall(common.Address{}, common.Address{}, common.Big0, 100_000, common.Big1, 0, 0, nil, 0, 0)
+		Require(t, err)
+		ids = append(ids, call.Id())
+	}
+
+	collected := func() int {
+		t.Helper()
+		txs, err := sched.DueCalls(common.Big1, common.Big1)
+		Require(t, err)
+		return len(txs)
+	}
+
+	Require(t, sched.CollectDueCalls(1, 1, common.Big1, evm, util.TracingDuringEVM))
+	if collected() != 2 {
+		Fail(t, "per-block gas limit wasn't respected")
+	}
+
+	// even if the limit is lowered below a call's gas, one call is run per block
+	Require(t, sched.SetMaxGasPerBlock(50_000))
+	Require(t, sched.CollectDueCalls(2, 2, common.Big1, evm, util.TracingDuringEVM))
+	if collected() != 1 {
+		Fail(t, "call larger than the per-block gas limit is stuck")
+	}
+	due, err := sched.IsDue(ids[2])
+	Require(t, err)
+	if !due {
+		Fail(t, "calls weren't run in order")
+	}
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
@@ -0,0 +1,204 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package arbos
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbos/addressTable"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbos/functiontable"
+)
+
+// Which function table a selector-compressed message refers to
+const (
+	functionTableGlobal  byte = 0 // the global table
+	functionTableAccount byte = 1 // an account's table, whose owner follows in its address-compressed form
+)
+
+// MessageFunctionTable opens the function tables that a block's selector-compressed L2 messages are decoded against.
+// The statedb must be the state at the start of the block. Returns nil if the ArbOS version doesn't support them.
+func MessageFunctionTable(statedb vm.StateDB) (*functiontable.FunctionTable, error) {
+	state, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	if state.ArbOSVersion() < arbostypes.ArbosVersion_SelectorCompression {
+		return nil, nil
+	}
+	return state.FunctionTable(), nil
+}
+
+// SelectorCompressor encodes signed transactions for the sequencer, replacing the start of their calldata
+// with the index of the longest matching entry in the global function table or their sender's table.
+type SelectorCompressor struct {
+	functions *functiontable.FunctionTable
+	addresses *addressTable.AddressTable
+	signer    types.Signer
+	tables    map[common.Address][][]byte // the entries of each table read so far
+}
+
+// NewSelectorCompressor makes a compressor for the block about to be produced on top of statedb, which must not
+// be modified afterwards, since messages are decoded against the tables as of the start of their block.
+// Returns nil if the ArbOS version doesn't support selector-compressed messages.
+func NewSelectorCompressor(statedb vm.StateDB, signer types.Signer) (*SelectorCompressor, error) {
+	state, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	if state.ArbOSVersion() < arbostypes.ArbosVersion_SelectorCompression {
+		return nil, nil
+	}
+	return &SelectorCompressor{
+		functions: state.FunctionTable(),
+		addresses: state.AddressTable(),
+		signer:    signer,
+		tables:    make(map[common.Address][][]byte),
+	}, nil
+}
+
+// EncodeSignedTx encodes a signed transaction as an L2 message, starting with its kind. The rest of the transaction
+// is encoded by the address compressor, which may be nil. The transaction is sent without selector compression
+// if the compressor is nil or doing so wouldn't make the message smaller.
+func (c *SelectorCompressor) EncodeSignedTx(tx *types.Transaction, addresses *AddressCompressor) ([]byte, error) {
+	plain, err := addresses.EncodeSignedTx(tx)
+	if c == nil || len(tx.Data()) < 4 || err != nil {
+		return plain, err
+	}
+	sender, err := types.Sender(c.signer, tx)
+	if err != nil {
+		return plain, nil // the block processor will reject the transaction anyway
+	}
+	var owner common.Address
+	var index uint64
+	var entry []byte
+	for _, candidate := range []common.Address{functiontable.GlobalOwner, sender} {
+		entries, err := c.entries(candidate)
+		if err != nil {
+			return nil, err
+		}
+		for i, value := range entries {
+			if len(value) > len(entry) && bytes.HasPrefix(tx.Data(), value) {
+				owner, index, entry = candidate, uint64(i), value
+			}
+		}
+	}
+	if entry == nil {
+		return plain, nil
+	}
+	stripped, ok := withData(tx, tx.Data()[len(entry):])
+	if !ok {
+		return plain, nil
+	}
+	msg := []byte{L2MessageKind_SelectorCompressedTx}
+	if owner == functiontable.GlobalOwner {
+		msg = append(msg, functionTableGlobal)
+	} else {
+		compressedOwner, err := c.addresses.Compress(owner)
+		if err != nil {
+			return nil, err
+		}
+		msg = append(msg, functionTableAccount)
+		msg = append(msg, compressedOwner...)
+	}
+	msg = rlp.AppendUint64(msg, index)
+	segment, err := addresses.EncodeSignedTx(stripped)
+	if err != nil {
+		return nil, err
+	}
+	msg = append(msg, segment...)
+	if len(msg) >= len(plain) {
+		return plain, nil
+	}
+	return msg, nil
+}
+
+func (c *SelectorCompressor) entries(owner common.Address) ([][]byte, error) {
+	if entries, ok := c.tables[owner]; ok {
+		return entries, nil
+	}
+	size, err := c.functions.Size(owner)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([][]byte, 0, size)
+	for i := uint64(0); i < size; i++ {
+		entry, _, err := c.functions.Get(owner, i)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	c.tables[owner] = entries
+	return entries, nil
+}
+
+// parseSelectorCompressedTx decodes a signed transaction whose calldata starts with an entry in a function table.
+// After the table and index comes the rest of the transaction, as either a signed or an address-compressed segment.
+func parseSelectorCompressedTx(
+	data []byte, addresses *addressTable.AddressTable, functions *functiontable.FunctionTable,
+) (*types.Transaction, error) {
+	if len(data) == 0 {
+		return nil, errors.New("selector-compressed tx is empty")
+	}
+	owner := functiontable.GlobalOwner
+	switch data[0] {
+	case functionTableGlobal:
+		data = data[1:]
+	case functionTableAccount:
+		account, ownerSize, err := addresses.Decompress(data[1:])
+		if err != nil {
+			return nil, err
+		}
+		owner = account
+		data = data[1+ownerSize:]
+	default:
+		return nil, fmt.Errorf("unknown function table kind %v", data[0])
+	}
+	rd := bytes.NewReader(data)
+	index, err := rlp.NewStream(rd, 9).Uint64()
+	if err != nil {
+		return nil, err
+	}
+	entry, exists, err := functions.Get(owner, index)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("invalid index in selector-compressed tx")
+	}
+	segment := data[len(data)-rd.Len():]
+	if len(segment) == 0 {
+		return nil, errors.New("selector-compressed tx is missing its transaction")
+	}
+	var tx *types.Transaction
+	switch segment[0] {
+	case L2MessageKind_SignedTx:
+		tx = new(types.Transaction)
+		if err := tx.UnmarshalBinary(segment[1:]); err != nil {
+			return nil, err
+		}
+	case L2MessageKind_AddressCompressedTx:
+		tx, err = parseAddressCompressedTx(segment[1:], addresses)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("L2 message kind %v can't be selector-compressed", segment[0])
+	}
+	calldata := append(append([]byte{}, entry...), tx.Data()...)
+	tx, ok := withData(tx, calldata)
+	if !ok {
+		return nil, types.ErrTxTypeNotSupported
+	}
+	return tx, nil
+}
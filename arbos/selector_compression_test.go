@@ -0,0 +1,119 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package arbos
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/offchainlabs/nitro/arbos/addressTable"
+	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/functiontable"
+	"github.com/offchainlabs/nitro/arbos/storage"
+)
+
+func TestSelectorCompressedTx(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	Require(t, err)
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	chainId := params.ArbitrumDevTestChainConfig().ChainID
+	signer := types.LatestSignerForChainID(chainId)
+
+	sto := storage.NewMemoryBacked(burn.NewSystemBurner(nil, false))
+	addresses := addressTable.Open(sto.OpenSubStorage([]byte{0}))
+	functions := functiontable.OpenFunctionTable(sto.OpenSubStorage([]byte{1}))
+
+	token := common.BytesToAddress([]byte{1})
+	_, err = addresses.Register(token)
+	Require(t, err)
+	transfer := []byte{0xa9, 0x05, 0x9c, 0xbb}
+	bob := common.BytesToHash([]byte{0xb0, 0xb}).Bytes()
+	carol := common.BytesToHash([]byte{0xca, 0x01}).Bytes()
+	amount := common.BigToHash(big.NewInt(params.Ether)).Bytes()
+	_, err = functions.Append(functiontable.GlobalOwner, [][]byte{transfer})
+	Require(t, err)
+	_, err = functions.Append(sender, [][]byte{append(append([]byte{}, transfer...), bob...)})
+	Require(t, err)
+
+	compressor := &SelectorCompressor{functions, addresses, signer, make(map[common.Address][][]byte)}
+	sign := func(data ...[]byte) *types.Transaction {
+		tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   chainId,
+			Nonce:     1,
+			GasTipCap: common.Big0,
+			GasFeeCap: big.NewInt(params.GWei),
+			Gas:       100_000,
+			To:        &token,
+			Value:     common.Big0,
+			Data:      bytes.Join(data, nil),
+		})
+		Require(t, err)
+		return tx
+	}
+	roundTrip := func(tx *types.Transaction, addressCompressor *AddressCompressor, expectedKind byte) []byte {
+		t.Helper()
+		msg, err := compressor.EncodeSignedTx(tx, addressCompressor)
+		Require(t, err)
+		if msg[0] != expectedKind {
+			Fail(t, "wrong message kind", msg[0], "expected", expectedKind)
+		}
+		txes, err := parseL2Message(bytes.NewReader(msg), common.Address{}, 0, nil, chainId, addresses, functions, 0)
+		Require(t, err)
+		if len(txes) != 1 || txes[0].Hash() != tx.Hash() {
+			Fail(t, "decompressed tx doesn't match the original")
+		}
+		parsedSender, err := types.Sender(signer, txes[0])
+		Require(t, err)
+		if parsedSender != sender {
+			Fail(t, "decompressed tx has the wrong sender", parsedSender)
+		}
+		return msg
+	}
+
+	// the sender's longer entry is preferred to the global one
+	toBob := sign(transfer, bob, amount)
+	msg := roundTrip(toBob, nil, L2MessageKind_SelectorCompressedTx)
+	if msg[1] != functionTableAccount {
+		Fail(t, "didn't use the sender's table")
+	}
+	uncompressed, err := toBob.MarshalBinary()
+	Require(t, err)
+	if len(msg) >= len(uncompressed) {
+		Fail(t, "compressed tx isn't smaller", len(msg), len(uncompressed))
+	}
+
+	// only the global entry matches, and the recipient can be address-compressed as well
+	toCarol := sign(transfer, carol, amount)
+	msg = roundTrip(toCarol, &AddressCompressor{addresses, 1}, L2MessageKind_SelectorCompressedTx)
+	if msg[1] != functionTableGlobal || msg[3] != L2MessageKind_AddressCompressedTx {
+		Fail(t, "didn't use the global table and the address table", msg[:4])
+	}
+
+	// calldata without a matching entry isn't compressed
+	roundTrip(sign([]byte{1, 2, 3, 4}, amount), nil, L2MessageKind_SignedTx)
+	roundTrip(sign(transfer[:3]), nil, L2MessageKind_SignedTx)
+
+	// a nil compressor never compresses, and nodes without function tables reject compressed txs
+	msg, err = (*SelectorCompressor)(nil).EncodeSignedTx(toBob, nil)
+	Require(t, err)
+	if msg[0] != L2MessageKind_SignedTx {
+		Fail(t, "nil compressor compressed a tx")
+	}
+	compressed, err := compressor.EncodeSignedTx(toBob, nil)
+	Require(t, err)
+	if _, err := parseL2Message(bytes.NewReader(compressed), common.Address{}, 0, nil, chainId, addresses, nil, 0); err == nil {
+		Fail(t, "parsed a compressed tx without function tables")
+	}
+	// indices past the end of a table are rejected
+	compressed[2+21] = 0x05
+	if _, err := parseL2Message(bytes.NewReader(compressed), common.Address{}, 0, nil, chainId, addresses, functions, 0); err == nil {
+		Fail(t, "parsed a compressed tx with an invalid index")
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package statistics
+
+import (
+	"math/big"
+
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+// Statistics holds running counters of the chain's activity since they were introduced in ArbOS 21.
+//
+// Each counter is a single slot that's written at most once per transaction. Geth keeps dirty slots in memory
+// and only hashes them into the state trie once per block, so the counters cost one trie update each per block.
+// Counts that ArbOS already tracks, like the number of L2-to-L1 messages, aren't duplicated here.
+type Statistics struct {
+	numTransactions       storage.StorageBackedUint64
+	numContractsCreated   storage.StorageBackedUint64
+	numRetryablesCreated  storage.StorageBackedUint64
+	numRetryablesRedeemed storage.StorageBackedUint64
+	l1FeesCollected       storage.StorageBackedBigUint
+}
+
+const (
+	numTransactionsOffset uint64 = iota
+	numContractsCreatedOffset
+	numRetryablesCreatedOffset
+	numRetryablesRedeemedOffset
+	l1FeesCollectedOffset
+)
+
+func InitializeStatistics(sto *storage.Storage) {
+	// no need to do anything, every counter starts at zero
+}
+
+func OpenStatistics(sto *storage.Storage) *Statistics {
+	return &Statistics{
+		sto.OpenStorageBackedUint64(numTransactionsOffset),
+		sto.OpenStorageBackedUint64(numContractsCreatedOffset),
+		sto.OpenStorageBackedUint64(numRetryablesCreatedOffset),
+		sto.OpenStorageBackedUint64(numRetryablesRedeemedOffset),
+		sto.OpenStorageBackedBigUint(l1FeesCollectedOffset),
+	}
+}
+
+func (s *Statistics) NumTransactions() (uint64, error) {
+	return s.numTransactions.Get()
+}
+
+func (s *Statistics) NumContractsCreated() (uint64, error) {
+	return s.numContractsCreated.Get()
+}
+
+func (s *Statistics) NumRetryablesCreated() (uint64, error) {
+	return s.numRetryablesCreated.Get()
+}
+
+func (s *Statistics) NumRetryablesRedeemed() (uint64, error) {
+	return s.numRetryablesRedeemed.Get()
+}
+
+func (s *Statistics) L1FeesCollected() (*big.Int, error) {
+	return s.l1FeesCollected.Get()
+}
+
+func (s *Statistics) RecordTransaction() error {
+	_, err := s.numTransactions.Increment()
+	return err
+}
+
+func (s *Statistics) RecordContractCreated() error {
+	_, err := s.numContractsCreated.Increment()
+	return err
+}
+
+func (s *Statistics) RecordRetryableCreated() error {
+	_, err := s.numRetryablesCreated.Increment()
+	return err
+}
+
+func (s *Statistics) RecordRetryableRedeemed() error {
+	_, err := s.numRetryablesRedeemed.Increment()
+	return err
+}
+
+// RecordL1Fees adds to the total L1 fees collected from transactions
+func (s *Statistics) RecordL1Fees(amount *big.Int) error {
+	if amount.Sign() <= 0 {
+		return nil
+	}
+	collected, err := s.l1FeesCollected.Get()
+	if err != nil {
+		return err
+	}
+	return s.l1FeesCollected.SetSaturatingWithWarning(arbmath.BigAdd(collected, amount), "L1 fees collected")
+}
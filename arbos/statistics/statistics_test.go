@@ -0,0 +1,61 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package statistics
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestStatistics(t *testing.T) {
+	sto := storage.NewMemoryBacked(burn.NewSystemBurner(nil, false))
+	InitializeStatistics(sto)
+	stats := OpenStatistics(sto)
+
+	for i := 0; i < 3; i++ {
+		Require(t, stats.RecordTransaction())
+	}
+	Require(t, stats.RecordContractCreated())
+	Require(t, stats.RecordRetryableCreated())
+	Require(t, stats.RecordRetryableCreated())
+	Require(t, stats.RecordRetryableRedeemed())
+	Require(t, stats.RecordL1Fees(big.NewInt(100)))
+	Require(t, stats.RecordL1Fees(big.NewInt(0)))
+	Require(t, stats.RecordL1Fees(big.NewInt(23)))
+
+	// reopen the counters to check they were persisted
+	stats = OpenStatistics(sto)
+	expect := func(name string, get func() (uint64, error), expected uint64) {
+		t.Helper()
+		value, err := get()
+		Require(t, err)
+		if value != expected {
+			Fail(t, "wrong", name, "count", value, "expected", expected)
+		}
+	}
+	expect("transaction", stats.NumTransactions, 3)
+	expect("contract", stats.NumContractsCreated, 1)
+	expect("retryable created", stats.NumRetryablesCreated, 2)
+	expect("retryable redeemed", stats.NumRetryablesRedeemed, 1)
+
+	fees, err := stats.L1FeesCollected()
+	Require(t, err)
+	if fees.Cmp(big.NewInt(123)) != 0 {
+		Fail(t, "wrong L1 fees collected", fees)
+	}
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
@@ -0,0 +1,153 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package timelock
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+// Timelock delays the chain owners' changes to the chain's configuration. While the delay is non-zero,
+// owner calls are queued instead of made, and anyone may execute them once the delay has passed.
+// Emergency methods bypass the delay.
+type Timelock struct {
+	actions          *storage.Storage
+	emergencyMethods *storage.Storage // method selector => 1 if the method bypasses the delay
+	delay            storage.StorageBackedUint64
+	nextNonce        storage.StorageBackedUint64
+}
+
+var (
+	actionsKey          = []byte{0}
+	emergencyMethodsKey = []byte{1}
+	calldataKey         = []byte{0}
+)
+
+const (
+	delayOffset uint64 = iota
+	nextNonceOffset
+)
+
+func InitializeTimelock(sto *storage.Storage) {
+	// no need to do anything, a zero delay disables the timelock
+}
+
+func OpenTimelock(sto *storage.Storage) *Timelock {
+	return &Timelock{
+		sto.OpenSubStorage(actionsKey),
+		sto.OpenCachedSubStorage(emergencyMethodsKey),
+		sto.OpenStorageBackedUint64(delayOffset),
+		sto.OpenStorageBackedUint64(nextNonceOffset),
+	}
+}
+
+// Delay gets how many seconds owner calls are queued for, or 0 if they take effect immediately
+func (t *Timelock) Delay() (uint64, error) {
+	return t.delay.Get()
+}
+
+func (t *Timelock) SetDelay(delay uint64) error {
+	return t.delay.Set(delay)
+}
+
+func (t *Timelock) IsEmergencyMethod(selector [4]byte) (bool, error) {
+	value, err := t.emergencyMethods.GetUint64(common.BytesToHash(selector[:]))
+	return value != 0, err
+}
+
+func (t *Timelock) SetEmergencyMethod(selector [4]byte, emergency bool) error {
+	key := common.BytesToHash(selector[:])
+	if !emergency {
+		return t.emergencyMethods.Clear(key)
+	}
+	return t.emergencyMethods.Set(key, util.UintToHash(1))
+}
+
+type QueuedAction struct {
+	id             common.Hash // not backed by storage; this key determines where it lives in storage
+	backingStorage *storage.Storage
+	owner          storage.StorageBackedAddress
+	eta            storage.StorageBackedUint64
+	calldata       storage.StorageBackedBytes
+}
+
+const (
+	ownerOffset uint64 = iota
+	etaOffset
+)
+
+func (t *Timelock) openAction(id common.Hash) *QueuedAction {
+	sto := t.actions.OpenSubStorage(id.Bytes())
+	return &QueuedAction{
+		id,
+		sto,
+		sto.OpenStorageBackedAddress(ownerOffset),
+		sto.OpenStorageBackedUint64(etaOffset),
+		sto.OpenStorageBackedBytes(calldataKey),
+	}
+}
+
+func ActionId(nonce uint64) common.Hash {
+	return crypto.Keccak256Hash([]byte("owner action"), arbmath.UintToBytes(nonce))
+}
+
+// Queue records an owner's call to be executed once the delay has passed, returning its id and the time
+// after which it may be executed. Only call this while the delay is non-zero, as a zero eta marks a deleted action.
+func (t *Timelock) Queue(owner common.Address, calldata []byte, currentTime uint64) (common.Hash, uint64, error) {
+	delay, err := t.delay.Get()
+	if err != nil {
+		return common.Hash{}, 0, err
+	}
+	nonce, err := t.nextNonce.Increment()
+	if err != nil {
+		return common.Hash{}, 0, err
+	}
+	id := ActionId(nonce - 1)
+	eta := arbmath.SaturatingUAdd(currentTime, delay)
+	action := t.openAction(id)
+	if err := action.owner.Set(owner); err != nil {
+		return common.Hash{}, 0, err
+	}
+	if err := action.eta.Set(eta); err != nil {
+		return common.Hash{}, 0, err
+	}
+	return id, eta, action.calldata.Set(calldata)
+}
+
+// OpenAction returns the queued action with the given id, or nil if there isn't one
+func (t *Timelock) OpenAction(id common.Hash) (*QueuedAction, error) {
+	action := t.openAction(id)
+	eta, err := action.eta.Get()
+	if eta == 0 || err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+func (action *QueuedAction) Id() common.Hash {
+	return action.id
+}
+
+func (action *QueuedAction) Owner() (common.Address, error) {
+	return action.owner.Get()
+}
+
+func (action *QueuedAction) Eta() (uint64, error) {
+	return action.eta.Get()
+}
+
+func (action *QueuedAction) Calldata() ([]byte, error) {
+	return action.calldata.Get()
+}
+
+// Delete removes the action from the queue, whether it's being executed or canceled
+func (action *QueuedAction) Delete() error {
+	// we ignore returned errors for the same reason DeleteRetryable does: the final Clear will fail as well
+	_ = action.backingStorage.ClearByUint64(ownerOffset)
+	_ = action.backingStorage.ClearByUint64(etaOffset)
+	return action.calldata.Clear()
+}
@@ -0,0 +1,88 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package timelock
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestTimelockQueue(t *testing.T) {
+	sto := storage.NewMemoryBacked(burn.NewSystemBurner(nil, false))
+	InitializeTimelock(sto)
+	timelock := OpenTimelock(sto)
+	owner := common.BytesToAddress([]byte{1})
+	calldata := []byte{0xde, 0xad, 0xbe, 0xef, 0x01}
+
+	Require(t, timelock.SetDelay(3600))
+	first, eta, err := timelock.Queue(owner, calldata, 1000)
+	Require(t, err)
+	if eta != 4600 {
+		Fail(t, "wrong eta", eta)
+	}
+	second, _, err := timelock.Queue(owner, calldata, 1000)
+	Require(t, err)
+	if first == second {
+		Fail(t, "identical calls share an id")
+	}
+
+	action, err := OpenTimelock(sto).OpenAction(first)
+	Require(t, err)
+	if action == nil {
+		Fail(t, "queued action doesn't exist")
+	}
+	actionOwner, err := action.Owner()
+	Require(t, err)
+	actionData, err := action.Calldata()
+	Require(t, err)
+	if actionOwner != owner || !bytes.Equal(actionData, calldata) {
+		Fail(t, "queued action doesn't match", actionOwner, actionData)
+	}
+
+	Require(t, action.Delete())
+	action, err = timelock.OpenAction(first)
+	Require(t, err)
+	if action != nil {
+		Fail(t, "deleted action still exists")
+	}
+	action, err = timelock.OpenAction(second)
+	Require(t, err)
+	if action == nil {
+		Fail(t, "deleting an action deleted another")
+	}
+}
+
+func TestTimelockEmergencyMethods(t *testing.T) {
+	timelock := OpenTimelock(storage.NewMemoryBacked(burn.NewSystemBurner(nil, false)))
+	selector := [4]byte{1, 2, 3, 4}
+
+	check := func(expected bool) {
+		t.Helper()
+		emergency, err := timelock.IsEmergencyMethod(selector)
+		Require(t, err)
+		if emergency != expected {
+			Fail(t, "expected emergency to be", expected)
+		}
+	}
+	check(false)
+	Require(t, timelock.SetEmergencyMethod(selector, true))
+	check(true)
+	Require(t, timelock.SetEmergencyMethod(selector, false))
+	check(false)
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
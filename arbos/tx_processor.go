@@ -8,7 +8,9 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/offchainlabs/nitro/arbos/feedistribution"
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
+	"github.com/offchainlabs/nitro/arbos/l2pricing"
 
 	"github.com/offchainlabs/nitro/arbos/util"
 	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
@@ -18,17 +20,22 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/offchainlabs/nitro/arbos/retryables"
+	"github.com/offchainlabs/nitro/arbos/statistics"
 
 	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	glog "github.com/ethereum/go-ethereum/log"
 )
 
 var arbosAddress = types.ArbosAddress
 
+var ErrFilteredAddress = errors.New("transaction from or to an address filtered by the chain owner")
+
 const GasEstimationL1PricePadding arbmath.Bips = 11000 // pad estimates by 10%
 
 // A TxProcessor is created and freed for every L2 transaction.
@@ -41,6 +48,7 @@ type TxProcessor struct {
 	posterGas        uint64
 	computeHoldGas   uint64 // amount of gas temporarily held to prevent compute from exceeding the gas limit
 	delayedInbox     bool   // whether this tx was submitted through the delayed inbox
+	scheduledCall    bool   // whether this tx is a call scheduled through ArbScheduler
 	Callers          []common.Address
 	TopTxType        *byte // set once in StartTxHook
 	evm              *vm.EVM
@@ -109,6 +117,22 @@ func (p *TxProcessor) StartTxHook() (endTxNow bool, gasUsed uint64, err error, r
 	p.TopTxType = &tipe
 	evm := p.evm
 
+	if tipe != types.ArbitrumInternalTxType {
+		p.recordStatistic((*statistics.Statistics).RecordTransaction)
+	}
+	if tipe < types.ArbitrumDepositTxType && p.msg.To != nil {
+		p.recordAddressUse(*p.msg.To)
+	}
+
+	switch underlyingTx.GetInner().(type) {
+	case *types.ArbitrumInternalTx, *types.ArbitrumDepositTx, *types.ArbitrumSubmitRetryableTx:
+		// these are checked below, after any funds deposited from L1 are minted
+	default:
+		if p.isFiltered(p.msg.From, p.msg.To) {
+			return true, 0, ErrFilteredAddress, nil
+		}
+	}
+
 	startTracer := func() func() {
 		tracer := evm.Config.Tracer
 		if tracer == nil {
@@ -139,6 +163,10 @@ func (p *TxProcessor) StartTxHook() (endTxNow bool, gasUsed uint64, err error, r
 			return true, 0, errors.New("eth deposit has no To address"), nil
 		}
 		util.MintBalance(&from, value, evm, util.TracingBeforeEVM, "deposit")
+		if p.isFiltered(from, to) {
+			// The deposit stays minted to the sender, and the tx ends with a failed receipt
+			return true, 0, ErrFilteredAddress, nil
+		}
 		defer (startTracer())()
 		// We intentionally use the variant here that doesn't do tracing,
 		// because this transfer is represented as the outer eth transaction.
@@ -165,6 +193,19 @@ func (p *TxProcessor) StartTxHook() (endTxNow bool, gasUsed uint64, err error, r
 		availableRefund := new(big.Int).Set(tx.DepositValue)
 		takeFunds(availableRefund, tx.RetryValue)
 		util.MintBalance(&tx.From, tx.DepositValue, evm, scenario, "deposit")
+		if p.isFiltered(tx.From, tx.RetryTo) {
+			// No ticket is created and the tx ends with a failed receipt.
+			// The deposit is credited to the beneficiary, as the callvalue of a failed retryable would be,
+			// unless the beneficiary is filtered too, in which case it stays with the sender's aliased address.
+			if !p.isFiltered(tx.Beneficiary, nil) {
+				err := util.TransferBalance(&tx.From, &tx.Beneficiary, tx.DepositValue, evm, scenario, "filtered")
+				if err != nil {
+					// should be impossible as the deposit was just minted
+					glog.Error("failed to credit filtered deposit to beneficiary", "err", err)
+				}
+			}
+			return true, 0, ErrFilteredAddress, nil
+		}
 
 		transfer := func(from, to *common.Address, amount *big.Int) error {
 			return util.TransferBalance(from, to, amount, evm, scenario, "during evm execution")
@@ -180,7 +221,10 @@ func (p *TxProcessor) StartTxHook() (endTxNow bool, gasUsed uint64, err error, r
 			return true, 0, err, nil
 		}
 
-		submissionFee := retryables.RetryableSubmissionFee(len(tx.RetryData), tx.L1BaseFee)
+		// the L1 base fee is in the parent chain's currency, which may differ from this chain's native token
+		l1BaseFee, err := p.state.L1PricingState().ParentChainToNative(tx.L1BaseFee, p.state.ArbOSVersion())
+		p.state.Restrict(err)
+		submissionFee := retryables.RetryableSubmissionFee(len(tx.RetryData), l1BaseFee)
 		if arbmath.BigLessThan(tx.MaxSubmissionFee, submissionFee) {
 			// should be impossible as this is checked at L1
 			err := fmt.Errorf(
@@ -238,6 +282,7 @@ func (p *TxProcessor) StartTxHook() (endTxNow bool, gasUsed uint64, err error, r
 			tx.RetryData,
 		)
 		p.state.Restrict(err)
+		p.recordStatistic((*statistics.Statistics).RecordRetryableCreated)
 
 		err = EmitTicketCreatedEvent(evm, ticketId)
 		if err != nil {
@@ -347,6 +392,12 @@ func (p *TxProcessor) StartTxHook() (endTxNow bool, gasUsed uint64, err error, r
 		}
 
 		return true, usergas, nil, ticketId.Bytes()
+	case *types.ArbitrumContractTx:
+		if p.state.ArbOSVersion() >= arbostypes.ArbosVersion_ScheduledCalls {
+			due, err := p.state.Scheduler().IsDue(tx.RequestId)
+			p.state.Restrict(err)
+			p.scheduledCall = due
+		}
 	case *types.ArbitrumRetryTx:
 
 		// Transfer callvalue from escrow
@@ -367,6 +418,12 @@ func (p *TxProcessor) StartTxHook() (endTxNow bool, gasUsed uint64, err error, r
 	return false, 0, nil, nil
 }
 
+func (p *TxProcessor) isFiltered(from common.Address, to *common.Address) bool {
+	filtered, err := p.state.IsFilteredTransaction(from, to)
+	p.state.Restrict(err)
+	return filtered
+}
+
 func GetPosterGas(state *arbosState.ArbosState, baseFee *big.Int, runMode core.MessageRunMode, posterCost *big.Int) uint64 {
 	if runMode == core.MessageGasEstimationMode {
 		// Suggest the amount of gas needed for a given amount of ETH is higher in case of congestion.
@@ -407,7 +464,8 @@ func (p *TxProcessor) GasChargingHook(gasRemaining *uint64) (common.Address, err
 	if p.msg.TxRunMode == core.MessageCommitMode {
 		p.msg.SkipL1Charging = false
 	}
-	if basefee.Sign() > 0 && !p.msg.SkipL1Charging {
+	// Scheduled calls are created by ArbOS, so the batch poster never posts them
+	if basefee.Sign() > 0 && !p.msg.SkipL1Charging && !p.scheduledCall {
 		// Since tips go to the network, and not to the poster, we use the basefee.
 		// Note, this only determines the amount of gas bought, not the price per gas.
 
@@ -464,6 +522,10 @@ func (p *TxProcessor) EndTxHook(gasLeft uint64, success bool) {
 	}
 	gasUsed := p.msg.GasLimit - gasLeft
 
+	if success && p.msg.To == nil {
+		p.recordStatistic((*statistics.Statistics).RecordContractCreated)
+	}
+
 	if underlyingTx != nil && underlyingTx.Type() == types.ArbitrumRetryTxType {
 		inner, _ := underlyingTx.GetInner().(*types.ArbitrumRetryTx)
 		effectiveBaseFee := inner.GasFeeCap
@@ -537,6 +599,7 @@ func (p *TxProcessor) EndTxHook(gasLeft uint64, success bool) {
 			tracingInfo := util.NewTracingInfo(p.evm, arbosAddress, p.msg.From, scenario)
 			state := arbosState.OpenSystemArbosStateOrPanic(p.evm.StateDB, tracingInfo, false)
 			_, _ = state.RetryableState().DeleteRetryable(inner.TicketId, p.evm, scenario)
+			p.recordStatistic((*statistics.Statistics).RecordRetryableRedeemed)
 		} else {
 			// return the Callvalue to escrow
 			escrow := retryables.RetryableEscrowAddress(inner.TicketId)
@@ -549,6 +612,7 @@ func (p *TxProcessor) EndTxHook(gasLeft uint64, success bool) {
 		}
 		// we've already credited the network fee account, but we didn't charge the gas pool yet
 		p.state.Restrict(p.state.L2PricingState().AddToGasPool(-arbmath.SaturatingCast(gasUsed)))
+		p.addResourceUsage()
 		return
 	}
 
@@ -574,12 +638,12 @@ func (p *TxProcessor) EndTxHook(gasLeft uint64, success bool) {
 			infraFee := arbmath.BigMin(minBaseFee, basefee)
 			computeGas := arbmath.SaturatingUSub(gasUsed, p.posterGas)
 			infraComputeCost := arbmath.BigMulByUint(infraFee, computeGas)
-			util.MintBalance(&infraFeeAccount, infraComputeCost, p.evm, scenario, purpose)
+			p.mintFees(feedistribution.FeeKindInfra, infraFeeAccount, infraComputeCost, scenario, purpose)
 			computeCost = arbmath.BigSub(computeCost, infraComputeCost)
 		}
 	}
 	if arbmath.BigGreaterThan(computeCost, common.Big0) {
-		util.MintBalance(&networkFeeAccount, computeCost, p.evm, scenario, purpose)
+		p.mintFees(feedistribution.FeeKindNetwork, networkFeeAccount, computeCost, scenario, purpose)
 	}
 	posterFeeDestination := l1pricing.L1PricerFundsPoolAddress
 	if p.state.ArbOSVersion() < 2 {
@@ -591,6 +655,9 @@ func (p *TxProcessor) EndTxHook(gasLeft uint64, success bool) {
 			log.Error("failed to update L1FeesAvailable: ", "err", err)
 		}
 	}
+	p.recordStatistic(func(stats *statistics.Statistics) error {
+		return stats.RecordL1Fees(p.PosterFee)
+	})
 
 	if p.msg.GasPrice.Sign() > 0 { // in tests, gas price could be 0
 		// ArbOS's gas pool is meant to enforce the computational speed-limit.
@@ -608,7 +675,63 @@ func (p *TxProcessor) EndTxHook(gasLeft uint64, success bool) {
 			computeGas = gasUsed
 		}
 		p.state.Restrict(p.state.L2PricingState().AddToGasPool(-arbmath.SaturatingCast(computeGas)))
+		p.addResourceUsage()
+	}
+}
+
+// mintFees pays a kind of fee to its recipients in the fee distribution table, which exists from ArbOS version 21,
+// and whatever remains to the account that would otherwise receive it
+func (p *TxProcessor) mintFees(
+	kind feedistribution.FeeKind, account common.Address, amount *big.Int, scenario util.TracingScenario, purpose string,
+) {
+	if p.state.ArbOSVersion() >= arbostypes.ArbosVersion_FeeDistribution {
+		payments, remainder, err := p.state.FeeDistribution().Split(kind, amount)
+		p.state.Restrict(err)
+		for _, payment := range payments {
+			util.MintBalance(&payment.To, payment.Amount, p.evm, scenario, purpose)
+		}
+		amount = remainder
+	}
+	util.MintBalance(&account, amount, p.evm, scenario, purpose)
+}
+
+// recordAddressUse counts a signed transaction's recipient towards its automatic registration in the address table,
+// after which the sequencer can compress it
+func (p *TxProcessor) recordAddressUse(to common.Address) {
+	if p.state.ArbOSVersion() < arbostypes.ArbosVersion_AddressCompression {
+		return
+	}
+	_, err := p.state.AddressTable().RecordUse(to)
+	p.state.Restrict(err)
+}
+
+// recordStatistic updates the chain's running counters, which are only kept from ArbOS version 21 onwards
+func (p *TxProcessor) recordStatistic(record func(*statistics.Statistics) error) {
+	if p.state.ArbOSVersion() < arbostypes.ArbosVersion_Statistics {
+		return
+	}
+	p.state.Restrict(record(p.state.Statistics()))
+}
+
+// addResourceUsage adds the tx's usage of resources that are priced apart from computation to their backlogs
+func (p *TxProcessor) addResourceUsage() {
+	if p.state.ArbOSVersion() < arbostypes.ArbosVersion_MultiDimensionalPricing {
+		return
+	}
+	historyGrowth := uint64(len(p.msg.Data))
+	for _, txLog := range p.evm.StateDB.GetCurrentTxLogs() {
+		historyGrowth += uint64(len(txLog.Data) + common.HashLength*len(txLog.Topics))
 	}
+	// Only the tx's own deployment is counted. Storage writes and contracts created by CREATE or CREATE2
+	// aren't visible to ArbOS, so they're still priced through computation alone.
+	var codeGrowth uint64
+	if p.msg.To == nil {
+		created := crypto.CreateAddress(p.msg.From, p.msg.Nonce)
+		codeGrowth = uint64(p.evm.StateDB.GetCodeSize(created))
+	}
+	pricing := p.state.L2PricingState()
+	p.state.Restrict(pricing.AddResourceUsage(l2pricing.ResourceKindHistoryGrowth, historyGrowth))
+	p.state.Restrict(pricing.AddResourceUsage(l2pricing.ResourceKindCodeGrowth, codeGrowth))
 }
 
 func (p *TxProcessor) ScheduledTxes() types.Transactions {
@@ -623,6 +746,10 @@ func (p *TxProcessor) ScheduledTxes() types.Transactions {
 		effectiveBaseFee = common.Big0
 	}
 
+	if p.msg.Tx != nil && p.msg.Tx.Type() == types.ArbitrumInternalTxType {
+		return p.dueScheduledCalls(chainID, effectiveBaseFee)
+	}
+
 	logs := p.evm.StateDB.GetCurrentTxLogs()
 	for _, log := range logs {
 		if log.Address != ArbRetryableTxAddress || log.Topics[0] != RedeemScheduledEventID {
@@ -653,6 +780,27 @@ func (p *TxProcessor) ScheduledTxes() types.Transactions {
 	return scheduled
 }
 
+// dueScheduledCalls makes the calls collected by the StartBlock internal tx, which run right after it
+func (p *TxProcessor) dueScheduledCalls(chainID *big.Int, baseFee *big.Int) types.Transactions {
+	scheduled := types.Transactions{}
+	data := p.msg.Tx.Data()
+	if p.state.ArbOSVersion() < arbostypes.ArbosVersion_ScheduledCalls || len(data) < 4 {
+		return scheduled
+	}
+	if *(*[4]byte)(data[:4]) != InternalTxStartBlockMethodID {
+		return scheduled
+	}
+	calls, err := p.state.Scheduler().DueCalls(chainID, baseFee)
+	if err != nil {
+		glog.Error("Failed to make due scheduled calls", "err", err)
+		return scheduled
+	}
+	for _, call := range calls {
+		scheduled = append(scheduled, types.NewTx(call))
+	}
+	return scheduled
+}
+
 func (p *TxProcessor) L1BlockNumber(blockCtx vm.BlockContext) (uint64, error) {
 	if p.cachedL1BlockNumber != nil {
 		return *p.cachedL1BlockNumber, nil
@@ -27,6 +27,11 @@ var PackInternalTxDataStartBlock func(...interface{}) ([]byte, error)
 var UnpackInternalTxDataStartBlock func([]byte) (map[string]interface{}, error)
 var PackInternalTxDataBatchPostingReport func(...interface{}) ([]byte, error)
 var UnpackInternalTxDataBatchPostingReport func([]byte) (map[string]interface{}, error)
+
+// The generated bindings don't declare batchPostingReportWithBlobs yet, so these are set when the precompiles are made
+var PackInternalTxDataBatchPostingReportWithBlobs func(...interface{}) ([]byte, error)
+var UnpackInternalTxDataBatchPostingReportWithBlobs func([]byte) (map[string]interface{}, error)
+
 var PackArbRetryableTxRedeem func(...interface{}) ([]byte, error)
 
 func init() {
@@ -73,17 +78,7 @@ func init() {
 		if !ok {
 			panic(fmt.Sprintf("method %v does not exist", name))
 		}
-		pack := func(args ...interface{}) ([]byte, error) {
-			return contract.Pack(name, args...)
-		}
-		unpack := func(data []byte) (map[string]interface{}, error) {
-			if len(data) < 4 {
-				return nil, errors.New("data not long enough")
-			}
-			args := make(map[string]interface{})
-			return args, method.Inputs.UnpackIntoMap(args, data[4:])
-		}
-		return pack, unpack
+		return MethodCallParser(method)
 	}
 
 	ParseRedeemScheduledLog = logParser(precompilesgen.ArbRetryableTxABI, "RedeemScheduled")
@@ -96,6 +91,25 @@ func init() {
 	PackArbRetryableTxRedeem, _ = callParser(precompilesgen.ArbRetryableTxABI, "redeem")
 }
 
+// MethodCallParser makes functions packing calls to the method and unpacking their arguments
+func MethodCallParser(method abi.Method) (func(...interface{}) ([]byte, error), func([]byte) (map[string]interface{}, error)) {
+	pack := func(args ...interface{}) ([]byte, error) {
+		arguments, err := method.Inputs.Pack(args...)
+		if err != nil {
+			return nil, err
+		}
+		return append(append([]byte{}, method.ID...), arguments...), nil
+	}
+	unpack := func(data []byte) (map[string]interface{}, error) {
+		if len(data) < 4 {
+			return nil, errors.New("data not long enough")
+		}
+		args := make(map[string]interface{})
+		return args, method.Inputs.UnpackIntoMap(args, data[4:])
+	}
+	return pack, unpack
+}
+
 func AddressToHash(address common.Address) common.Hash {
 	return common.BytesToHash(address.Bytes())
 }
@@ -0,0 +1,207 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/cmd/conf"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+)
+
+type ArbosInspectConfig struct {
+	Persistent      conf.PersistentConfig `koanf:"persistent"`
+	BlockNumber     int64                 `koanf:"block-number"`
+	BlockHash       string                `koanf:"block-hash"`
+	DiffBlockNumber int64                 `koanf:"diff-block-number"`
+	DiffBlockHash   string                `koanf:"diff-block-hash"`
+	MaxEntries      uint64                `koanf:"max-entries"`
+	Output          string                `koanf:"output"`
+	LogLevel        int                   `koanf:"log-level"`
+	LogType         string                `koanf:"log-type"`
+}
+
+var DefaultArbosInspectConfig = ArbosInspectConfig{
+	Persistent:      conf.PersistentConfigDefault,
+	BlockNumber:     -1,
+	BlockHash:       "",
+	DiffBlockNumber: -1,
+	DiffBlockHash:   "",
+	MaxEntries:      0,
+	Output:          "",
+	LogLevel:        int(log.LvlWarn),
+	LogType:         "plaintext",
+}
+
+func main() {
+	if err := startup(); err != nil {
+		log.Error("Error inspecting ArbOS state", "err", err)
+		os.Exit(1)
+	}
+}
+
+func printSampleUsage(progname string) {
+	fmt.Printf("\n")
+	fmt.Printf("Sample usage:                  %s --persistent.chain /path/to/chain --block-number 1000 \n", progname)
+	fmt.Printf("Diff two blocks:               %s --persistent.chain /path/to/chain --block-number 1000 --diff-block-number 2000 \n", progname)
+}
+
+func parseArbosInspect(args []string) (*ArbosInspectConfig, error) {
+	f := flag.NewFlagSet("arbosinspect", flag.ContinueOnError)
+	conf.PersistentConfigAddOptions("persistent", f)
+	f.Int64("block-number", DefaultArbosInspectConfig.BlockNumber, "number of the block whose ArbOS state is inspected (-1 for the latest block)")
+	f.String("block-hash", DefaultArbosInspectConfig.BlockHash, "hash of the block whose ArbOS state is inspected (overrides block-number)")
+	f.Int64("diff-block-number", DefaultArbosInspectConfig.DiffBlockNumber, "if set, print the fields of the ArbOS state which changed between block-number and this block instead of the state itself")
+	f.String("diff-block-hash", DefaultArbosInspectConfig.DiffBlockHash, "hash of the block to diff against (overrides diff-block-number)")
+	f.Uint64("max-entries", DefaultArbosInspectConfig.MaxEntries, "the most retryables and address table entries to include (0 for all of them)")
+	f.String("output", DefaultArbosInspectConfig.Output, "file to write the JSON output to (stdout if empty)")
+	f.Int("log-level", DefaultArbosInspectConfig.LogLevel, "log level; 1: ERROR, 2: WARN, 3: INFO, 4: DEBUG, 5: TRACE")
+	f.String("log-type", DefaultArbosInspectConfig.LogType, "log type (plaintext or json)")
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+	var config ArbosInspectConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func startup() error {
+	config, err := parseArbosInspect(os.Args[1:])
+	if err != nil {
+		confighelpers.PrintErrorAndExit(err, printSampleUsage)
+	}
+	if config.Persistent.Chain == "" {
+		confighelpers.PrintErrorAndExit(errors.New("--persistent.chain not specified"), printSampleUsage)
+	}
+	if err := config.Persistent.Validate(); err != nil {
+		return err
+	}
+
+	logFormat, err := genericconf.ParseLogType(config.LogType)
+	if err != nil {
+		flag.Usage()
+		return fmt.Errorf("error parsing log type: %w", err)
+	}
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, logFormat))
+	glogger.Verbosity(log.Lvl(config.LogLevel))
+	log.Root().SetHandler(glogger)
+
+	stackConf := node.DefaultConfig
+	// The database lives in the directory named after the nitro binary
+	stackConf.Name = "nitro"
+	stackConf.DataDir = config.Persistent.Chain
+	stackConf.DBEngine = config.Persistent.DBEngine
+	stackConf.P2P.ListenAddr = ""
+	stackConf.P2P.NoDial = true
+	stackConf.P2P.NoDiscovery = true
+	stack, err := node.New(&stackConf)
+	if err != nil {
+		return err
+	}
+	defer stack.Close()
+	chainDb, err := stack.OpenDatabaseWithFreezer("l2chaindata", 0, config.Persistent.Handles, config.Persistent.Ancient, "", true)
+	if err != nil {
+		return fmt.Errorf("error opening chain database: %w", err)
+	}
+	defer chainDb.Close()
+	stateDatabase := state.NewDatabase(chainDb)
+	inspectConfig := &arbosState.InspectConfig{MaxEntries: config.MaxEntries}
+
+	header, err := findHeader(chainDb, config.BlockHash, config.BlockNumber)
+	if err != nil {
+		return err
+	}
+	dump, err := inspectBlock(stateDatabase, header, inspectConfig)
+	if err != nil {
+		return err
+	}
+	var output interface{} = dump
+	if config.DiffBlockHash != "" || config.DiffBlockNumber >= 0 {
+		diffHeader, err := findHeader(chainDb, config.DiffBlockHash, config.DiffBlockNumber)
+		if err != nil {
+			return err
+		}
+		diffDump, err := inspectBlock(stateDatabase, diffHeader, inspectConfig)
+		if err != nil {
+			return err
+		}
+		diffs, err := arbosState.DiffStateDumps(dump, diffDump)
+		if err != nil {
+			return err
+		}
+		log.Info("diffed ArbOS state", "from", header.Number, "to", diffHeader.Number, "changes", len(diffs))
+		output = diffs
+	}
+
+	var writer io.Writer = os.Stdout
+	if config.Output != "" {
+		file, err := os.Create(config.Output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		writer = file
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+func inspectBlock(stateDatabase state.Database, header *types.Header, config *arbosState.InspectConfig) (*arbosState.StateDump, error) {
+	log.Info("inspecting ArbOS state", "block", header.Number, "hash", header.Hash(), "root", header.Root)
+	statedb, err := state.New(header.Root, stateDatabase, nil)
+	if err != nil {
+		return nil, err
+	}
+	return arbosState.InspectArbosState(statedb, config)
+}
+
+func findHeader(chainDb ethdb.Database, hash string, number int64) (*types.Header, error) {
+	var blockHash common.Hash
+	if hash != "" {
+		blockHash = common.HexToHash(hash)
+	} else if number >= 0 {
+		blockHash = rawdb.ReadCanonicalHash(chainDb, uint64(number))
+	} else {
+		blockHash = rawdb.ReadHeadBlockHash(chainDb)
+	}
+	if blockHash == (common.Hash{}) {
+		return nil, errors.New("block to inspect not found")
+	}
+	blockNumber := rawdb.ReadHeaderNumber(chainDb, blockHash)
+	if blockNumber == nil {
+		return nil, fmt.Errorf("block %v not found", blockHash)
+	}
+	header := rawdb.ReadHeader(chainDb, blockHash, *blockNumber)
+	if header == nil {
+		return nil, fmt.Errorf("header of block %v not found", blockHash)
+	}
+	hasState, err := chainDb.Has(header.Root.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if !hasState {
+		return nil, fmt.Errorf("state of block %v (root %v) isn't available in the database", header.Number, header.Root)
+	}
+	return header, nil
+}
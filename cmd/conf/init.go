@@ -7,42 +7,45 @@ import (
 )
 
 type InitConfig struct {
-	Force           bool          `koanf:"force"`
-	Url             string        `koanf:"url"`
-	DownloadPath    string        `koanf:"download-path"`
-	DownloadPoll    time.Duration `koanf:"download-poll"`
-	DevInit         bool          `koanf:"dev-init"`
-	DevInitAddress  string        `koanf:"dev-init-address"`
-	DevInitBlockNum uint64        `koanf:"dev-init-blocknum"`
-	Empty           bool          `koanf:"empty"`
-	AccountsPerSync uint          `koanf:"accounts-per-sync"`
-	ImportFile      string        `koanf:"import-file"`
-	ThenQuit        bool          `koanf:"then-quit"`
-	Prune           string        `koanf:"prune"`
-	PruneBloomSize  uint64        `koanf:"prune-bloom-size"`
-	ResetToMessage  int64         `koanf:"reset-to-message"`
+	Force            bool          `koanf:"force"`
+	Url              string        `koanf:"url"`
+	ValidateChecksum bool          `koanf:"validate-checksum"`
+	DownloadPath     string        `koanf:"download-path"`
+	DownloadPoll     time.Duration `koanf:"download-poll"`
+	DevInit          bool          `koanf:"dev-init"`
+	DevInitAddress   string        `koanf:"dev-init-address"`
+	DevInitBlockNum  uint64        `koanf:"dev-init-blocknum"`
+	Empty            bool          `koanf:"empty"`
+	AccountsPerSync  uint          `koanf:"accounts-per-sync"`
+	ImportFile       string        `koanf:"import-file"`
+	ThenQuit         bool          `koanf:"then-quit"`
+	Prune            string        `koanf:"prune"`
+	PruneBloomSize   uint64        `koanf:"prune-bloom-size"`
+	ResetToMessage   int64         `koanf:"reset-to-message"`
 }
 
 var InitConfigDefault = InitConfig{
-	Force:           false,
-	Url:             "",
-	DownloadPath:    "/tmp/",
-	DownloadPoll:    time.Minute,
-	DevInit:         false,
-	DevInitAddress:  "",
-	DevInitBlockNum: 0,
-	Empty:           false,
-	ImportFile:      "",
-	AccountsPerSync: 100000,
-	ThenQuit:        false,
-	Prune:           "",
-	PruneBloomSize:  2048,
-	ResetToMessage:  -1,
+	Force:            false,
+	Url:              "",
+	ValidateChecksum: true,
+	DownloadPath:     "/tmp/",
+	DownloadPoll:     time.Minute,
+	DevInit:          false,
+	DevInitAddress:   "",
+	DevInitBlockNum:  0,
+	Empty:            false,
+	ImportFile:       "",
+	AccountsPerSync:  100000,
+	ThenQuit:         false,
+	Prune:            "",
+	PruneBloomSize:   2048,
+	ResetToMessage:   -1,
 }
 
 func InitConfigAddOptions(prefix string, f *pflag.FlagSet) {
 	f.Bool(prefix+".force", InitConfigDefault.Force, "if true: in case database exists init code will be reexecuted and genesis block compared to database")
 	f.String(prefix+".url", InitConfigDefault.Url, "url to download initializtion data - will poll if download fails")
+	f.Bool(prefix+".validate-checksum", InitConfigDefault.ValidateChecksum, "if true: validate the downloaded init archive against the sha256 file published next to it (<url>.sha256), and fail if there isn't one")
 	f.String(prefix+".download-path", InitConfigDefault.DownloadPath, "path to save temp downloaded file")
 	f.Duration(prefix+".download-poll", InitConfigDefault.DownloadPoll, "how long to wait between polling attempts")
 	f.Bool(prefix+".dev-init", InitConfigDefault.DevInit, "init with dev data (1 account with balance) instead of file import")
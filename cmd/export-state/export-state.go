@@ -0,0 +1,205 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/trie"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/cmd/conf"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+	"github.com/offchainlabs/nitro/statetransfer"
+)
+
+type ExportStateConfig struct {
+	Persistent            conf.PersistentConfig `koanf:"persistent"`
+	BlockNumber           int64                 `koanf:"block-number"`
+	BlockHash             string                `koanf:"block-hash"`
+	NextBlockNumber       int64                 `koanf:"next-block-number"`
+	Output                string                `koanf:"output"`
+	AllowMissingPreimages bool                  `koanf:"allow-missing-preimages"`
+	LogLevel              int                   `koanf:"log-level"`
+	LogType               string                `koanf:"log-type"`
+}
+
+var DefaultExportStateConfig = ExportStateConfig{
+	Persistent:            conf.PersistentConfigDefault,
+	BlockNumber:           -1,
+	BlockHash:             "",
+	NextBlockNumber:       -1,
+	Output:                "",
+	AllowMissingPreimages: false,
+	LogLevel:              int(log.LvlInfo),
+	LogType:               "plaintext",
+}
+
+func main() {
+	if err := startup(); err != nil {
+		log.Error("Error exporting state", "err", err)
+		os.Exit(1)
+	}
+}
+
+func printSampleUsage(progname string) {
+	fmt.Printf("\n")
+	fmt.Printf("Sample usage:                  %s --persistent.chain /path/to/chain --output /path/to/export \n", progname)
+}
+
+func parseExportState(args []string) (*ExportStateConfig, error) {
+	f := flag.NewFlagSet("export-state", flag.ContinueOnError)
+	conf.PersistentConfigAddOptions("persistent", f)
+	f.Int64("block-number", DefaultExportStateConfig.BlockNumber, "number of the block whose state is exported (-1 for the latest block)")
+	f.String("block-hash", DefaultExportStateConfig.BlockHash, "hash of the block whose state is exported (overrides block-number)")
+	f.Int64("next-block-number", DefaultExportStateConfig.NextBlockNumber, "next block number recorded in the export (-1 for the exported block's number plus one)")
+	f.String("output", DefaultExportStateConfig.Output, "directory to write the exported state to, which can then be imported with --init.import-file <output>/"+statetransfer.JsonInitFileName)
+	f.Bool("allow-missing-preimages", DefaultExportStateConfig.AllowMissingPreimages, "skip accounts and storage slots whose trie key preimages aren't recorded instead of failing (preimages are only recorded by archive nodes)")
+	f.Int("log-level", DefaultExportStateConfig.LogLevel, "log level; 1: ERROR, 2: WARN, 3: INFO, 4: DEBUG, 5: TRACE")
+	f.String("log-type", DefaultExportStateConfig.LogType, "log type (plaintext or json)")
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+	var config ExportStateConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func startup() error {
+	config, err := parseExportState(os.Args[1:])
+	if err != nil {
+		confighelpers.PrintErrorAndExit(err, printSampleUsage)
+	}
+	if config.Persistent.Chain == "" {
+		confighelpers.PrintErrorAndExit(errors.New("--persistent.chain not specified"), printSampleUsage)
+	}
+	if config.Output == "" {
+		confighelpers.PrintErrorAndExit(errors.New("--output not specified"), printSampleUsage)
+	}
+	if err := config.Persistent.Validate(); err != nil {
+		return err
+	}
+
+	logFormat, err := genericconf.ParseLogType(config.LogType)
+	if err != nil {
+		flag.Usage()
+		return fmt.Errorf("error parsing log type: %w", err)
+	}
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, logFormat))
+	glogger.Verbosity(log.Lvl(config.LogLevel))
+	log.Root().SetHandler(glogger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigint := make(chan os.Signal, 1)
+	signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigint
+		log.Info("shutting down because of sigint")
+		cancel()
+	}()
+
+	stackConf := node.DefaultConfig
+	// The database lives in the directory named after the nitro binary
+	stackConf.Name = "nitro"
+	stackConf.DataDir = config.Persistent.Chain
+	stackConf.DBEngine = config.Persistent.DBEngine
+	stackConf.P2P.ListenAddr = ""
+	stackConf.P2P.NoDial = true
+	stackConf.P2P.NoDiscovery = true
+	stack, err := node.New(&stackConf)
+	if err != nil {
+		return err
+	}
+	defer stack.Close()
+	chainDb, err := stack.OpenDatabaseWithFreezer("l2chaindata", 0, config.Persistent.Handles, config.Persistent.Ancient, "", true)
+	if err != nil {
+		return fmt.Errorf("error opening chain database: %w", err)
+	}
+	defer chainDb.Close()
+
+	header, err := findHeader(chainDb, config)
+	if err != nil {
+		return err
+	}
+	nextBlockNumber := header.Number.Uint64() + 1
+	if config.NextBlockNumber >= 0 {
+		nextBlockNumber = uint64(config.NextBlockNumber)
+	}
+	log.Info("exporting state", "block", header.Number, "hash", header.Hash(), "root", header.Root, "nextBlockNumber", nextBlockNumber, "output", config.Output)
+
+	stateDatabase := state.NewDatabaseWithConfig(chainDb, &trie.Config{Preimages: true})
+	writer, err := statetransfer.NewJsonInitDataWriter(config.Output)
+	if err != nil {
+		return err
+	}
+	stats, err := arbosState.ExportArbosState(ctx, stateDatabase, header.Root, header.Time, nextBlockNumber, writer, &arbosState.ExportConfig{
+		AllowMissingPreimages: config.AllowMissingPreimages,
+	})
+	if err != nil {
+		return err
+	}
+	initPath, err := writer.Close()
+	if err != nil {
+		return err
+	}
+	log.Info(
+		"export complete",
+		"initFile", initPath,
+		"addressTableEntries", stats.AddressTableEntries,
+		"retryables", stats.Retryables,
+		"accounts", stats.Accounts,
+		"storageSlots", stats.StorageSlots,
+		"missingPreimages", stats.MissingPreimages,
+	)
+	return nil
+}
+
+func findHeader(chainDb ethdb.Database, config *ExportStateConfig) (*types.Header, error) {
+	var blockHash common.Hash
+	if config.BlockHash != "" {
+		blockHash = common.HexToHash(config.BlockHash)
+	} else if config.BlockNumber >= 0 {
+		blockHash = rawdb.ReadCanonicalHash(chainDb, uint64(config.BlockNumber))
+	} else {
+		blockHash = rawdb.ReadHeadBlockHash(chainDb)
+	}
+	if blockHash == (common.Hash{}) {
+		return nil, errors.New("block to export not found")
+	}
+	blockNumber := rawdb.ReadHeaderNumber(chainDb, blockHash)
+	if blockNumber == nil {
+		return nil, fmt.Errorf("block %v not found", blockHash)
+	}
+	header := rawdb.ReadHeader(chainDb, blockHash, *blockNumber)
+	if header == nil {
+		return nil, fmt.Errorf("header of block %v not found", blockHash)
+	}
+	hasState, err := chainDb.Has(header.Root.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if !hasState {
+		return nil, fmt.Errorf("state of block %v (root %v) isn't available in the database", header.Number, header.Root)
+	}
+	return header, nil
+}
@@ -5,11 +5,16 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math/big"
+	"net/http"
 	"os"
+	"path"
 	"runtime"
 	"strings"
 	"sync"
@@ -121,6 +126,138 @@ func downloadInit(ctx context.Context, initConfig *conf.InitConfig) (string, err
 	}
 }
 
+// Fetches the expected sha256 of the init archive, from <url>.sha256.
+// Returns an empty string if no checksum is published, or if the url scheme can't publish one.
+func fetchInitChecksum(ctx context.Context, initConfig *conf.InitConfig, initFile string) (string, error) {
+	var checksumData []byte
+	if strings.HasPrefix(initConfig.Url, "http://") || strings.HasPrefix(initConfig.Url, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, initConfig.Url+".sha256", nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		// S3 and similar object stores answer 403 rather than 404 for missing objects
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+			return "", nil
+		}
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("unexpected status %v fetching init archive checksum", resp.Status)
+		}
+		checksumData, err = io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if err != nil {
+			return "", err
+		}
+	} else if strings.HasPrefix(initConfig.Url, "file:") {
+		var err error
+		checksumData, err = os.ReadFile(initFile + ".sha256")
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+	} else {
+		return "", nil
+	}
+	// the file is in sha256sum format: the checksum is followed by the file name
+	fields := strings.Fields(string(checksumData))
+	if len(fields) == 0 {
+		return "", errors.New("empty init archive checksum file")
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+func validateInitChecksum(ctx context.Context, initConfig *conf.InitConfig, initFile string) error {
+	expected, err := fetchInitChecksum(ctx, initConfig, initFile)
+	if err != nil {
+		return fmt.Errorf("failed to fetch init archive checksum: %w", err)
+	}
+	if expected == "" {
+		return fmt.Errorf("no checksum found for init archive at %v.sha256 (set --init.validate-checksum=false to import it without one)", initConfig.Url)
+	}
+	file, err := os.Open(initFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	log.Info("validating init archive checksum", "file", initFile)
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(hasher.Sum(nil)); actual != expected {
+		return fmt.Errorf("init archive %v has sha256 %v but %v was expected", initFile, actual, expected)
+	}
+	return nil
+}
+
+// Checks the manifest of an extracted snapshot, if there is one, against the node's chain ID and the
+// extracted arbitrum database. Returns nil if the archive had no manifest.
+func checkSnapshotManifest(stack *node.Node, chainId *big.Int) (*SnapshotManifest, error) {
+	manifestData, err := os.ReadFile(path.Join(stack.InstanceDir(), SnapshotManifestFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest SnapshotManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot manifest: %w", err)
+	}
+	if manifest.ChainId == nil {
+		return nil, errors.New("snapshot manifest has no chain ID")
+	}
+	if manifest.ChainId.Cmp(chainId) != 0 {
+		return nil, fmt.Errorf("init archive is a snapshot of chain ID %v but the node is configured for chain ID %v", manifest.ChainId, chainId)
+	}
+	for _, database := range manifest.Databases {
+		if _, err := os.Stat(path.Join(stack.InstanceDir(), database)); err != nil {
+			return nil, fmt.Errorf("database %v listed in the snapshot manifest wasn't extracted: %w", database, err)
+		}
+	}
+	arbDb, err := stack.OpenDatabase("arbitrumdata", 0, 0, "", true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the snapshot's arbitrum database: %w", err)
+	}
+	messageCount, err := arbnode.ReadMessageCount(arbDb)
+	closeErr := arbDb.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the snapshot's message count: %w", err)
+	}
+	if closeErr != nil {
+		return nil, closeErr
+	}
+	if messageCount != manifest.MessageCount {
+		return nil, fmt.Errorf("snapshot manifest has message count %v but the arbitrum database has %v", manifest.MessageCount, messageCount)
+	}
+	log.Info("extracted snapshot", "block", manifest.BlockNumber, "blockHash", manifest.BlockHash, "messageCount", manifest.MessageCount)
+	return &manifest, nil
+}
+
+// Checks the extracted chain database has the head block and state recorded in the snapshot manifest.
+func checkSnapshotChainDb(chainDb ethdb.Database, manifest *SnapshotManifest) error {
+	if hash := rawdb.ReadCanonicalHash(chainDb, manifest.BlockNumber); hash != manifest.BlockHash {
+		return fmt.Errorf("snapshot manifest has block %v with hash %v but the chain database has %v", manifest.BlockNumber, manifest.BlockHash, hash)
+	}
+	header := rawdb.ReadHeader(chainDb, manifest.BlockHash, manifest.BlockNumber)
+	if header == nil || header.Root != manifest.StateRoot {
+		return fmt.Errorf("snapshot block %v header is missing or doesn't have the manifest's state root %v", manifest.BlockNumber, manifest.StateRoot)
+	}
+	hasState, err := chainDb.Has(manifest.StateRoot.Bytes())
+	if err != nil {
+		return err
+	}
+	if !hasState {
+		return fmt.Errorf("state of snapshot block %v isn't in the chain database", manifest.BlockNumber)
+	}
+	return nil
+}
+
 func validateBlockChain(blockChain *core.BlockChain, chainConfig *params.ChainConfig) error {
 	statedb, err := blockChain.State()
 	if err != nil {
@@ -193,7 +330,13 @@ func openInitializeChainDb(ctx context.Context, stack *node.Node, config *NodeCo
 	if err != nil {
 		return nil, nil, err
 	}
+	if initFile != "" && config.Init.ValidateChecksum {
+		if err := validateInitChecksum(ctx, &config.Init, initFile); err != nil {
+			return nil, nil, err
+		}
+	}
 
+	var snapshotManifest *SnapshotManifest
 	if initFile != "" {
 		reader, err := os.Open(initFile)
 		if err != nil {
@@ -208,6 +351,10 @@ func openInitializeChainDb(ctx context.Context, stack *node.Node, config *NodeCo
 		if err != nil {
 			return nil, nil, fmt.Errorf("couln't extract init archive '%v' err:%w", initFile, err)
 		}
+		snapshotManifest, err = checkSnapshotManifest(stack, chainId)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	var initDataReader statetransfer.InitDataReader = nil
@@ -216,8 +363,20 @@ func openInitializeChainDb(ctx context.Context, stack *node.Node, config *NodeCo
 	if err != nil {
 		return chainDb, nil, err
 	}
+	if snapshotManifest != nil {
+		if err := checkSnapshotChainDb(chainDb, snapshotManifest); err != nil {
+			return chainDb, nil, err
+		}
+	}
 
 	if config.Init.ImportFile != "" {
+		checksumsFile := path.Join(path.Dir(config.Init.ImportFile), statetransfer.JsonChecksumsFileName)
+		if _, err := os.Stat(checksumsFile); err == nil {
+			if err := statetransfer.VerifyJsonInitDataChecksums(path.Dir(config.Init.ImportFile)); err != nil {
+				return chainDb, nil, fmt.Errorf("error verifying import file checksums: %w", err)
+			}
+			log.Info("verified import file checksums", "file", checksumsFile)
+		}
 		initDataReader, err = statetransfer.NewJsonInitDataReader(config.Init.ImportFile)
 		if err != nil {
 			return chainDb, nil, fmt.Errorf("error reading import file: %w", err)
@@ -68,6 +68,8 @@ func printSampleUsage(name string) {
 	fmt.Printf("Options:\n")
 	fmt.Printf("  --help\n")
 	fmt.Printf("  --dev: Start a default L2-only dev chain\n")
+	fmt.Printf("\nCommands:\n")
+	fmt.Printf("  snapshot create: Create a database snapshot archive usable with --init.url\n")
 }
 
 func addUnlockWallet(accountManager *accounts.Manager, walletConf *genericconf.WalletConfig) (common.Address, error) {
@@ -162,6 +164,9 @@ func mainImpl() int {
 	defer cancelFunc()
 
 	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "snapshot" {
+		return snapshotMain(ctx, args[1:])
+	}
 	nodeConfig, l1Wallet, l2DevWallet, err := ParseNode(ctx, args)
 	if err != nil {
 		confighelpers.PrintErrorAndExit(err, printSampleUsage)
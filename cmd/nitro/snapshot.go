@@ -0,0 +1,402 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/state/pruner"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/cmd/conf"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+)
+
+// SnapshotManifestFileName is the name of the manifest at the root of snapshot archives
+const SnapshotManifestFileName = "snapshot-manifest.json"
+
+// The databases included in a snapshot, relative to the node's instance directory.
+// Databases which don't exist in the instance directory are skipped.
+var snapshotDatabases = []string{"l2chaindata", "arbitrumdata", "classic-msg"}
+
+type SnapshotManifest struct {
+	ChainId      *big.Int             `json:"chainId"`
+	BlockNumber  uint64               `json:"blockNumber"`
+	BlockHash    common.Hash          `json:"blockHash"`
+	StateRoot    common.Hash          `json:"stateRoot"`
+	MessageCount arbutil.MessageIndex `json:"messageCount"`
+	PrunedTo     *uint64              `json:"prunedTo,omitempty"`
+	Databases    []string             `json:"databases"`
+}
+
+type SnapshotCreateConfig struct {
+	Persistent     conf.PersistentConfig `koanf:"persistent"`
+	Output         string                `koanf:"output"`
+	PruneToBlock   int64                 `koanf:"prune-to-block"`
+	PruneBloomSize uint64                `koanf:"prune-bloom-size"`
+	LogLevel       int                   `koanf:"log-level"`
+	LogType        string                `koanf:"log-type"`
+}
+
+var SnapshotCreateConfigDefault = SnapshotCreateConfig{
+	Persistent:     conf.PersistentConfigDefault,
+	Output:         "",
+	PruneToBlock:   -1,
+	PruneBloomSize: conf.InitConfigDefault.PruneBloomSize,
+	LogLevel:       int(log.LvlInfo),
+	LogType:        "plaintext",
+}
+
+func SnapshotCreateConfigAddOptions(f *flag.FlagSet) {
+	conf.PersistentConfigAddOptions("persistent", f)
+	f.String("output", SnapshotCreateConfigDefault.Output, "path of the snapshot archive to create (gzip compressed if it ends with .gz or .tgz)")
+	f.Int64("prune-to-block", SnapshotCreateConfigDefault.PruneToBlock, "prune the state of all blocks except this one and the head block before creating the snapshot (-1 to disable)")
+	f.Uint64("prune-bloom-size", SnapshotCreateConfigDefault.PruneBloomSize, "the amount of memory in megabytes to use for the pruning bloom filter (higher values prune better)")
+	f.Int("log-level", SnapshotCreateConfigDefault.LogLevel, "log level; 1: ERROR, 2: WARN, 3: INFO, 4: DEBUG, 5: TRACE")
+	f.String("log-type", SnapshotCreateConfigDefault.LogType, "log type (plaintext or json)")
+}
+
+func printSnapshotUsage(name string) {
+	fmt.Printf("Sample usage: %s snapshot create --persistent.chain /path/to/chain --output snapshot.tar \n\n", name)
+	fmt.Printf("The node must be stopped while the snapshot is created.\n")
+	fmt.Printf("The databases are opened and closed again before archiving, so that any unflushed writes reach disk,\n")
+	fmt.Printf("and the data directory stays locked until the archive is written so that no node can start on it.\n")
+}
+
+func snapshotMain(ctx context.Context, args []string) int {
+	if len(args) == 0 || args[0] != "create" {
+		printSnapshotUsage(os.Args[0])
+		return 1
+	}
+	f := flag.NewFlagSet("snapshot create", flag.ContinueOnError)
+	SnapshotCreateConfigAddOptions(f)
+	k, err := confighelpers.BeginCommonParse(f, args[1:])
+	if err != nil {
+		confighelpers.PrintErrorAndExit(err, printSnapshotUsage)
+	}
+	var config SnapshotCreateConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		confighelpers.PrintErrorAndExit(err, printSnapshotUsage)
+	}
+	if config.Persistent.Chain == "" {
+		confighelpers.PrintErrorAndExit(errors.New("--persistent.chain not specified"), printSnapshotUsage)
+	}
+	if config.Output == "" {
+		confighelpers.PrintErrorAndExit(errors.New("--output not specified"), printSnapshotUsage)
+	}
+	logFormat, err := genericconf.ParseLogType(config.LogType)
+	if err != nil {
+		confighelpers.PrintErrorAndExit(err, printSnapshotUsage)
+	}
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, logFormat))
+	glogger.Verbosity(log.Lvl(config.LogLevel))
+	log.Root().SetHandler(glogger)
+
+	if err := createSnapshot(ctx, &config); err != nil {
+		log.Error("failed to create snapshot", "err", err)
+		return 1
+	}
+	return 0
+}
+
+func createSnapshot(ctx context.Context, config *SnapshotCreateConfig) error {
+	if err := config.Persistent.Validate(); err != nil {
+		return err
+	}
+	if err := config.Persistent.ResolveDirectoryNames(); err != nil {
+		return err
+	}
+	stackConf := node.DefaultConfig
+	stackConf.DataDir = config.Persistent.Chain
+	stackConf.DBEngine = config.Persistent.DBEngine
+	stackConf.P2P.ListenAddr = ""
+	stackConf.P2P.NoDial = true
+	stackConf.P2P.NoDiscovery = true
+	// The stack holds the lock on the data directory until it's closed,
+	// so no node can start writing to the databases while they're archived.
+	stack, err := node.New(&stackConf)
+	if err != nil {
+		return err
+	}
+	defer stack.Close()
+
+	// Opening the databases for writing also makes sure no running node is using them.
+	// They're closed again before returning, which flushes them, so the files archived below are consistent.
+	manifest, err := checkpointDatabases(stack, config)
+	if err != nil {
+		return err
+	}
+	for _, name := range snapshotDatabases {
+		if _, err := os.Stat(stack.ResolvePath(name)); err == nil {
+			manifest.Databases = append(manifest.Databases, name)
+		}
+	}
+	log.Info("creating snapshot", "output", config.Output, "chainId", manifest.ChainId, "block", manifest.BlockNumber, "blockHash", manifest.BlockHash, "messageCount", manifest.MessageCount, "databases", manifest.Databases)
+
+	checksum, err := writeSnapshotArchive(ctx, stack.InstanceDir(), config.Persistent.Ancient, config.Output, manifest)
+	if err != nil {
+		return err
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(config.Output+".manifest.json", manifestData, 0644); err != nil {
+		return err
+	}
+	checksumLine := checksum + "  " + filepath.Base(config.Output) + "\n"
+	if err := os.WriteFile(config.Output+".sha256", []byte(checksumLine), 0644); err != nil {
+		return err
+	}
+	log.Info("snapshot created", "output", config.Output, "sha256", checksum)
+	return nil
+}
+
+// Opens the databases, optionally prunes the chain state, reads the manifest data,
+// and closes them again so that everything is flushed to disk.
+// Failing to close a database is an error, as its files might not be consistent.
+func checkpointDatabases(stack *node.Node, config *SnapshotCreateConfig) (manifest *SnapshotManifest, err error) {
+	chainDb, err := stack.OpenDatabaseWithFreezer("l2chaindata", 0, config.Persistent.Handles, config.Persistent.Ancient, "", false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chain database (is the node still running?): %w", err)
+	}
+	defer flushDb(chainDb, "chain", &err)
+	arbDb, err := stack.OpenDatabase("arbitrumdata", 0, 0, "", false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open arbitrum database (is the node still running?): %w", err)
+	}
+	defer flushDb(arbDb, "arbitrum", &err)
+
+	headHash := rawdb.ReadHeadBlockHash(chainDb)
+	if headHash == (common.Hash{}) {
+		return nil, errors.New("chain database has no head block")
+	}
+	headNumber := rawdb.ReadHeaderNumber(chainDb, headHash)
+	if headNumber == nil {
+		return nil, fmt.Errorf("head block %v not found", headHash)
+	}
+	head := rawdb.ReadHeader(chainDb, headHash, *headNumber)
+	if head == nil {
+		return nil, fmt.Errorf("head block header %v not found", headHash)
+	}
+	hasState, err := chainDb.Has(head.Root.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if !hasState {
+		return nil, fmt.Errorf("state of head block %v isn't on disk, the node might not have been shut down cleanly", head.Number)
+	}
+	messageCount, err := arbnode.ReadMessageCount(arbDb)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message count: %w", err)
+	}
+	statedb, err := state.New(head.Root, state.NewDatabase(chainDb), nil)
+	if err != nil {
+		return nil, err
+	}
+	arbState, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	chainId, err := arbState.ChainId()
+	if err != nil {
+		return nil, err
+	}
+	manifest = &SnapshotManifest{
+		ChainId:      chainId,
+		BlockNumber:  head.Number.Uint64(),
+		BlockHash:    headHash,
+		StateRoot:    head.Root,
+		MessageCount: messageCount,
+	}
+
+	if config.PruneToBlock >= 0 {
+		target, err := pruneSnapshotState(stack, chainDb, head, uint64(config.PruneToBlock), config.PruneBloomSize)
+		if err != nil {
+			return nil, err
+		}
+		manifest.PrunedTo = &target
+	}
+	return manifest, nil
+}
+
+// Closes db, setting *errp if it failed and no earlier error was returned.
+func flushDb(db ethdb.Database, name string, errp *error) {
+	if err := db.Close(); err != nil && *errp == nil {
+		*errp = fmt.Errorf("failed to close %v database: %w", name, err)
+	}
+}
+
+func pruneSnapshotState(stack *node.Node, chainDb ethdb.Database, head *types.Header, target uint64, bloomSize uint64) (uint64, error) {
+	if target > head.Number.Uint64() {
+		return 0, fmt.Errorf("prune target block %v is after the head block %v", target, head.Number)
+	}
+	targetHeader := rawdb.ReadHeader(chainDb, rawdb.ReadCanonicalHash(chainDb, target), target)
+	if targetHeader == nil {
+		return 0, fmt.Errorf("prune target block %v not found", target)
+	}
+	hasState, err := chainDb.Has(targetHeader.Root.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	if !hasState {
+		return 0, fmt.Errorf("state of prune target block %v isn't on disk", target)
+	}
+	roots := []common.Hash{targetHeader.Root}
+	if targetHeader.Root != head.Root {
+		roots = append(roots, head.Root)
+	}
+	roots = append(roots, common.Hash{}) // the latest snapshot
+	log.Info("pruning state before creating snapshot", "target", target, "head", head.Number)
+	statePruner, err := pruner.NewPruner(chainDb, pruner.Config{Datadir: stack.InstanceDir(), BloomSize: bloomSize})
+	if err != nil {
+		return 0, err
+	}
+	if err := statePruner.Prune(roots); err != nil {
+		return 0, err
+	}
+	return target, nil
+}
+
+// Writes the manifest and databases into a tar archive, returning the hex sha256 of the archive file.
+// If ancient is set, it's the ancient directory of the chain database, stored outside of the instance directory.
+func writeSnapshotArchive(ctx context.Context, instanceDir string, ancient string, output string, manifest *SnapshotManifest) (string, error) {
+	file, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hasher := sha256.New()
+	buffered := bufio.NewWriterSize(io.MultiWriter(file, hasher), 1<<20)
+	var archiveWriter io.Writer = buffered
+	var gzipWriter *gzip.Writer
+	if strings.HasSuffix(output, ".gz") || strings.HasSuffix(output, ".tgz") {
+		gzipWriter = gzip.NewWriter(buffered)
+		archiveWriter = gzipWriter
+	}
+	tarWriter := tar.NewWriter(archiveWriter)
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	err = tarWriter.WriteHeader(&tar.Header{
+		Name: SnapshotManifestFileName,
+		Mode: 0644,
+		Size: int64(len(manifestData)),
+	})
+	if err != nil {
+		return "", err
+	}
+	if _, err := tarWriter.Write(manifestData); err != nil {
+		return "", err
+	}
+
+	for _, name := range manifest.Databases {
+		if err := addDirToArchive(ctx, tarWriter, filepath.Join(instanceDir, name), name); err != nil {
+			return "", fmt.Errorf("failed to archive %v: %w", name, err)
+		}
+		log.Info("archived database", "name", name)
+	}
+	if ancient != "" {
+		// the importing node expects the ancients in their default location
+		if err := addDirToArchive(ctx, tarWriter, ancient, "l2chaindata/ancient"); err != nil {
+			return "", fmt.Errorf("failed to archive ancients: %w", err)
+		}
+		log.Info("archived ancients", "dir", ancient)
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return "", err
+	}
+	if gzipWriter != nil {
+		if err := gzipWriter.Close(); err != nil {
+			return "", err
+		}
+	}
+	if err := buffered.Flush(); err != nil {
+		return "", err
+	}
+	if err := file.Sync(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+func addDirToArchive(ctx context.Context, tarWriter *tar.Writer, dir string, archiveDir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		return addFileToArchive(tarWriter, path, filepath.Join(archiveDir, relPath), info)
+	})
+}
+
+func addFileToArchive(tarWriter *tar.Writer, path string, archivePath string, info os.FileInfo) error {
+	if info.Mode()&os.ModeSymlink != 0 {
+		// follow symlinks, e.g. to an ancient directory on a different disk
+		resolved, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if resolved.IsDir() {
+			return fmt.Errorf("symlinked directory %v isn't supported", path)
+		}
+		info = resolved
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.ToSlash(archivePath)
+	if info.IsDir() {
+		header.Name += "/"
+		return tarWriter.WriteHeader(header)
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	// only copy the size recorded in the header, in case the file is being appended to
+	_, err = io.CopyN(tarWriter, source, header.Size)
+	return err
+}
@@ -0,0 +1,169 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/log"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+	"github.com/offchainlabs/nitro/precompiles"
+)
+
+type PrecompileAbiConfig struct {
+	Source          string `koanf:"source"`
+	Output          string `koanf:"output"`
+	Check           bool   `koanf:"check"`
+	ReleasedVersion uint64 `koanf:"released-version"`
+	LogLevel        int    `koanf:"log-level"`
+	LogType         string `koanf:"log-type"`
+}
+
+var DefaultPrecompileAbiConfig = PrecompileAbiConfig{
+	Source:          "precompiles",
+	Output:          "precompiles/abi",
+	Check:           false,
+	ReleasedVersion: 11,
+	LogLevel:        int(log.LvlInfo),
+	LogType:         "plaintext",
+}
+
+const manifestFile = "manifest.json"
+const markdownFile = "PRECOMPILES.md"
+
+func main() {
+	if err := startup(); err != nil {
+		log.Error("Error generating precompile ABIs", "err", err)
+		os.Exit(1)
+	}
+}
+
+func printSampleUsage(progname string) {
+	fmt.Printf("\n")
+	fmt.Printf("Sample usage:                  %s --output precompiles/abi \n", progname)
+	fmt.Printf("Check the committed ABIs:      %s --check --released-version 11 \n", progname)
+}
+
+func parsePrecompileAbi(args []string) (*PrecompileAbiConfig, error) {
+	f := flag.NewFlagSet("precompile-abi", flag.ContinueOnError)
+	f.String("source", DefaultPrecompileAbiConfig.Source, "directory of the precompiles' go sources, which are searched for _preVersionN_ methods")
+	f.String("output", DefaultPrecompileAbiConfig.Output, "directory the manifest, per-version ABIs, and markdown reference are written to")
+	f.Bool("check", DefaultPrecompileAbiConfig.Check, "instead of writing the output, check it's up to date and compatible with the manifest already there")
+	f.Uint64("released-version", DefaultPrecompileAbiConfig.ReleasedVersion, "the latest released ArbOS version, whose precompile ABIs must not change")
+	f.Int("log-level", DefaultPrecompileAbiConfig.LogLevel, "log level; 1: ERROR, 2: WARN, 3: INFO, 4: DEBUG, 5: TRACE")
+	f.String("log-type", DefaultPrecompileAbiConfig.LogType, "log type (plaintext or json)")
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+	var config PrecompileAbiConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func startup() error {
+	config, err := parsePrecompileAbi(os.Args[1:])
+	if err != nil {
+		confighelpers.PrintErrorAndExit(err, printSampleUsage)
+	}
+
+	logFormat, err := genericconf.ParseLogType(config.LogType)
+	if err != nil {
+		flag.Usage()
+		return fmt.Errorf("error parsing log type: %w", err)
+	}
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, logFormat))
+	glogger.Verbosity(log.Lvl(config.LogLevel))
+	log.Root().SetHandler(glogger)
+
+	// Building the precompiles fails if a go method has no solidity interface or their types differ
+	behaviorChanges, err := precompiles.PreVersionMethods(config.Source)
+	if err != nil {
+		return fmt.Errorf("error parsing precompile sources: %w", err)
+	}
+	manifest, err := precompiles.BuildManifest(behaviorChanges)
+	if err != nil {
+		return err
+	}
+	files, err := renderFiles(manifest)
+	if err != nil {
+		return err
+	}
+
+	if !config.Check {
+		if err := os.MkdirAll(config.Output, 0755); err != nil {
+			return err
+		}
+		for name, contents := range files {
+			if err := os.WriteFile(filepath.Join(config.Output, name), contents, 0644); err != nil {
+				return err
+			}
+		}
+		log.Info("wrote precompile ABIs", "dir", config.Output, "files", len(files))
+		return nil
+	}
+
+	previousJson, err := os.ReadFile(filepath.Join(config.Output, manifestFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("no manifest to check against in %v (run make precompile-abi and commit it)", config.Output)
+	}
+	if err != nil {
+		return fmt.Errorf("error reading the existing manifest (run without --check to generate it): %w", err)
+	}
+	var previous precompiles.Manifest
+	if err := json.Unmarshal(previousJson, &previous); err != nil {
+		return fmt.Errorf("error parsing the existing manifest: %w", err)
+	}
+	problems := precompiles.CheckManifestCompatibility(&previous, manifest, config.ReleasedVersion)
+	for _, problem := range problems {
+		log.Error("incompatible precompile change", "err", problem)
+	}
+	for name, contents := range files {
+		existing, err := os.ReadFile(filepath.Join(config.Output, name))
+		if err != nil || !bytes.Equal(existing, contents) {
+			log.Error("generated file is out of date, run make precompile-abi", "file", name)
+			problems = append(problems, fmt.Errorf("%v is out of date", name))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("found %v problems with the precompile ABIs", len(problems))
+	}
+	log.Info("precompile ABIs are up to date and compatible", "releasedVersion", config.ReleasedVersion)
+	return nil
+}
+
+// renderFiles produces the manifest, an ABI file for each ArbOS version the precompiles changed in, and the markdown reference
+func renderFiles(manifest *precompiles.Manifest) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	encode := func(name string, value interface{}) error {
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return err
+		}
+		files[name] = append(data, '\n')
+		return nil
+	}
+	if err := encode(manifestFile, manifest); err != nil {
+		return nil, err
+	}
+	for _, version := range manifest.Versions() {
+		if err := encode(fmt.Sprintf("arbos%v.json", version), manifest.ABIsAt(version)); err != nil {
+			return nil, err
+		}
+	}
+	files[markdownFile] = []byte(manifest.Markdown())
+	return files, nil
+}
@@ -0,0 +1,223 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/cmd/conf"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+	"github.com/offchainlabs/nitro/execution/gethexec"
+)
+
+type UpgradeRehearsalConfig struct {
+	Persistent       conf.PersistentConfig `koanf:"persistent"`
+	TargetVersion    uint64                `koanf:"target-version"`
+	UpgradeTimestamp uint64                `koanf:"upgrade-timestamp"`
+	StartBlock       int64                 `koanf:"start-block"`
+	Blocks           uint64                `koanf:"blocks"`
+	Output           string                `koanf:"output"`
+	LogLevel         int                   `koanf:"log-level"`
+	LogType          string                `koanf:"log-type"`
+}
+
+var DefaultUpgradeRehearsalConfig = UpgradeRehearsalConfig{
+	Persistent:       conf.PersistentConfigDefault,
+	TargetVersion:    0,
+	UpgradeTimestamp: 0,
+	StartBlock:       -1,
+	Blocks:           100,
+	Output:           "",
+	LogLevel:         int(log.LvlInfo),
+	LogType:          "plaintext",
+}
+
+func main() {
+	if err := startup(); err != nil {
+		log.Error("Error rehearsing ArbOS upgrade", "err", err)
+		os.Exit(1)
+	}
+}
+
+func printSampleUsage(progname string) {
+	fmt.Printf("\n")
+	fmt.Printf("Sample usage:                  %s --persistent.chain /path/to/chain --target-version 21 --blocks 1000 \n", progname)
+}
+
+func parseUpgradeRehearsal(args []string) (*UpgradeRehearsalConfig, error) {
+	f := flag.NewFlagSet("upgrade-rehearsal", flag.ContinueOnError)
+	conf.PersistentConfigAddOptions("persistent", f)
+	f.Uint64("target-version", DefaultUpgradeRehearsalConfig.TargetVersion, "ArbOS version to upgrade to (the current version re-executes the blocks without upgrading)")
+	f.Uint64("upgrade-timestamp", DefaultUpgradeRehearsalConfig.UpgradeTimestamp, "simulated timestamp of the upgrade, which happens in the first re-executed block at or after it (0 to upgrade in the first block)")
+	f.Int64("start-block", DefaultUpgradeRehearsalConfig.StartBlock, "block whose state is forked, with the blocks after it re-executed (-1 for the block which leaves enough blocks before the latest one)")
+	f.Uint64("blocks", DefaultUpgradeRehearsalConfig.Blocks, "number of blocks to re-execute")
+	f.String("output", DefaultUpgradeRehearsalConfig.Output, "file to write the JSON report to (stdout if empty)")
+	f.Int("log-level", DefaultUpgradeRehearsalConfig.LogLevel, "log level; 1: ERROR, 2: WARN, 3: INFO, 4: DEBUG, 5: TRACE")
+	f.String("log-type", DefaultUpgradeRehearsalConfig.LogType, "log type (plaintext or json)")
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+	var config UpgradeRehearsalConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func startup() error {
+	config, err := parseUpgradeRehearsal(os.Args[1:])
+	if err != nil {
+		confighelpers.PrintErrorAndExit(err, printSampleUsage)
+	}
+	if config.Persistent.Chain == "" {
+		confighelpers.PrintErrorAndExit(errors.New("--persistent.chain not specified"), printSampleUsage)
+	}
+	if config.TargetVersion == 0 {
+		confighelpers.PrintErrorAndExit(errors.New("--target-version not specified"), printSampleUsage)
+	}
+	if err := config.Persistent.Validate(); err != nil {
+		return err
+	}
+
+	logFormat, err := genericconf.ParseLogType(config.LogType)
+	if err != nil {
+		flag.Usage()
+		return fmt.Errorf("error parsing log type: %w", err)
+	}
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, logFormat))
+	glogger.Verbosity(log.Lvl(config.LogLevel))
+	log.Root().SetHandler(glogger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigint := make(chan os.Signal, 1)
+	signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigint
+		log.Info("shutting down because of sigint")
+		cancel()
+	}()
+
+	stackConf := node.DefaultConfig
+	// The database lives in the directory named after the nitro binary
+	stackConf.Name = "nitro"
+	stackConf.DataDir = config.Persistent.Chain
+	stackConf.DBEngine = config.Persistent.DBEngine
+	stackConf.P2P.ListenAddr = ""
+	stackConf.P2P.NoDial = true
+	stackConf.P2P.NoDiscovery = true
+	stack, err := node.New(&stackConf)
+	if err != nil {
+		return err
+	}
+	defer stack.Close()
+	chainDb, err := stack.OpenDatabaseWithFreezer("l2chaindata", 0, config.Persistent.Handles, config.Persistent.Ancient, "", true)
+	if err != nil {
+		return fmt.Errorf("error opening chain database: %w", err)
+	}
+	defer chainDb.Close()
+	arbDb, err := stack.OpenDatabase("arbitrumdata", 0, 0, "", true)
+	if err != nil {
+		return fmt.Errorf("error opening arbitrum database: %w", err)
+	}
+	defer arbDb.Close()
+
+	head := rawdb.ReadHeadHeader(chainDb)
+	if head == nil {
+		return errors.New("latest block not found")
+	}
+	startBlock := uint64(config.StartBlock)
+	if config.StartBlock < 0 {
+		if head.Number.Uint64() < config.Blocks {
+			return fmt.Errorf("the chain only has %v blocks", head.Number)
+		}
+		startBlock = head.Number.Uint64() - config.Blocks
+	}
+	chainConfig, err := readChainConfig(chainDb, head.Root)
+	if err != nil {
+		return err
+	}
+
+	readMessage := func(index arbutil.MessageIndex) (*arbostypes.MessageWithMetadata, error) {
+		return arbnode.ReadMessage(arbDb, index)
+	}
+	report, err := gethexec.RehearseArbOSUpgrade(ctx, chainDb, chainConfig, readMessage, &gethexec.UpgradeRehearsalConfig{
+		TargetVersion:    config.TargetVersion,
+		UpgradeTimestamp: config.UpgradeTimestamp,
+		StartBlock:       startBlock,
+		Blocks:           config.Blocks,
+	})
+	if err != nil {
+		return err
+	}
+	log.Info(
+		"rehearsal complete",
+		"fromVersion", report.FromVersion,
+		"targetVersion", report.TargetVersion,
+		"blocks", len(report.Blocks),
+		"rootMismatches", report.RootMismatches,
+		"failedTxs", report.FailedTxs,
+		"gasUsedDelta", report.GasUsedDelta,
+	)
+
+	var writer io.Writer = os.Stdout
+	if config.Output != "" {
+		file, err := os.Create(config.Output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		writer = file
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// readChainConfig reads the chain config ArbOS stores in its state
+func readChainConfig(chainDb ethdb.Database, root common.Hash) (*params.ChainConfig, error) {
+	statedb, err := state.New(root, state.NewDatabase(chainDb), nil)
+	if err != nil {
+		return nil, err
+	}
+	arbState, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	serializedConfig, err := arbState.ChainConfig()
+	if err != nil {
+		return nil, err
+	}
+	if len(serializedConfig) == 0 {
+		return nil, errors.New("chain config isn't stored in the ArbOS state")
+	}
+	var chainConfig params.ChainConfig
+	if err := json.Unmarshal(serializedConfig, &chainConfig); err != nil {
+		return nil, fmt.Errorf("failed to deserialize chain config: %w", err)
+	}
+	return &chainConfig, nil
+}
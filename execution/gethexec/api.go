@@ -4,6 +4,7 @@
 package gethexec
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -18,8 +19,10 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/offchainlabs/nitro/arbos"
 	"github.com/offchainlabs/nitro/arbos/arbosState"
 	"github.com/offchainlabs/nitro/arbos/retryables"
+	"github.com/offchainlabs/nitro/arbos/util"
 	"github.com/offchainlabs/nitro/util/arbmath"
 )
 
@@ -177,6 +180,168 @@ func (api *ArbDebugAPI) PricingModel(ctx context.Context, start, end rpc.BlockNu
 	return history, nil
 }
 
+type BatchPostingReport struct {
+	BlockNumber        uint64         `json:"blockNumber"`
+	TxHash             common.Hash    `json:"txHash"`
+	BatchTimestamp     *big.Int       `json:"batchTimestamp"`
+	BatchPosterAddress common.Address `json:"batchPosterAddress"`
+	BatchNumber        uint64         `json:"batchNumber"`
+	BatchDataGas       uint64         `json:"batchDataGas"`
+	L1BaseFeeWei       *big.Int       `json:"l1BaseFeeWei"`
+	BlobGasUsed        uint64         `json:"blobGasUsed,omitempty"`
+	BlobBaseFeeWei     *big.Int       `json:"blobBaseFeeWei,omitempty"`
+}
+
+type L1PricingModelHistory struct {
+	Start                  uint64                `json:"start"`
+	End                    uint64                `json:"end"`
+	Step                   uint64                `json:"step"`
+	Timestamp              []uint64              `json:"timestamp"`
+	PricePerUnit           []*big.Int            `json:"pricePerUnit"`
+	UnitsSinceUpdate       []uint64              `json:"unitsSinceUpdate"`
+	LastUpdateTime         []uint64              `json:"lastUpdateTime"`
+	FundsDue               []*big.Int            `json:"fundsDue"`
+	FundsDueForRewards     []*big.Int            `json:"fundsDueForRewards"`
+	FeesAvailable          []*big.Int            `json:"feesAvailable"`
+	LastSurplus            []*big.Int            `json:"lastSurplus"`
+	AmortizedCostCapBips   []uint64              `json:"amortizedCostCapBips"`
+	EquilibrationUnits     []*big.Int            `json:"equilibrationUnits"`
+	PerBatchGasCost        []int64               `json:"perBatchGasCost"`
+	LastBatchPostingReport []*BatchPostingReport `json:"lastBatchPostingReport"`
+}
+
+func (api *ArbDebugAPI) L1PricingModel(ctx context.Context, start, end rpc.BlockNumber) (L1PricingModelHistory, error) {
+	first, step, last, blocks, err := api.evenlySpaceBlocks(start, end)
+	if err != nil {
+		return L1PricingModelHistory{}, err
+	}
+
+	history := L1PricingModelHistory{
+		Start:                  first,
+		End:                    last,
+		Step:                   step,
+		Timestamp:              make([]uint64, blocks),
+		PricePerUnit:           make([]*big.Int, blocks),
+		UnitsSinceUpdate:       make([]uint64, blocks),
+		LastUpdateTime:         make([]uint64, blocks),
+		FundsDue:               make([]*big.Int, blocks),
+		FundsDueForRewards:     make([]*big.Int, blocks),
+		FeesAvailable:          make([]*big.Int, blocks),
+		LastSurplus:            make([]*big.Int, blocks),
+		AmortizedCostCapBips:   make([]uint64, blocks),
+		EquilibrationUnits:     make([]*big.Int, blocks),
+		PerBatchGasCost:        make([]int64, blocks),
+		LastBatchPostingReport: make([]*BatchPostingReport, blocks),
+	}
+
+	var report *BatchPostingReport
+	var lastReportTime uint64
+	var lastReportSurplus *big.Int
+	searchFrom := uint64(0)
+	for i := uint64(0); i < blocks; i++ {
+		if ctx.Err() != nil {
+			return history, ctx.Err()
+		}
+		blockNum := first + i*step
+		state, header, err := stateAndHeader(api.blockchain, blockNum)
+		if err != nil {
+			return history, err
+		}
+		l1Pricing := state.L1PricingState()
+
+		pricePerUnit, err := l1Pricing.PricePerUnit()
+		if err != nil {
+			return history, err
+		}
+		unitsSinceUpdate, _ := l1Pricing.UnitsSinceUpdate()
+		lastUpdateTime, _ := l1Pricing.LastUpdateTime()
+		fundsDue, _ := l1Pricing.BatchPosterTable().TotalFundsDue()
+		fundsDueForRewards, _ := l1Pricing.FundsDueForRewards()
+		feesAvailable, _ := l1Pricing.L1FeesAvailable()
+		lastSurplus, _ := l1Pricing.LastSurplus()
+		amortizedCostCapBips, _ := l1Pricing.AmortizedCostCapBips()
+		equilibrationUnits, _ := l1Pricing.EquilibrationUnits()
+		perBatchGasCost, _ := l1Pricing.PerBatchGasCost()
+
+		history.Timestamp[i] = header.Time
+		history.PricePerUnit[i] = pricePerUnit
+		history.UnitsSinceUpdate[i] = unitsSinceUpdate
+		history.LastUpdateTime[i] = lastUpdateTime
+		history.FundsDue[i] = fundsDue
+		history.FundsDueForRewards[i] = fundsDueForRewards
+		history.FeesAvailable[i] = feesAvailable
+		history.LastSurplus[i] = lastSurplus
+		history.AmortizedCostCapBips[i] = amortizedCostCapBips
+		history.EquilibrationUnits[i] = equilibrationUnits
+		history.PerBatchGasCost[i] = perBatchGasCost
+
+		// Applying a report sets the last update time and surplus, so blocks are only searched
+		// for a new report if one of these changed since the previous sample. Reports applied
+		// more than blockRangeBound blocks before a sample aren't searched for, and are null.
+		if i == 0 || lastUpdateTime != lastReportTime || !arbmath.BigEquals(lastSurplus, lastReportSurplus) {
+			if blockNum >= api.blockRangeBound && blockNum-api.blockRangeBound+1 > searchFrom {
+				searchFrom = blockNum - api.blockRangeBound + 1
+			}
+			report, err = api.findBatchPostingReport(blockNum, searchFrom)
+			if err != nil {
+				return history, err
+			}
+		}
+		lastReportTime = lastUpdateTime
+		lastReportSurplus = lastSurplus
+		history.LastBatchPostingReport[i] = report
+		searchFrom = blockNum + 1
+	}
+	return history, nil
+}
+
+// findBatchPostingReport returns the last batch posting report applied in blocks [from, to], or nil if there's none.
+func (api *ArbDebugAPI) findBatchPostingReport(to uint64, from uint64) (*BatchPostingReport, error) {
+	for blockNum := to; blockNum >= from && blockNum > 0; blockNum-- {
+		block := api.blockchain.GetBlockByNumber(blockNum)
+		if block == nil {
+			return nil, fmt.Errorf("block %v not found", blockNum)
+		}
+		if !api.blockchain.Config().IsArbitrumNitro(block.Number()) {
+			return nil, nil
+		}
+		txs := block.Transactions()
+		for i := len(txs) - 1; i >= 0; i-- {
+			tx := txs[i]
+			if tx.Type() != types.ArbitrumInternalTxType || len(tx.Data()) < 4 {
+				continue
+			}
+			withBlobs := bytes.Equal(tx.Data()[:4], arbos.InternalTxBatchPostingReportWithBlobsMethodID[:])
+			if !withBlobs && !bytes.Equal(tx.Data()[:4], arbos.InternalTxBatchPostingReportMethodID[:]) {
+				continue
+			}
+			unpack := util.UnpackInternalTxDataBatchPostingReport
+			if withBlobs {
+				unpack = util.UnpackInternalTxDataBatchPostingReportWithBlobs
+			}
+			inputs, err := unpack(tx.Data())
+			if err != nil {
+				return nil, err
+			}
+			report := &BatchPostingReport{
+				BlockNumber:        blockNum,
+				TxHash:             tx.Hash(),
+				BatchTimestamp:     util.SafeMapGet[*big.Int](inputs, "batchTimestamp"),
+				BatchPosterAddress: util.SafeMapGet[common.Address](inputs, "batchPosterAddress"),
+				BatchNumber:        util.SafeMapGet[uint64](inputs, "batchNumber"),
+				BatchDataGas:       util.SafeMapGet[uint64](inputs, "batchDataGas"),
+				L1BaseFeeWei:       util.SafeMapGet[*big.Int](inputs, "l1BaseFeeWei"),
+			}
+			if withBlobs {
+				report.BlobGasUsed = util.SafeMapGet[uint64](inputs, "blobGasUsed")
+				report.BlobBaseFeeWei = util.SafeMapGet[*big.Int](inputs, "blobBaseFeeWei")
+			}
+			return report, nil
+		}
+	}
+	return nil, nil
+}
+
 type TimeoutQueueHistory struct {
 	Start uint64   `json:"start"`
 	End   uint64   `json:"end"`
@@ -263,6 +428,83 @@ func (api *ArbDebugAPI) TimeoutQueue(ctx context.Context, blockNum rpc.BlockNumb
 	return queue, err
 }
 
+type LiveRetryable struct {
+	TicketId    common.Hash     `json:"ticketId"`
+	Timeout     uint64          `json:"timeout"`
+	NumTries    uint64          `json:"numTries"`
+	From        common.Address  `json:"from"`
+	To          *common.Address `json:"to"`
+	Callvalue   *big.Int        `json:"callvalue"`
+	Beneficiary common.Address  `json:"beneficiary"`
+}
+
+type LiveRetryables struct {
+	BlockNumber uint64          `json:"blockNumber"`
+	Complete    bool            `json:"complete"` // false if the timeout queue was longer than the node's bound
+	Retryables  []LiveRetryable `json:"retryables"`
+}
+
+// LiveRetryables lists the retryables that haven't been redeemed, canceled, or expired as of a block.
+// Unlike TimeoutQueue, each retryable appears once, with the timeout including any lifetimes it's been kept alive for.
+func (api *ArbDebugAPI) LiveRetryables(ctx context.Context, blockNum rpc.BlockNumber) (LiveRetryables, error) {
+
+	blockNum, _ = api.blockchain.ClipToPostNitroGenesis(blockNum)
+
+	live := LiveRetryables{
+		BlockNumber: uint64(blockNum),
+		Complete:    true,
+		Retryables:  []LiveRetryable{},
+	}
+
+	state, header, err := stateAndHeader(api.blockchain, uint64(blockNum))
+	if err != nil {
+		return live, err
+	}
+
+	seen := make(map[common.Hash]struct{})
+	closure := func(index uint64, ticket common.Hash) (bool, error) {
+		if index == api.timeoutQueueBound {
+			live.Complete = false
+			return true, nil
+		}
+		if _, ok := seen[ticket]; ok {
+			// retryables that have been kept alive appear in the queue more than once
+			return false, nil
+		}
+		seen[ticket] = struct{}{}
+
+		retryable, err := state.RetryableState().OpenRetryable(ticket, header.Time)
+		if retryable == nil || err != nil {
+			return false, err
+		}
+		timeout, err := retryable.CalculateTimeout()
+		if err != nil {
+			return false, err
+		}
+		numTries, _ := retryable.NumTries()
+		from, _ := retryable.From()
+		to, _ := retryable.To()
+		callvalue, _ := retryable.Callvalue()
+		beneficiary, err := retryable.Beneficiary()
+		if err != nil {
+			return false, err
+		}
+		live.Retryables = append(live.Retryables, LiveRetryable{
+			TicketId:    ticket,
+			Timeout:     timeout,
+			NumTries:    numTries,
+			From:        from,
+			To:          to,
+			Callvalue:   callvalue,
+			Beneficiary: beneficiary,
+		})
+		return false, nil
+	}
+
+	err = state.RetryableState().TimeoutQueue.ForEach(closure)
+	return live, err
+}
+
 func stateAndHeader(blockchain *core.BlockChain, block uint64) (*arbosState.ArbosState, *types.Header, error) {
 	header := blockchain.GetHeaderByNumber(block)
 	if !blockchain.Config().IsArbitrumNitro(header.Number) {
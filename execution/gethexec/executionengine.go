@@ -9,17 +9,21 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/arbos/addressTable"
 	"github.com/offchainlabs/nitro/arbos/arbosState"
 	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbos/functiontable"
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
 	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/arbmath"
 	"github.com/offchainlabs/nitro/util/sharedmetrics"
 	"github.com/offchainlabs/nitro/util/stopwaiter"
 )
@@ -31,7 +35,7 @@ type ExecutionEngine struct {
 	streamer execution.TransactionStreamer
 	recorder *BlockRecorder
 
-	resequenceChan    chan []*arbostypes.MessageWithMetadata
+	resequenceChan    chan reorgedMessages
 	createBlocksMutex sync.Mutex
 
 	newBlockNotifier chan struct{}
@@ -40,13 +44,15 @@ type ExecutionEngine struct {
 
 	nextScheduledVersionCheck time.Time // protected by the createBlocksMutex
 
-	reorgSequencing bool
+	reorgSequencing   bool
+	compressAddresses bool
+	compressSelectors bool
 }
 
 func NewExecutionEngine(bc *core.BlockChain) (*ExecutionEngine, error) {
 	return &ExecutionEngine{
 		bc:               bc,
-		resequenceChan:   make(chan []*arbostypes.MessageWithMetadata),
+		resequenceChan:   make(chan reorgedMessages),
 		newBlockNotifier: make(chan struct{}, 1),
 	}, nil
 }
@@ -71,6 +77,20 @@ func (s *ExecutionEngine) EnableReorgSequencing() {
 	s.reorgSequencing = true
 }
 
+func (s *ExecutionEngine) EnableAddressCompression() {
+	if s.Started() {
+		panic("trying to enable address compression after start")
+	}
+	s.compressAddresses = true
+}
+
+func (s *ExecutionEngine) EnableSelectorCompression() {
+	if s.Started() {
+		panic("trying to enable selector compression after start")
+	}
+	s.compressSelectors = true
+}
+
 func (s *ExecutionEngine) SetTransactionStreamer(streamer execution.TransactionStreamer) {
 	if s.Started() {
 		panic("trying to set transaction streamer after start")
@@ -101,6 +121,12 @@ func (s *ExecutionEngine) Reorg(count arbutil.MessageIndex, newMessages []arbost
 		log.Warn("reorg target block not found", "block", blockNum)
 		return nil
 	}
+	// Old messages are decompressed against the address tables of the blocks they were built on,
+	// which stop being canonical once we reorg.
+	oldParents := make([]*types.Header, len(oldMessages))
+	for i := range oldMessages {
+		oldParents[i] = s.bc.GetHeaderByNumber(uint64(blockNum) + uint64(i))
+	}
 
 	err := s.bc.ReorgToOldBlock(targetBlock)
 	if err != nil {
@@ -116,7 +142,7 @@ func (s *ExecutionEngine) Reorg(count arbutil.MessageIndex, newMessages []arbost
 		s.recorder.ReorgTo(targetBlock.Header())
 	}
 	if len(oldMessages) > 0 {
-		s.resequenceChan <- oldMessages
+		s.resequenceChan <- reorgedMessages{oldMessages, oldParents}
 		resequencing = true
 	}
 	return nil
@@ -152,15 +178,20 @@ func (s *ExecutionEngine) NextDelayedMessageNumber() (uint64, error) {
 	return currentHeader.Nonce.Uint64(), nil
 }
 
-func messageFromTxes(header *arbostypes.L1IncomingMessageHeader, txes types.Transactions, txErrors []error) (*arbostypes.L1IncomingMessage, error) {
+func messageFromTxes(
+	header *arbostypes.L1IncomingMessageHeader,
+	txes types.Transactions,
+	txErrors []error,
+	addresses *arbos.AddressCompressor,
+	selectors *arbos.SelectorCompressor,
+) (*arbostypes.L1IncomingMessage, error) {
 	var l2Message []byte
 	if len(txes) == 1 && txErrors[0] == nil {
-		txBytes, err := txes[0].MarshalBinary()
+		segment, err := selectors.EncodeSignedTx(txes[0], addresses)
 		if err != nil {
 			return nil, err
 		}
-		l2Message = append(l2Message, arbos.L2MessageKind_SignedTx)
-		l2Message = append(l2Message, txBytes...)
+		l2Message = append(l2Message, segment...)
 	} else {
 		l2Message = append(l2Message, arbos.L2MessageKind_Batch)
 		sizeBuf := make([]byte, 8)
@@ -168,14 +199,13 @@ func messageFromTxes(header *arbostypes.L1IncomingMessageHeader, txes types.Tran
 			if txErrors[i] != nil {
 				continue
 			}
-			txBytes, err := tx.MarshalBinary()
+			segment, err := selectors.EncodeSignedTx(tx, addresses)
 			if err != nil {
 				return nil, err
 			}
-			binary.BigEndian.PutUint64(sizeBuf, uint64(len(txBytes)+1))
+			binary.BigEndian.PutUint64(sizeBuf, uint64(len(segment)))
 			l2Message = append(l2Message, sizeBuf...)
-			l2Message = append(l2Message, arbos.L2MessageKind_SignedTx)
-			l2Message = append(l2Message, txBytes...)
+			l2Message = append(l2Message, segment...)
 		}
 	}
 	return &arbostypes.L1IncomingMessage{
@@ -185,11 +215,18 @@ func messageFromTxes(header *arbostypes.L1IncomingMessageHeader, txes types.Tran
 }
 
 // The caller must hold the createBlocksMutex
-func (s *ExecutionEngine) resequenceReorgedMessages(messages []*arbostypes.MessageWithMetadata) {
+// reorgedMessages are the messages removed by a reorg, along with the headers of the blocks they were built on
+type reorgedMessages struct {
+	messages []*arbostypes.MessageWithMetadata
+	parents  []*types.Header
+}
+
+func (s *ExecutionEngine) resequenceReorgedMessages(reorged reorgedMessages) {
 	if !s.reorgSequencing {
 		return
 	}
 
+	messages := reorged.messages
 	log.Info("Trying to resequence messages", "number", len(messages))
 	lastBlockHeader, err := s.getCurrentHeader()
 	if err != nil {
@@ -199,7 +236,7 @@ func (s *ExecutionEngine) resequenceReorgedMessages(messages []*arbostypes.Messa
 
 	nextDelayedSeqNum := lastBlockHeader.Nonce.Uint64()
 
-	for _, msg := range messages {
+	for i, msg := range messages {
 		// Check if the message is non-nil just to be safe
 		if msg == nil || msg.Message == nil || msg.Message.Header == nil {
 			continue
@@ -223,8 +260,13 @@ func (s *ExecutionEngine) resequenceReorgedMessages(messages []*arbostypes.Messa
 			log.Warn("skipping non-standard sequencer message found from reorg", "header", header)
 			continue
 		}
-		// We don't need a batch fetcher as this is an L2 message
-		txes, err := arbos.ParseL2Transactions(msg.Message, s.bc.Config().ChainID, nil)
+		arbosVersion, addresses, functions, err := s.messageParsingState(reorged.parents[i])
+		if err != nil {
+			log.Warn("failed to open the state of sequencer message found from reorg", "err", err)
+			continue
+		}
+		// We don't need a batch fetcher or the deposit decimals as this is an L2 message
+		txes, err := arbos.ParseL2Transactions(msg.Message, s.bc.Config().ChainID, arbosVersion, arbostypes.EthDecimals, addresses, functions, nil)
 		if err != nil {
 			log.Warn("failed to parse sequencer message found from reorg", "err", err)
 			continue
@@ -239,6 +281,23 @@ func (s *ExecutionEngine) resequenceReorgedMessages(messages []*arbostypes.Messa
 	}
 }
 
+// messageParsingState gets the ArbOS version, and the address and function tables, that a message built on the given block is parsed with
+func (s *ExecutionEngine) messageParsingState(parent *types.Header) (uint64, *addressTable.AddressTable, *functiontable.FunctionTable, error) {
+	if parent == nil {
+		return 0, nil, nil, errors.New("parent block not found")
+	}
+	statedb, err := s.bc.StateAt(parent.Root)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	addresses, err := arbos.MessageAddressTable(statedb)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	functions, err := arbos.MessageFunctionTable(statedb)
+	return arbosState.ArbOSVersion(statedb), addresses, functions, err
+}
+
 func (s *ExecutionEngine) sequencerWrapper(sequencerFunc func() (*types.Block, error)) (*types.Block, error) {
 	attempts := 0
 	for {
@@ -288,6 +347,25 @@ func (s *ExecutionEngine) sequenceTransactionsWithBlockMutex(header *arbostypes.
 
 	delayedMessagesRead := lastBlockHeader.Nonce.Uint64()
 
+	var addresses *arbos.AddressCompressor
+	if s.compressAddresses {
+		// The compressor must see the table as of the parent block, not as the block being produced changes it
+		addresses, err = arbos.NewAddressCompressor(statedb.Copy())
+		if err != nil {
+			return nil, err
+		}
+	}
+	var selectors *arbos.SelectorCompressor
+	if s.compressSelectors {
+		// Likewise, the selector compressor sees the tables as of the start of the block
+		nextHeaderNumber := arbmath.BigAdd(lastBlockHeader.Number, common.Big1)
+		signer := types.MakeSigner(s.bc.Config(), nextHeaderNumber, lastBlockHeader.Time)
+		selectors, err = arbos.NewSelectorCompressor(statedb.Copy(), signer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	startTime := time.Now()
 	block, receipts, err := arbos.ProduceBlockAdvanced(
 		header,
@@ -322,7 +400,7 @@ func (s *ExecutionEngine) sequenceTransactionsWithBlockMutex(header *arbostypes.
 		return nil, nil
 	}
 
-	msg, err := messageFromTxes(header, txes, hooks.TxErrors)
+	msg, err := messageFromTxes(header, txes, hooks.TxErrors, addresses, selectors)
 	if err != nil {
 		return nil, err
 	}
@@ -50,6 +50,8 @@ type Config struct {
 	RPC                       arbitrum.Config                  `koanf:"rpc"`
 	TxLookupLimit             uint64                           `koanf:"tx-lookup-limit"`
 	Dangerous                 DangerousConfig                  `koanf:"dangerous"`
+	StatePruner               StatePrunerConfig                `koanf:"state-pruner"`
+	Simulate                  SimulateConfig                   `koanf:"simulate" reload:"hot"`
 
 	forwardingTarget string
 }
@@ -69,6 +71,16 @@ func (c *Config) Validate() error {
 	if c.forwardingTarget != "" && c.Sequencer.Enable {
 		return errors.New("ForwardingTarget set and sequencer enabled")
 	}
+	if c.StatePruner.Enable && c.Caching.Archive {
+		return errors.New("live state pruning cannot be enabled in archive mode")
+	}
+	if c.StatePruner.Enable && c.Caching.TrieCleanCache != 0 {
+		// pruned nodes can't be evicted from the clean cache, so it would keep serving state that's been deleted
+		return errors.New("live state pruning requires the trie clean cache to be disabled (--execution.caching.trie-clean-cache=0)")
+	}
+	if err := c.StatePruner.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -84,6 +96,8 @@ func ConfigAddOptions(prefix string, f *flag.FlagSet) {
 	CachingConfigAddOptions(prefix+".caching", f)
 	f.Uint64(prefix+".tx-lookup-limit", ConfigDefault.TxLookupLimit, "retain the ability to lookup transactions by hash for the past N blocks (0 = all blocks)")
 	DangerousConfigAddOptions(prefix+".dangerous", f)
+	StatePrunerConfigAddOptions(prefix+".state-pruner", f)
+	SimulateConfigAddOptions(prefix+".simulate", f)
 }
 
 var ConfigDefault = Config{
@@ -98,6 +112,8 @@ var ConfigDefault = Config{
 	Caching:                   DefaultCachingConfig,
 	Dangerous:                 DefaultDangerousConfig,
 	Forwarder:                 DefaultNodeForwarderConfig,
+	StatePruner:               DefaultStatePrunerConfig,
+	Simulate:                  DefaultSimulateConfig,
 }
 
 func ConfigDefaultNonSequencerTest() *Config {
@@ -136,6 +152,7 @@ type ExecutionNode struct {
 	TxPublisher       TransactionPublisher
 	ConfigFetcher     ConfigFetcher
 	ParentChainReader *headerreader.HeaderReader
+	StatePruner       *StatePruner // nil if live state pruning is disabled
 	started           atomic.Bool
 }
 
@@ -166,6 +183,12 @@ func CreateExecutionNode(
 	}
 
 	if config.Sequencer.Enable {
+		if config.Sequencer.CompressAddresses {
+			execEngine.EnableAddressCompression()
+		}
+		if config.Sequencer.CompressSelectors {
+			execEngine.EnableSelectorCompression()
+		}
 		seqConfigFetcher := func() *SequencerConfig { return &configFetcher().Sequencer }
 		sequencer, err = NewSequencer(execEngine, parentChainReader, seqConfigFetcher)
 		if err != nil {
@@ -205,6 +228,17 @@ func CreateExecutionNode(
 		Service:   NewArbAPI(txPublisher),
 		Public:    false,
 	}}
+	apis = append(apis, rpc.API{
+		Namespace: "arb",
+		Version:   "1.0",
+		Service: NewArbSimulateAPI(
+			backend.APIBackend(),
+			l2BlockChain,
+			config.RPC.RPCGasCap,
+			func() *SimulateConfig { return &configFetcher().Simulate },
+		),
+		Public: false,
+	})
 	apis = append(apis, rpc.API{
 		Namespace: "arbdebug",
 		Version:   "1.0",
@@ -232,6 +266,11 @@ func CreateExecutionNode(
 
 	stack.RegisterAPIs(apis)
 
+	var statePruner *StatePruner
+	if config.StatePruner.Enable {
+		statePruner = NewStatePruner(l2BlockChain, chainDB, execEngine, func() *StatePrunerConfig { return &configFetcher().StatePruner })
+	}
+
 	return &ExecutionNode{
 		ChainDB:           chainDB,
 		Backend:           backend,
@@ -243,6 +282,7 @@ func CreateExecutionNode(
 		TxPublisher:       txPublisher,
 		ConfigFetcher:     configFetcher,
 		ParentChainReader: parentChainReader,
+		StatePruner:       statePruner,
 	}, nil
 
 }
@@ -261,6 +301,9 @@ func (n *ExecutionNode) Initialize(ctx context.Context, arbnode interface{}, syn
 	if err != nil {
 		return fmt.Errorf("error setting sync backend: %w", err)
 	}
+	if fetcher, ok := sync.(safeBlockFetcher); ok && n.StatePruner != nil {
+		n.StatePruner.SetSafeBlockFetcher(fetcher)
+	}
 	return nil
 }
 
@@ -282,6 +325,9 @@ func (n *ExecutionNode) Start(ctx context.Context) error {
 	if n.ParentChainReader != nil {
 		n.ParentChainReader.Start(ctx)
 	}
+	if n.StatePruner != nil {
+		n.StatePruner.Start(ctx)
+	}
 	return nil
 }
 
@@ -295,6 +341,9 @@ func (n *ExecutionNode) StopAndWait() {
 		n.TxPublisher.StopAndWait()
 	}
 	n.Recorder.OrderlyShutdown()
+	if n.StatePruner != nil && n.StatePruner.Started() {
+		n.StatePruner.StopAndWait()
+	}
 	if n.ParentChainReader != nil && n.ParentChainReader.Started() {
 		n.ParentChainReader.StopAndWait()
 	}
@@ -342,6 +391,9 @@ func (n *ExecutionNode) RecordBlockCreation(
 }
 func (n *ExecutionNode) MarkValid(pos arbutil.MessageIndex, resultHash common.Hash) {
 	n.Recorder.MarkValid(pos, resultHash)
+	if n.StatePruner != nil {
+		n.StatePruner.UpdateLatestValidated(n.ExecEngine.MessageIndexToBlockNumber(pos))
+	}
 }
 func (n *ExecutionNode) PrepareForRecord(ctx context.Context, start, end arbutil.MessageIndex) error {
 	return n.Recorder.PrepareForRecord(ctx, start, end)
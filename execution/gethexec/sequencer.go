@@ -66,6 +66,8 @@ type SequencerConfig struct {
 	MaxTxDataSize               int             `koanf:"max-tx-data-size" reload:"hot"`
 	NonceFailureCacheSize       int             `koanf:"nonce-failure-cache-size" reload:"hot"`
 	NonceFailureCacheExpiry     time.Duration   `koanf:"nonce-failure-cache-expiry" reload:"hot"`
+	CompressAddresses           bool            `koanf:"compress-addresses"`
+	CompressSelectors           bool            `koanf:"compress-selectors"`
 }
 
 func (c *SequencerConfig) Validate() error {
@@ -97,6 +99,8 @@ var DefaultSequencerConfig = SequencerConfig{
 	MaxTxDataSize:           95000,
 	NonceFailureCacheSize:   1024,
 	NonceFailureCacheExpiry: time.Second,
+	CompressAddresses:       false,
+	CompressSelectors:       false,
 }
 
 var TestSequencerConfig = SequencerConfig{
@@ -112,6 +116,8 @@ var TestSequencerConfig = SequencerConfig{
 	MaxTxDataSize:               95000,
 	NonceFailureCacheSize:       1024,
 	NonceFailureCacheExpiry:     time.Second,
+	CompressAddresses:           false,
+	CompressSelectors:           false,
 }
 
 func SequencerConfigAddOptions(prefix string, f *flag.FlagSet) {
@@ -127,6 +133,8 @@ func SequencerConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Int(prefix+".max-tx-data-size", DefaultSequencerConfig.MaxTxDataSize, "maximum transaction size the sequencer will accept")
 	f.Int(prefix+".nonce-failure-cache-size", DefaultSequencerConfig.NonceFailureCacheSize, "number of transactions with too high of a nonce to keep in memory while waiting for their predecessor")
 	f.Duration(prefix+".nonce-failure-cache-expiry", DefaultSequencerConfig.NonceFailureCacheExpiry, "maximum amount of time to wait for a predecessor before rejecting a tx with nonce too high")
+	f.Bool(prefix+".compress-addresses", DefaultSequencerConfig.CompressAddresses, "replace transaction recipients that are in the address table with their index (requires ArbOS 21)")
+	f.Bool(prefix+".compress-selectors", DefaultSequencerConfig.CompressSelectors, "replace the start of transactions' calldata with its index in the global function table or the sender's table (requires ArbOS 21)")
 }
 
 type txQueueItem struct {
@@ -0,0 +1,572 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/arbitrum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbos/l1pricing"
+	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+type SimulateConfig struct {
+	MaxBlocks uint64 `koanf:"max-blocks"`
+	MaxCalls  uint64 `koanf:"max-calls"`
+}
+
+var DefaultSimulateConfig = SimulateConfig{
+	MaxBlocks: 16,
+	MaxCalls:  256,
+}
+
+func SimulateConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Uint64(prefix+".max-blocks", DefaultSimulateConfig.MaxBlocks, "maximum number of blocks a single arb_simulate request may simulate")
+	f.Uint64(prefix+".max-calls", DefaultSimulateConfig.MaxCalls, "maximum number of calls across all blocks of a single arb_simulate request")
+}
+
+// SimulateOverrides replace the L1 context and ArbOS pricing parameters before a simulated block is produced.
+// Unset fields keep the values carried over from the parent block.
+type SimulateOverrides struct {
+	Timestamp         *hexutil.Uint64 `json:"timestamp"`
+	L1BlockNumber     *hexutil.Uint64 `json:"l1BlockNumber"`
+	L1BaseFee         *hexutil.Big    `json:"l1BaseFee"`
+	L1BaseFeeEstimate *hexutil.Big    `json:"l1BaseFeeEstimate"`
+	L1PerBatchGasCost *int64          `json:"l1PerBatchGasCost"`
+	L1AmortizedCapBip *hexutil.Uint64 `json:"l1AmortizedCostCapBips"`
+	L2BaseFee         *hexutil.Big    `json:"l2BaseFee"`
+	L2MinBaseFee      *hexutil.Big    `json:"l2MinBaseFee"`
+	L2SpeedLimit      *hexutil.Uint64 `json:"l2SpeedLimit"`
+	L2PerBlockGas     *hexutil.Uint64 `json:"l2PerBlockGasLimit"`
+	L2GasBacklog      *hexutil.Uint64 `json:"l2GasBacklog"`
+}
+
+// SimulateRetryable turns a call into a retryable ticket submitted from L1 by the call's sender.
+type SimulateRetryable struct {
+	Deposit          *hexutil.Big    `json:"deposit"`
+	MaxSubmissionFee *hexutil.Big    `json:"maxSubmissionFee"`
+	FeeRefundAddress *common.Address `json:"excessFeeRefundAddress"`
+	Beneficiary      *common.Address `json:"callValueRefundAddress"`
+}
+
+type SimulateCall struct {
+	From      common.Address     `json:"from"`
+	To        *common.Address    `json:"to"`
+	Gas       *hexutil.Uint64    `json:"gas"`
+	GasFeeCap *hexutil.Big       `json:"maxFeePerGas"`
+	Value     *hexutil.Big       `json:"value"`
+	Data      hexutil.Bytes      `json:"data"`
+	Retryable *SimulateRetryable `json:"retryable"`
+}
+
+type SimulateBlock struct {
+	Overrides SimulateOverrides `json:"overrides"`
+	Calls     []SimulateCall    `json:"calls"`
+}
+
+type SimulateRedeemResult struct {
+	TxHash  common.Hash    `json:"txHash"`
+	Status  hexutil.Uint64 `json:"status"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Logs    []*types.Log   `json:"logs"`
+}
+
+type SimulateRetryableResult struct {
+	TicketId common.Hash            `json:"ticketId"`
+	Created  bool                   `json:"created"`
+	Redeemed bool                   `json:"redeemed"`
+	Redeems  []SimulateRedeemResult `json:"redeems"`
+}
+
+type SimulateCallResult struct {
+	TxHash       common.Hash              `json:"txHash"`
+	Status       hexutil.Uint64           `json:"status"`
+	ReturnData   hexutil.Bytes            `json:"returnData"`
+	Error        string                   `json:"error,omitempty"`
+	GasUsed      hexutil.Uint64           `json:"gasUsed"`
+	GasUsedForL1 hexutil.Uint64           `json:"gasUsedForL1"`
+	GasUsedForL2 hexutil.Uint64           `json:"gasUsedForL2"`
+	Logs         []*types.Log             `json:"logs"`
+	Retryable    *SimulateRetryableResult `json:"retryable,omitempty"`
+}
+
+type SimulateBlockResult struct {
+	Number            hexutil.Uint64       `json:"number"`
+	Hash              common.Hash          `json:"hash"`
+	Timestamp         hexutil.Uint64       `json:"timestamp"`
+	BaseFee           *hexutil.Big         `json:"baseFee"`
+	L1BlockNumber     hexutil.Uint64       `json:"l1BlockNumber"`
+	L1BaseFeeEstimate *hexutil.Big         `json:"l1BaseFeeEstimate"`
+	GasUsed           hexutil.Uint64       `json:"gasUsed"`
+	Calls             []SimulateCallResult `json:"calls"`
+}
+
+// ArbSimulateAPI executes calls across simulated blocks on top of an existing state without persisting anything.
+type ArbSimulateAPI struct {
+	backend    *arbitrum.APIBackend
+	blockchain *core.BlockChain
+	gasCap     uint64
+	config     func() *SimulateConfig
+}
+
+func NewArbSimulateAPI(backend *arbitrum.APIBackend, blockchain *core.BlockChain, gasCap uint64, config func() *SimulateConfig) *ArbSimulateAPI {
+	return &ArbSimulateAPI{backend, blockchain, gasCap, config}
+}
+
+// Simulate produces each of the given blocks in turn on top of the state at blockNrOrHash,
+// running the ArbOS block processor so that L1 pricing, retryable submission and auto-redeems
+// behave as they would on chain. Calls from the same sender are given consecutive nonces.
+func (api *ArbSimulateAPI) Simulate(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, blocks []SimulateBlock) ([]SimulateBlockResult, error) {
+	config := api.config()
+	if len(blocks) == 0 {
+		return nil, errors.New("no blocks to simulate")
+	}
+	if uint64(len(blocks)) > config.MaxBlocks {
+		return nil, fmt.Errorf("too many blocks to simulate: %v (max %v)", len(blocks), config.MaxBlocks)
+	}
+	totalCalls := uint64(0)
+	for _, block := range blocks {
+		totalCalls += uint64(len(block.Calls))
+	}
+	if totalCalls > config.MaxCalls {
+		return nil, fmt.Errorf("too many calls to simulate: %v (max %v)", totalCalls, config.MaxCalls)
+	}
+
+	statedb, header, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if statedb == nil || header == nil {
+		return nil, errors.New("state not found")
+	}
+	chainConfig := api.blockchain.Config()
+	if !chainConfig.IsArbitrumNitro(header.Number) {
+		return nil, types.ErrUseFallback
+	}
+	// Simulated blocks are committed into their own database, so nothing reaches the chain's trie database or disk
+	simulateDb, err := newSimulateDatabase(statedb.Database().TrieDB(), header.Root)
+	if err != nil {
+		return nil, err
+	}
+	statedb, err = state.New(header.Root, simulateDb, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]SimulateBlockResult, 0, len(blocks))
+	for blockIndex, simBlock := range blocks {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		result, block, err := api.simulateBlock(statedb, header, blockIndex, &simBlock)
+		if err != nil {
+			return nil, fmt.Errorf("simulating block %v: %w", blockIndex, err)
+		}
+		results = append(results, *result)
+		header = block.Header()
+		if blockIndex+1 < len(blocks) {
+			// The block processor requires a StateDB without pending balance changes, so reopen it at the new root
+			if _, err := statedb.Commit(header.Number.Uint64(), true); err != nil {
+				return nil, err
+			}
+			statedb, err = state.New(header.Root, simulateDb, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return results, nil
+}
+
+func (api *ArbSimulateAPI) simulateBlock(statedb *state.StateDB, parent *types.Header, blockIndex int, simBlock *SimulateBlock) (*SimulateBlockResult, *types.Block, error) {
+	arbState, err := arbosState.OpenSystemArbosState(statedb, nil, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	l1Header, err := applySimulateOverrides(arbState, parent, &simBlock.Overrides)
+	if err != nil {
+		return nil, nil, err
+	}
+	baseFee, err := arbState.L2PricingState().BaseFeeWei()
+	if err != nil {
+		return nil, nil, err
+	}
+	blockGasLimit, err := arbState.L2PricingState().PerBlockGasLimit()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chainId := api.blockchain.Config().ChainID
+	nonces := make(map[common.Address]uint64)
+	txes := make(types.Transactions, 0, len(simBlock.Calls))
+	for callIndex, call := range simBlock.Calls {
+		gas := api.gasCap
+		if gas == 0 {
+			gas = blockGasLimit
+		}
+		if call.Gas != nil && uint64(*call.Gas) < gas {
+			gas = uint64(*call.Gas)
+		}
+		// Like eth_call, a call without a fee cap doesn't pay for its gas, so its sender needn't be funded.
+		// Retryables are always charged, as their submission is paid for on L1.
+		gasFeeCap := new(big.Int)
+		if call.GasFeeCap != nil {
+			gasFeeCap = call.GasFeeCap.ToInt()
+		} else if call.Retryable != nil {
+			gasFeeCap = baseFee
+		}
+		value := new(big.Int)
+		if call.Value != nil {
+			value = call.Value.ToInt()
+		}
+		if call.Retryable != nil {
+			txes = append(txes, simulatedRetryableTx(chainId, l1Header, blockIndex, callIndex, &call, gas, gasFeeCap, value))
+			continue
+		}
+		nonce, seen := nonces[call.From]
+		if !seen {
+			nonce = statedb.GetNonce(call.From)
+		}
+		nonces[call.From] = nonce + 1
+		txes = append(txes, types.NewTx(&types.ArbitrumUnsignedTx{
+			ChainId:   chainId,
+			From:      call.From,
+			Nonce:     nonce,
+			GasFeeCap: gasFeeCap,
+			Gas:       gas,
+			To:        call.To,
+			Value:     value,
+			Data:      call.Data,
+		}))
+	}
+
+	executionResults := make(map[common.Hash]*core.ExecutionResult)
+	hooks := arbos.NoopSequencingHooks()
+	hooks.NoBaseFee = true
+	hooks.PostTxFilter = func(_ *types.Header, _ *arbosState.ArbosState, tx *types.Transaction, _ common.Address, _ uint64, result *core.ExecutionResult) error {
+		executionResults[tx.Hash()] = result
+		return nil
+	}
+	block, receipts, err := arbos.ProduceBlockAdvanced(l1Header, txes, 0, parent, statedb, api.blockchain, api.blockchain.Config(), hooks)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	receiptsByHash := make(map[common.Hash]*types.Receipt, len(receipts))
+	redeemsByTicket := make(map[common.Hash][]SimulateRedeemResult)
+	for i, tx := range block.Transactions() {
+		receipt := receipts[i]
+		receiptsByHash[tx.Hash()] = receipt
+		if retry, ok := tx.GetInner().(*types.ArbitrumRetryTx); ok {
+			redeemsByTicket[retry.TicketId] = append(redeemsByTicket[retry.TicketId], SimulateRedeemResult{
+				TxHash:  tx.Hash(),
+				Status:  hexutil.Uint64(receipt.Status),
+				GasUsed: hexutil.Uint64(receipt.GasUsed),
+				Logs:    receipt.Logs,
+			})
+		}
+	}
+
+	arbState, err = arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	l1BaseFeeEstimate, err := arbState.L1PricingState().PricePerUnit()
+	if err != nil {
+		return nil, nil, err
+	}
+	result := &SimulateBlockResult{
+		Number:            hexutil.Uint64(block.NumberU64()),
+		Hash:              block.Hash(),
+		Timestamp:         hexutil.Uint64(block.Time()),
+		BaseFee:           (*hexutil.Big)(block.BaseFee()),
+		L1BlockNumber:     hexutil.Uint64(l1Header.BlockNumber),
+		L1BaseFeeEstimate: (*hexutil.Big)(l1BaseFeeEstimate),
+		GasUsed:           hexutil.Uint64(block.GasUsed()),
+		Calls:             make([]SimulateCallResult, len(txes)),
+	}
+	for i, tx := range txes {
+		callResult := &result.Calls[i]
+		callResult.TxHash = tx.Hash()
+		if i < len(hooks.TxErrors) && hooks.TxErrors[i] != nil {
+			callResult.Error = hooks.TxErrors[i].Error()
+			continue
+		}
+		receipt := receiptsByHash[tx.Hash()]
+		if receipt == nil {
+			callResult.Error = "transaction wasn't included in the simulated block"
+			continue
+		}
+		callResult.Status = hexutil.Uint64(receipt.Status)
+		callResult.GasUsed = hexutil.Uint64(receipt.GasUsed)
+		callResult.GasUsedForL1 = hexutil.Uint64(receipt.GasUsedForL1)
+		callResult.GasUsedForL2 = hexutil.Uint64(arbmath.SaturatingUSub(receipt.GasUsed, receipt.GasUsedForL1))
+		callResult.Logs = receipt.Logs
+		if executionResult := executionResults[tx.Hash()]; executionResult != nil {
+			callResult.ReturnData = executionResult.ReturnData
+			if executionResult.Err != nil {
+				callResult.Error = executionResult.Err.Error()
+			}
+		}
+		if tx.Type() == types.ArbitrumSubmitRetryableTxType {
+			ticketId := tx.Hash()
+			redeems := redeemsByTicket[ticketId]
+			retryable, err := arbState.RetryableState().OpenRetryable(ticketId, block.Time())
+			if err != nil {
+				return nil, nil, err
+			}
+			redeemed := false
+			for _, redeem := range redeems {
+				redeemed = redeemed || redeem.Status == hexutil.Uint64(types.ReceiptStatusSuccessful)
+			}
+			callResult.Retryable = &SimulateRetryableResult{
+				TicketId: ticketId,
+				Created:  receipt.Status == types.ReceiptStatusSuccessful,
+				Redeemed: redeemed || (receipt.Status == types.ReceiptStatusSuccessful && retryable == nil),
+				Redeems:  redeems,
+			}
+		}
+	}
+	return result, block, nil
+}
+
+// applySimulateOverrides writes the ArbOS pricing overrides into the state and returns the L1 header the block is produced with.
+func applySimulateOverrides(arbState *arbosState.ArbosState, parent *types.Header, overrides *SimulateOverrides) (*arbostypes.L1IncomingMessageHeader, error) {
+	l1BlockNumber, err := arbState.Blockhashes().L1BlockNumber()
+	if err != nil {
+		return nil, err
+	}
+	l1Pricing := arbState.L1PricingState()
+	l2Pricing := arbState.L2PricingState()
+	l1BaseFee, err := l1Pricing.PricePerUnit()
+	if err != nil {
+		return nil, err
+	}
+	l1Header := &arbostypes.L1IncomingMessageHeader{
+		Kind:        arbostypes.L1MessageType_L2Message,
+		Poster:      l1pricing.BatchPosterAddress,
+		BlockNumber: l1BlockNumber,
+		Timestamp:   parent.Time,
+		RequestId:   nil,
+		L1BaseFee:   l1BaseFee,
+	}
+	if overrides.Timestamp != nil {
+		l1Header.Timestamp = uint64(*overrides.Timestamp)
+	}
+	if overrides.L1BlockNumber != nil {
+		l1Header.BlockNumber = uint64(*overrides.L1BlockNumber)
+	}
+	if overrides.L1BaseFee != nil {
+		l1Header.L1BaseFee = overrides.L1BaseFee.ToInt()
+	}
+	if overrides.L1BaseFeeEstimate != nil {
+		if err := l1Pricing.SetPricePerUnit(overrides.L1BaseFeeEstimate.ToInt()); err != nil {
+			return nil, err
+		}
+	}
+	if overrides.L1PerBatchGasCost != nil {
+		if err := l1Pricing.SetPerBatchGasCost(*overrides.L1PerBatchGasCost); err != nil {
+			return nil, err
+		}
+	}
+	if overrides.L1AmortizedCapBip != nil {
+		if err := l1Pricing.SetAmortizedCostCapBips(uint64(*overrides.L1AmortizedCapBip)); err != nil {
+			return nil, err
+		}
+	}
+	if overrides.L2MinBaseFee != nil {
+		if err := l2Pricing.SetMinBaseFeeWei(overrides.L2MinBaseFee.ToInt()); err != nil {
+			return nil, err
+		}
+	}
+	if overrides.L2BaseFee != nil {
+		if err := l2Pricing.SetBaseFeeWei(overrides.L2BaseFee.ToInt()); err != nil {
+			return nil, err
+		}
+	}
+	if overrides.L2SpeedLimit != nil {
+		if err := l2Pricing.SetSpeedLimitPerSecond(uint64(*overrides.L2SpeedLimit)); err != nil {
+			return nil, err
+		}
+	}
+	if overrides.L2PerBlockGas != nil {
+		if err := l2Pricing.SetMaxPerBlockGasLimit(uint64(*overrides.L2PerBlockGas)); err != nil {
+			return nil, err
+		}
+	}
+	if overrides.L2GasBacklog != nil {
+		if err := l2Pricing.SetGasBacklog(uint64(*overrides.L2GasBacklog)); err != nil {
+			return nil, err
+		}
+	}
+	return l1Header, nil
+}
+
+// simulatedRetryableTx builds the submission a retryable ticket created on L1 by call.From would produce.
+func simulatedRetryableTx(
+	chainId *big.Int,
+	l1Header *arbostypes.L1IncomingMessageHeader,
+	blockIndex, callIndex int,
+	call *SimulateCall,
+	gas uint64,
+	gasFeeCap *big.Int,
+	value *big.Int,
+) *types.Transaction {
+	params := call.Retryable
+	sender := util.RemapL1Address(call.From)
+	deposit := new(big.Int)
+	if params.Deposit != nil {
+		deposit = params.Deposit.ToInt()
+	}
+	maxSubmissionFee := new(big.Int)
+	if params.MaxSubmissionFee != nil {
+		maxSubmissionFee = params.MaxSubmissionFee.ToInt()
+	}
+	feeRefundAddr := sender
+	if params.FeeRefundAddress != nil {
+		feeRefundAddr = *params.FeeRefundAddress
+	}
+	beneficiary := sender
+	if params.Beneficiary != nil {
+		beneficiary = *params.Beneficiary
+	}
+	// the request id only has to be unique within the simulation
+	requestId := crypto.Keccak256Hash(
+		[]byte("arb_simulate"),
+		arbmath.UintToBytes(uint64(blockIndex)),
+		arbmath.UintToBytes(uint64(callIndex)),
+	)
+	return types.NewTx(&types.ArbitrumSubmitRetryableTx{
+		ChainId:          chainId,
+		RequestId:        requestId,
+		From:             sender,
+		L1BaseFee:        l1Header.L1BaseFee,
+		DepositValue:     deposit,
+		GasFeeCap:        gasFeeCap,
+		Gas:              gas,
+		RetryTo:          call.To,
+		RetryValue:       value,
+		Beneficiary:      beneficiary,
+		MaxSubmissionFee: maxSubmissionFee,
+		FeeRefundAddr:    feeRefundAddr,
+		RetryData:        call.Data,
+	})
+}
+
+// simulateKV keeps everything written during a simulation in memory,
+// reading through to the chain's trie database and disk for the rest.
+// Deleted keys are recorded as tombstones so they aren't read through.
+type simulateKV struct {
+	ethdb.Database
+	written    *memorydb.Database
+	trieReader trie.Reader
+
+	deletedLock sync.RWMutex
+	deleted     map[string]bool
+}
+
+var errSimulateKVNotFound = errors.New("not found")
+
+func newSimulateDatabase(triedb *trie.Database, root common.Hash) (state.Database, error) {
+	trieReader, err := triedb.Reader(root)
+	if err != nil {
+		return nil, err
+	}
+	kv := &simulateKV{
+		Database:   triedb.DiskDB(),
+		written:    memorydb.New(),
+		trieReader: trieReader,
+		deleted:    make(map[string]bool),
+	}
+	return state.NewDatabase(kv), nil
+}
+
+func (db *simulateKV) isDeleted(key []byte) bool {
+	db.deletedLock.RLock()
+	defer db.deletedLock.RUnlock()
+	return db.deleted[string(key)]
+}
+
+func (db *simulateKV) Get(key []byte) ([]byte, error) {
+	if value, err := db.written.Get(key); err == nil {
+		return value, nil
+	}
+	if db.isDeleted(key) {
+		return nil, errSimulateKVNotFound
+	}
+	if len(key) == common.HashLength {
+		// recent trie nodes may only be in the chain's trie database cache
+		if node, err := db.trieReader.Node(common.Hash{}, nil, common.BytesToHash(key)); err == nil && len(node) > 0 {
+			return node, nil
+		}
+	}
+	return db.Database.Get(key)
+}
+
+func (db *simulateKV) Has(key []byte) (bool, error) {
+	if has, err := db.written.Has(key); err != nil || has {
+		return has, err
+	}
+	if db.isDeleted(key) {
+		return false, nil
+	}
+	if len(key) == common.HashLength {
+		if node, err := db.trieReader.Node(common.Hash{}, nil, common.BytesToHash(key)); err == nil && len(node) > 0 {
+			return true, nil
+		}
+	}
+	return db.Database.Has(key)
+}
+
+func (db *simulateKV) Put(key []byte, value []byte) error {
+	db.deletedLock.Lock()
+	delete(db.deleted, string(key))
+	db.deletedLock.Unlock()
+	return db.written.Put(key, value)
+}
+
+func (db *simulateKV) Delete(key []byte) error {
+	db.deletedLock.Lock()
+	db.deleted[string(key)] = true
+	db.deletedLock.Unlock()
+	return db.written.Delete(key)
+}
+
+func (db *simulateKV) NewBatch() ethdb.Batch {
+	return &simulateBatch{Batch: db.written.NewBatch(), db: db}
+}
+
+func (db *simulateKV) NewBatchWithSize(size int) ethdb.Batch {
+	return &simulateBatch{Batch: db.written.NewBatchWithSize(size), db: db}
+}
+
+// simulateBatch collects writes in a memory batch, and applies them through its simulateKV so deletions leave tombstones.
+type simulateBatch struct {
+	ethdb.Batch
+	db *simulateKV
+}
+
+func (b *simulateBatch) Write() error {
+	return b.Batch.Replay(b.db)
+}
@@ -0,0 +1,569 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/state/pruner"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+	"github.com/offchainlabs/nitro/validator"
+)
+
+var (
+	statePrunerCyclesCounter   = metrics.NewRegisteredCounter("arb/pruning/live/cycles", nil)
+	statePrunerMarkedCounter   = metrics.NewRegisteredCounter("arb/pruning/live/marked", nil)
+	statePrunerScannedCounter  = metrics.NewRegisteredCounter("arb/pruning/live/scanned", nil)
+	statePrunerDeletedCounter  = metrics.NewRegisteredCounter("arb/pruning/live/deleted", nil)
+	statePrunerDeletedBytes    = metrics.NewRegisteredCounter("arb/pruning/live/deleted_bytes", nil)
+	statePrunerProgressGauge   = metrics.NewRegisteredGauge("arb/pruning/live/progress", nil)
+	statePrunerRetainedGauge   = metrics.NewRegisteredGauge("arb/pruning/live/retained_roots", nil)
+	statePrunerLastDurationSec = metrics.NewRegisteredGauge("arb/pruning/live/last_duration_seconds", nil)
+	statePrunerVerifyFailures  = metrics.NewRegisteredCounter("arb/pruning/live/verify_failures", nil)
+)
+
+type StatePrunerConfig struct {
+	Enable bool `koanf:"enable"`
+	// How often a full mark and sweep cycle is started.
+	Interval     time.Duration `koanf:"interval" reload:"hot"`
+	RetainBlocks uint64        `koanf:"retain-blocks" reload:"hot"`
+	// Bounds on the disk I/O done by the sweep phase.
+	BatchSize  int           `koanf:"batch-size" reload:"hot"`
+	BatchDelay time.Duration `koanf:"batch-delay" reload:"hot"`
+	BloomSize  uint64        `koanf:"bloom-size"`
+	Verify     bool          `koanf:"verify" reload:"hot"`
+}
+
+type StatePrunerConfigFetcher func() *StatePrunerConfig
+
+var DefaultStatePrunerConfig = StatePrunerConfig{
+	Enable:       false,
+	Interval:     24 * time.Hour,
+	RetainBlocks: 128,
+	BatchSize:    10_000,
+	BatchDelay:   100 * time.Millisecond,
+	BloomSize:    256,
+	Verify:       false,
+}
+
+func StatePrunerConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultStatePrunerConfig.Enable, "enable live pruning of old state while the node is running (requires --execution.caching.trie-clean-cache=0, and isn't supported in archive mode)")
+	f.Duration(prefix+".interval", DefaultStatePrunerConfig.Interval, "interval between the start of live pruning cycles")
+	f.Uint64(prefix+".retain-blocks", DefaultStatePrunerConfig.RetainBlocks, "number of most recent blocks whose state is retained by live pruning (in addition to the latest confirmed, validated and safe blocks)")
+	f.Int(prefix+".batch-size", DefaultStatePrunerConfig.BatchSize, "maximum number of trie nodes deleted per batch during live pruning")
+	f.Duration(prefix+".batch-delay", DefaultStatePrunerConfig.BatchDelay, "delay between live pruning delete batches, to bound disk I/O")
+	f.Uint64(prefix+".bloom-size", DefaultStatePrunerConfig.BloomSize, "the amount of memory in megabytes to use for the live pruning bloom filter, allocated for the duration of each cycle (higher values prune better)")
+	f.Bool(prefix+".verify", DefaultStatePrunerConfig.Verify, "after each live pruning cycle, walk the retained state again from disk to check it is complete (expensive)")
+}
+
+func (c *StatePrunerConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.BatchSize <= 0 {
+		return errors.New("state pruner batch size must be positive")
+	}
+	if c.BloomSize == 0 {
+		return errors.New("state pruner bloom size must be positive")
+	}
+	return nil
+}
+
+type safeBlockFetcher interface {
+	SafeBlockNumber(ctx context.Context) (uint64, error)
+}
+
+// StatePruner garbage collects trie nodes that are no longer reachable from any
+// retained state root. Retained roots are the last RetainBlocks blocks with state
+// on disk, plus the latest confirmed, validated and safe blocks.
+type StatePruner struct {
+	stopwaiter.StopWaiter
+	bc         *core.BlockChain
+	db         ethdb.Database
+	execEngine *ExecutionEngine
+	config     StatePrunerConfigFetcher
+
+	importantLock  sync.Mutex
+	confirmedBlock common.Hash
+	validatedBlock uint64
+	safeFetcher    safeBlockFetcher
+
+	lastCycleStart time.Time
+}
+
+func NewStatePruner(bc *core.BlockChain, db ethdb.Database, execEngine *ExecutionEngine, config StatePrunerConfigFetcher) *StatePruner {
+	return &StatePruner{
+		bc:         bc,
+		db:         db,
+		execEngine: execEngine,
+		config:     config,
+	}
+}
+
+func (p *StatePruner) SetSafeBlockFetcher(fetcher safeBlockFetcher) {
+	p.importantLock.Lock()
+	defer p.importantLock.Unlock()
+	p.safeFetcher = fetcher
+}
+
+// UpdateLatestConfirmed implements staker.LatestConfirmedNotifier
+func (p *StatePruner) UpdateLatestConfirmed(count arbutil.MessageIndex, globalState validator.GoGlobalState) {
+	p.importantLock.Lock()
+	defer p.importantLock.Unlock()
+	p.confirmedBlock = globalState.BlockHash
+}
+
+func (p *StatePruner) UpdateLatestValidated(blockNum uint64) {
+	p.importantLock.Lock()
+	defer p.importantLock.Unlock()
+	if blockNum > p.validatedBlock {
+		p.validatedBlock = blockNum
+	}
+}
+
+func (p *StatePruner) Start(ctxIn context.Context) {
+	p.StopWaiter.Start(ctxIn, p)
+	p.CallIteratively(p.pruneIteration)
+}
+
+func (p *StatePruner) pruneIteration(ctx context.Context) time.Duration {
+	interval := p.config().Interval
+	if wait := time.Until(p.lastCycleStart.Add(interval)); wait > 0 {
+		return wait
+	}
+	p.lastCycleStart = time.Now()
+	err := p.Prune(ctx)
+	if err != nil && ctx.Err() == nil {
+		log.Error("live state pruning failed", "err", err)
+	}
+	statePrunerLastDurationSec.Update(int64(time.Since(p.lastCycleStart).Seconds()))
+	return interval
+}
+
+// Walks back from the given header to the first block whose state is on disk.
+// Returns nil if none could be found.
+func (p *StatePruner) headerWithState(header *types.Header) (*types.Header, error) {
+	for header != nil && header.Root != (common.Hash{}) {
+		exists, err := p.db.Has(header.Root.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return header, nil
+		}
+		num := header.Number.Uint64()
+		if num == 0 {
+			break
+		}
+		header = rawdb.ReadHeader(p.db, header.ParentHash, num-1)
+	}
+	return nil, nil
+}
+
+// Collects the headers whose state must survive this pruning cycle, sorted by block number.
+func (p *StatePruner) retainedHeaders(ctx context.Context) ([]*types.Header, error) {
+	head := p.bc.CurrentBlock()
+	if head == nil {
+		return nil, errors.New("no current block")
+	}
+	headNum := head.Number.Uint64()
+	retain := p.config().RetainBlocks
+	var candidates []*types.Header
+	// The latest state on disk is always kept, as in-memory tries build on it.
+	candidates = append(candidates, head)
+	var firstRetained uint64
+	if headNum > retain {
+		firstRetained = headNum - retain
+	}
+	for num := firstRetained; num < headNum; num++ {
+		if header := p.bc.GetHeaderByNumber(num); header != nil {
+			candidates = append(candidates, header)
+		}
+	}
+	if genesis := p.bc.GetHeaderByNumber(p.bc.Config().ArbitrumChainParams.GenesisBlockNum); genesis != nil {
+		candidates = append(candidates, genesis)
+	}
+
+	p.importantLock.Lock()
+	confirmedBlock := p.confirmedBlock
+	validatedBlock := p.validatedBlock
+	safeFetcher := p.safeFetcher
+	p.importantLock.Unlock()
+
+	if confirmedBlock != (common.Hash{}) {
+		if header := p.bc.GetHeaderByHash(confirmedBlock); header != nil {
+			candidates = append(candidates, header)
+		} else {
+			log.Warn("latest confirmed block not found for live pruning", "hash", confirmedBlock)
+		}
+	}
+	if validatedBlock > 0 {
+		if header := p.bc.GetHeaderByNumber(validatedBlock); header != nil {
+			candidates = append(candidates, header)
+		}
+	}
+	if safeFetcher != nil {
+		safeBlock, err := safeFetcher.SafeBlockNumber(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get safe block: %w", err)
+		}
+		if header := p.bc.GetHeaderByNumber(safeBlock); header != nil {
+			candidates = append(candidates, header)
+		}
+	}
+
+	seen := make(map[common.Hash]bool)
+	var retained []*types.Header
+	for _, candidate := range candidates {
+		header, err := p.headerWithState(candidate)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			if candidate == head {
+				return nil, errors.New("no state found on disk for the current head")
+			}
+			continue
+		}
+		if seen[header.Root] {
+			continue
+		}
+		seen[header.Root] = true
+		retained = append(retained, header)
+	}
+	sort.Slice(retained, func(i, j int) bool {
+		return retained[i].Number.Uint64() < retained[j].Number.Uint64()
+	})
+	return retained, nil
+}
+
+// Prune runs a single mark and sweep cycle.
+func (p *StatePruner) Prune(ctx context.Context) error {
+	start := time.Now()
+	config := p.config()
+	statePrunerCyclesCounter.Inc(1)
+	statePrunerProgressGauge.Update(0)
+
+	bloom, err := pruner.NewStateBloomWithSize(config.BloomSize)
+	if err != nil {
+		return err
+	}
+	marker := &stateMarker{
+		triedb:      p.bc.StateCache().TrieDB(),
+		bloom:       bloom,
+		markedRoots: make(map[common.Hash]bool),
+	}
+	defer func() { statePrunerMarkedCounter.Inc(marker.marked) }()
+
+	// Mark phase: everything reachable from the retained roots. Consecutive
+	// roots are mostly identical, so only the difference to the previously
+	// marked root is walked.
+	retained, err := p.retainedHeaders(ctx)
+	if err != nil {
+		return err
+	}
+	statePrunerRetainedGauge.Update(int64(len(retained)))
+	log.Info("live state pruning started", "retainedRoots", len(retained), "oldest", retained[0].Number, "newest", retained[len(retained)-1].Number)
+	var lastRoot common.Hash
+	for _, header := range retained {
+		if err := marker.markState(ctx, header.Root, lastRoot); err != nil {
+			return fmt.Errorf("failed marking state of block %v: %w", header.Number, err)
+		}
+		lastRoot = header.Root
+	}
+
+	// Sweep phase: delete unmarked trie nodes in bounded batches. Like the
+	// offline pruner, this has to look at every key in the database, but the
+	// iterator is reopened for each batch so it doesn't pin the database.
+	var scanned, deleted, deletedBytes int64
+	var next []byte
+	for {
+		var keys [][]byte
+		var sizes int64
+		keys, sizes, next, scanned, err = p.collectUnmarked(marker, next, config.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			statePrunerProgressGauge.Update(int64(keys[len(keys)-1][0]) * 100 / 256)
+			lastRoot, err = p.deleteUnmarked(ctx, marker, lastRoot, keys)
+			if err != nil {
+				return err
+			}
+		}
+		deleted += int64(len(keys))
+		deletedBytes += sizes
+		statePrunerScannedCounter.Inc(scanned)
+		statePrunerDeletedCounter.Inc(int64(len(keys)))
+		statePrunerDeletedBytes.Inc(sizes)
+		if next == nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(p.config().BatchDelay):
+		}
+	}
+	statePrunerProgressGauge.Update(100)
+	log.Info("live state pruning done", "deleted", deleted, "deletedBytes", deletedBytes, "elapsed", time.Since(start))
+	if !p.config().Verify {
+		return nil
+	}
+	return p.verifyRetained(ctx, retained)
+}
+
+// Scans the database from the given key for up to batchSize unmarked trie nodes.
+// Returns the keys found, their total size, the key to continue from (nil once
+// the whole database has been scanned), and the number of trie node keys scanned.
+func (p *StatePruner) collectUnmarked(marker *stateMarker, from []byte, batchSize int) ([][]byte, int64, []byte, int64, error) {
+	it := p.db.NewIterator(nil, from)
+	defer it.Release()
+	var keys [][]byte
+	var sizes, scanned int64
+	for it.Next() {
+		key := it.Key()
+		if len(keys) >= batchSize {
+			return keys, sizes, common.CopyBytes(key), scanned, it.Error()
+		}
+		if len(key) != common.HashLength {
+			continue
+		}
+		scanned++
+		if marker.isMarked(key) {
+			continue
+		}
+		keys = append(keys, common.CopyBytes(key))
+		sizes += int64(len(key) + len(it.Value()))
+	}
+	return keys, sizes, nil, scanned, it.Error()
+}
+
+// Deletes the keys which are still unmarked once the state committed since
+// the last marked root has been marked, so that nodes rewritten by new blocks
+// aren't deleted. Marking is done without blocking block creation; the
+// createBlocksMutex is only held to check no new block arrived before the
+// deletion is written. Returns the last marked root.
+func (p *StatePruner) deleteUnmarked(ctx context.Context, marker *stateMarker, lastRoot common.Hash, keys [][]byte) (common.Hash, error) {
+	for {
+		var head common.Hash
+		var err error
+		lastRoot, head, err = p.markNewState(ctx, marker, lastRoot)
+		if err != nil {
+			return lastRoot, err
+		}
+		p.execEngine.createBlocksMutex.Lock()
+		if current := p.bc.CurrentBlock(); current != nil && current.Hash() != head {
+			p.execEngine.createBlocksMutex.Unlock()
+			continue
+		}
+		batch := p.db.NewBatch()
+		for _, key := range keys {
+			if marker.isMarked(key) {
+				continue
+			}
+			if err = batch.Delete(key); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = batch.Write()
+		}
+		p.execEngine.createBlocksMutex.Unlock()
+		return lastRoot, err
+	}
+}
+
+// Walks the retained states again, reading every node from disk, to check the sweep left them complete.
+// The walk doesn't go through the blockchain's trie database, whose caches would hide deleted nodes.
+func (p *StatePruner) verifyRetained(ctx context.Context, retained []*types.Header) error {
+	bloom, err := pruner.NewStateBloomWithSize(p.config().BloomSize)
+	if err != nil {
+		return err
+	}
+	verifier := &stateMarker{
+		triedb:      state.NewDatabaseWithConfig(p.db, nil).TrieDB(),
+		bloom:       bloom,
+		markedRoots: make(map[common.Hash]bool),
+	}
+	var lastRoot common.Hash
+	for _, header := range retained {
+		if err := verifier.markState(ctx, header.Root, lastRoot); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			statePrunerVerifyFailures.Inc(1)
+			return fmt.Errorf("state of retained block %v is incomplete after live pruning: %w", header.Number, err)
+		}
+		lastRoot = header.Root
+	}
+	return nil
+}
+
+// Marks the state of recent blocks committed to disk since the mark phase,
+// including any blocks created after a reorg. Returns the last marked root,
+// and the hash of the head block it marked up to.
+func (p *StatePruner) markNewState(ctx context.Context, marker *stateMarker, lastRoot common.Hash) (common.Hash, common.Hash, error) {
+	head := p.bc.CurrentBlock()
+	if head == nil {
+		return lastRoot, common.Hash{}, nil
+	}
+	headNum := head.Number.Uint64()
+	var start uint64
+	if retain := p.config().RetainBlocks; headNum > retain {
+		start = headNum - retain
+	}
+	for num := start; num <= headNum; num++ {
+		header := p.bc.GetHeaderByNumber(num)
+		if header == nil || marker.markedRoots[header.Root] {
+			continue
+		}
+		exists, err := p.db.Has(header.Root.Bytes())
+		if err != nil {
+			return lastRoot, head.Hash(), err
+		}
+		if !exists {
+			continue
+		}
+		if err := marker.markState(ctx, header.Root, lastRoot); err != nil {
+			return lastRoot, head.Hash(), err
+		}
+		lastRoot = header.Root
+	}
+	return lastRoot, head.Hash(), nil
+}
+
+// stateBloom is the bloom filter over trie node and code hashes used by geth's offline pruner.
+type stateBloom interface {
+	Put(key []byte, value []byte) error
+	Contain(key []byte) bool
+}
+
+type stateMarker struct {
+	triedb      *trie.Database
+	bloom       stateBloom
+	markedRoots map[common.Hash]bool
+	marked      int64
+}
+
+func (m *stateMarker) mark(hash common.Hash) {
+	// Put only fails for keys which aren't 32 byte hashes
+	_ = m.bloom.Put(hash.Bytes(), nil)
+	m.marked++
+}
+
+func (m *stateMarker) isMarked(key []byte) bool {
+	return m.bloom.Contain(key)
+}
+
+func (m *stateMarker) nodeIterator(id *trie.ID, base common.Hash, baseId *trie.ID) (trie.NodeIterator, *trie.Trie, error) {
+	tr, err := trie.New(id, m.triedb)
+	if err != nil {
+		return nil, nil, err
+	}
+	it, err := tr.NodeIterator(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if base == (common.Hash{}) || base == types.EmptyRootHash {
+		return it, nil, nil
+	}
+	baseTrie, err := trie.New(baseId, m.triedb)
+	if err != nil {
+		return nil, nil, err
+	}
+	baseIt, err := baseTrie.NodeIterator(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	diffIt, _ := trie.NewDifferenceIterator(baseIt, it)
+	return diffIt, baseTrie, nil
+}
+
+// Marks all nodes of the state trie at root, including storage tries and
+// contract code. If base is set, it must already be marked, and only the
+// parts of root which differ from base are walked.
+func (m *stateMarker) markState(ctx context.Context, root common.Hash, base common.Hash) error {
+	if m.markedRoots[root] {
+		return nil
+	}
+	it, baseTrie, err := m.nodeIterator(trie.StateTrieID(root), base, trie.StateTrieID(base))
+	if err != nil {
+		return err
+	}
+	for it.Next(true) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if hash := it.Hash(); hash != (common.Hash{}) {
+			m.mark(hash)
+		}
+		if !it.Leaf() {
+			continue
+		}
+		var account types.StateAccount
+		if err := rlp.DecodeBytes(it.LeafBlob(), &account); err != nil {
+			return err
+		}
+		if codeHash := common.BytesToHash(account.CodeHash); codeHash != types.EmptyCodeHash {
+			m.mark(codeHash)
+		}
+		if account.Root == types.EmptyRootHash {
+			continue
+		}
+		accountHash := common.BytesToHash(it.LeafKey())
+		baseStorageRoot := common.Hash{}
+		if baseTrie != nil {
+			baseAccountData, err := baseTrie.Get(it.LeafKey())
+			if err != nil {
+				return err
+			}
+			if len(baseAccountData) > 0 {
+				var baseAccount types.StateAccount
+				if err := rlp.DecodeBytes(baseAccountData, &baseAccount); err != nil {
+					return err
+				}
+				baseStorageRoot = baseAccount.Root
+			}
+		}
+		if account.Root == baseStorageRoot {
+			continue
+		}
+		storageIt, _, err := m.nodeIterator(trie.StorageTrieID(root, accountHash, account.Root), baseStorageRoot, trie.StorageTrieID(base, accountHash, baseStorageRoot))
+		if err != nil {
+			return err
+		}
+		for storageIt.Next(true) {
+			if hash := storageIt.Hash(); hash != (common.Hash{}) {
+				m.mark(hash)
+			}
+		}
+		if err := storageIt.Error(); err != nil {
+			return err
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	m.markedRoots[root] = true
+	return nil
+}
@@ -0,0 +1,349 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+)
+
+type UpgradeRehearsalConfig struct {
+	// The ArbOS version to upgrade to, which may equal the current version to check the rehearsal reproduces the chain.
+	TargetVersion uint64
+	// The upgrade happens in the first re-executed block whose timestamp is at least this.
+	UpgradeTimestamp uint64
+	// The block whose state is forked; the blocks after it are re-executed.
+	StartBlock uint64
+	Blocks     uint64
+}
+
+type UpgradeRehearsalReport struct {
+	StartBlock     uint64                        `json:"startBlock"`
+	FromVersion    uint64                        `json:"fromVersion"`
+	TargetVersion  uint64                        `json:"targetVersion"`
+	RootMismatches uint64                        `json:"rootMismatches"`
+	FailedTxs      uint64                        `json:"failedTxs"`
+	GasUsedDelta   int64                         `json:"gasUsedDelta"`
+	Blocks         []UpgradeRehearsalBlockReport `json:"blocks"`
+}
+
+type UpgradeRehearsalBlockReport struct {
+	Number           uint64                     `json:"number"`
+	Timestamp        uint64                     `json:"timestamp"`
+	ArbOSVersion     uint64                     `json:"arbosVersion"`
+	OriginalRoot     common.Hash                `json:"originalRoot"`
+	RehearsalRoot    common.Hash                `json:"rehearsalRoot"`
+	OriginalGasUsed  uint64                     `json:"originalGasUsed"`
+	RehearsalGasUsed uint64                     `json:"rehearsalGasUsed"`
+	Txs              []UpgradeRehearsalTxReport `json:"txs,omitempty"`
+}
+
+// UpgradeRehearsalTxReport describes a transaction whose outcome changed under the upgrade.
+// A transaction missing from one of the blocks, such as a scheduled redeem with different parameters, has a nil receipt there.
+type UpgradeRehearsalTxReport struct {
+	Hash             common.Hash `json:"hash"`
+	OriginalStatus   *uint64     `json:"originalStatus"`
+	RehearsalStatus  *uint64     `json:"rehearsalStatus"`
+	OriginalGasUsed  uint64      `json:"originalGasUsed"`
+	RehearsalGasUsed uint64      `json:"rehearsalGasUsed"`
+	Error            string      `json:"error,omitempty"`
+}
+
+// failed is whether the transaction was dropped, or failed after succeeding in the original block
+func (r *UpgradeRehearsalTxReport) failed() bool {
+	if r.Error != "" {
+		return true
+	}
+	if r.RehearsalStatus == nil || *r.RehearsalStatus == types.ReceiptStatusSuccessful {
+		return false
+	}
+	return r.OriginalStatus == nil || *r.OriginalStatus == types.ReceiptStatusSuccessful
+}
+
+// rehearsalChainContext serves the headers of re-executed blocks, falling back to the database for earlier ones
+type rehearsalChainContext struct {
+	db      ethdb.Database
+	headers map[common.Hash]*types.Header
+}
+
+func (c *rehearsalChainContext) Engine() consensus.Engine {
+	return arbos.Engine{}
+}
+
+func (c *rehearsalChainContext) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header, ok := c.headers[hash]; ok {
+		return header
+	}
+	return rawdb.ReadHeader(c.db, hash, number)
+}
+
+// rehearsalDatabase reads through to the node's database but keeps everything written in memory,
+// so that re-executed blocks can be committed while the node's database is opened read-only.
+// Deleting a key only removes it from memory, which is fine as committing state never deletes.
+type rehearsalDatabase struct {
+	ethdb.Database
+	overlay ethdb.KeyValueStore
+}
+
+func newRehearsalDatabase(chainDb ethdb.Database) *rehearsalDatabase {
+	return &rehearsalDatabase{chainDb, memorydb.New()}
+}
+
+func (db *rehearsalDatabase) Has(key []byte) (bool, error) {
+	has, err := db.overlay.Has(key)
+	if has || err != nil {
+		return has, err
+	}
+	return db.Database.Has(key)
+}
+
+func (db *rehearsalDatabase) Get(key []byte) ([]byte, error) {
+	has, err := db.overlay.Has(key)
+	if err != nil {
+		return nil, err
+	}
+	if has {
+		return db.overlay.Get(key)
+	}
+	return db.Database.Get(key)
+}
+
+func (db *rehearsalDatabase) Put(key []byte, value []byte) error {
+	return db.overlay.Put(key, value)
+}
+
+func (db *rehearsalDatabase) Delete(key []byte) error {
+	return db.overlay.Delete(key)
+}
+
+func (db *rehearsalDatabase) NewBatch() ethdb.Batch {
+	return db.overlay.NewBatch()
+}
+
+func (db *rehearsalDatabase) NewBatchWithSize(size int) ethdb.Batch {
+	return db.overlay.NewBatchWithSize(size)
+}
+
+// RehearseArbOSUpgrade forks the state after the start block in memory, schedules an ArbOS upgrade,
+// and re-executes the following blocks with their original L1 headers and transactions.
+// Nothing is written to the database, as the re-executed blocks' state is kept in memory.
+func RehearseArbOSUpgrade(
+	ctx context.Context,
+	chainDb ethdb.Database,
+	chainConfig *params.ChainConfig,
+	readMessage func(arbutil.MessageIndex) (*arbostypes.MessageWithMetadata, error),
+	config *UpgradeRehearsalConfig,
+) (*UpgradeRehearsalReport, error) {
+	genesis := chainConfig.ArbitrumChainParams.GenesisBlockNum
+	if config.StartBlock < genesis {
+		return nil, fmt.Errorf("start block %v precedes the genesis block %v", config.StartBlock, genesis)
+	}
+	parent, err := readCanonicalHeader(chainDb, config.StartBlock)
+	if err != nil {
+		return nil, err
+	}
+	statedb, err := state.New(parent.Root, state.NewDatabase(newRehearsalDatabase(chainDb)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("state of block %v isn't available: %w", config.StartBlock, err)
+	}
+	arbState, err := arbosState.OpenSystemArbosState(statedb, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	report := &UpgradeRehearsalReport{
+		StartBlock:    config.StartBlock,
+		FromVersion:   arbState.ArbOSVersion(),
+		TargetVersion: config.TargetVersion,
+	}
+	if config.TargetVersion < report.FromVersion {
+		return nil, fmt.Errorf("target ArbOS version %v is older than the current version %v", config.TargetVersion, report.FromVersion)
+	}
+	if config.TargetVersion > report.FromVersion {
+		if err := arbState.ScheduleArbOSUpgrade(config.TargetVersion, config.UpgradeTimestamp); err != nil {
+			return nil, err
+		}
+	}
+
+	chainContext := &rehearsalChainContext{chainDb, make(map[common.Hash]*types.Header)}
+	for number := config.StartBlock + 1; number <= config.StartBlock+config.Blocks; number++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		original, err := readCanonicalHeader(chainDb, number)
+		if err != nil {
+			return nil, err
+		}
+		originalBlock := rawdb.ReadBlock(chainDb, original.Hash(), number)
+		if originalBlock == nil {
+			return nil, fmt.Errorf("body of block %v not found", number)
+		}
+		originalReceipts := rawdb.ReadReceipts(chainDb, original.Hash(), number, original.Time, chainConfig)
+		if originalReceipts == nil {
+			return nil, fmt.Errorf("receipts of block %v not found", number)
+		}
+		msg, err := readMessage(arbutil.MessageIndex(number - genesis))
+		if err != nil {
+			return nil, fmt.Errorf("reading message of block %v: %w", number, err)
+		}
+
+		arbState, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+		if err != nil {
+			return nil, err
+		}
+		var txes types.Transactions
+		for i, tx := range originalBlock.Transactions() {
+			generated, err := isGeneratedTx(arbState, i, tx)
+			if err != nil {
+				return nil, err
+			}
+			if !generated {
+				txes = append(txes, tx)
+			}
+		}
+		hooks := arbos.NoopSequencingHooks()
+		block, receipts, err := arbos.ProduceBlockAdvanced(
+			msg.Message.Header, txes, msg.DelayedMessagesRead, parent, statedb, chainContext, chainConfig, hooks,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("re-executing block %v: %w", number, err)
+		}
+		blockReport := rehearsalBlockReport(block, receipts, originalBlock, originalReceipts, txes, hooks.TxErrors)
+		blockReport.ArbOSVersion = arbosState.ArbOSVersion(statedb)
+		for _, tx := range blockReport.Txs {
+			if tx.failed() {
+				report.FailedTxs++
+			}
+		}
+		if blockReport.RehearsalRoot != blockReport.OriginalRoot {
+			report.RootMismatches++
+		}
+		report.GasUsedDelta += int64(blockReport.RehearsalGasUsed) - int64(blockReport.OriginalGasUsed)
+		report.Blocks = append(report.Blocks, *blockReport)
+		log.Info(
+			"re-executed block", "number", number, "arbosVersion", blockReport.ArbOSVersion,
+			"rootMatches", blockReport.RehearsalRoot == blockReport.OriginalRoot, "changedTxs", len(blockReport.Txs),
+		)
+
+		parent = block.Header()
+		chainContext.headers[parent.Hash()] = parent
+		// The block processor requires a StateDB without pending balance changes, so reopen it at the new root.
+		// Committing only writes to the rehearsal database's memory and the trie database's dirty nodes.
+		if _, err := statedb.Commit(number, true); err != nil {
+			return nil, err
+		}
+		statedb, err = state.New(parent.Root, statedb.Database(), nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// isGeneratedTx is whether the block processor adds a transaction of the original block itself:
+// the start block transaction, scheduled redeems, and scheduled calls.
+func isGeneratedTx(arbState *arbosState.ArbosState, index int, tx *types.Transaction) (bool, error) {
+	switch inner := tx.GetInner().(type) {
+	case *types.ArbitrumInternalTx:
+		return index == 0, nil
+	case *types.ArbitrumRetryTx:
+		return true, nil
+	case *types.ArbitrumContractTx:
+		call, err := arbState.Scheduler().OpenCall(inner.RequestId)
+		return call != nil, err
+	default:
+		return false, nil
+	}
+}
+
+func rehearsalBlockReport(
+	block *types.Block, receipts types.Receipts, originalBlock *types.Block, originalReceipts types.Receipts, txes types.Transactions, txErrors []error,
+) *UpgradeRehearsalBlockReport {
+	report := &UpgradeRehearsalBlockReport{
+		Number:           block.NumberU64(),
+		Timestamp:        block.Time(),
+		OriginalRoot:     originalBlock.Root(),
+		RehearsalRoot:    block.Root(),
+		OriginalGasUsed:  originalBlock.GasUsed(),
+		RehearsalGasUsed: block.GasUsed(),
+	}
+	originals := make(map[common.Hash]*types.Receipt, len(originalReceipts))
+	for i, tx := range originalBlock.Transactions() {
+		if i < len(originalReceipts) {
+			originals[tx.Hash()] = originalReceipts[i]
+		}
+	}
+	rehearsed := make(map[common.Hash]bool, len(receipts))
+	for i, tx := range block.Transactions() {
+		receipt := receipts[i]
+		rehearsed[tx.Hash()] = true
+		original := originals[tx.Hash()]
+		if original != nil && original.Status == receipt.Status && original.GasUsed == receipt.GasUsed {
+			continue
+		}
+		txReport := UpgradeRehearsalTxReport{
+			Hash:             tx.Hash(),
+			RehearsalStatus:  &receipt.Status,
+			RehearsalGasUsed: receipt.GasUsed,
+		}
+		if original != nil {
+			txReport.OriginalStatus = &original.Status
+			txReport.OriginalGasUsed = original.GasUsed
+		}
+		report.Txs = append(report.Txs, txReport)
+	}
+	// only user transactions are recorded in the sequencing hooks' errors
+	txErrorsByHash := make(map[common.Hash]error)
+	for _, tx := range txes {
+		if tx.Type() == types.ArbitrumInternalTxType {
+			continue
+		}
+		if len(txErrors) == 0 {
+			break
+		}
+		txErrorsByHash[tx.Hash()] = txErrors[0]
+		txErrors = txErrors[1:]
+	}
+	for _, tx := range originalBlock.Transactions() {
+		if rehearsed[tx.Hash()] {
+			continue
+		}
+		txReport := UpgradeRehearsalTxReport{Hash: tx.Hash()}
+		if original := originals[tx.Hash()]; original != nil {
+			txReport.OriginalStatus = &original.Status
+			txReport.OriginalGasUsed = original.GasUsed
+		}
+		if err := txErrorsByHash[tx.Hash()]; err != nil {
+			txReport.Error = err.Error()
+		}
+		report.Txs = append(report.Txs, txReport)
+	}
+	return report
+}
+
+func readCanonicalHeader(chainDb ethdb.Database, number uint64) (*types.Header, error) {
+	hash := rawdb.ReadCanonicalHash(chainDb, number)
+	if hash == (common.Hash{}) {
+		return nil, fmt.Errorf("block %v not found", number)
+	}
+	header := rawdb.ReadHeader(chainDb, hash, number)
+	if header == nil {
+		return nil, fmt.Errorf("header of block %v not found", number)
+	}
+	return header, nil
+}
@@ -55,6 +55,14 @@ func init() {
 		vm.PrecompiledContractsArbitrum[k] = v
 	}
 
+	// The BLS12-381 precompiles aren't in the address list, which is the same at every ArbOS version.
+	// Listing them would make them warm in every transaction's access list and give them code at genesis,
+	// changing gas on chains that haven't upgraded to ArbOS 21. Instead they're cold like any other account,
+	// and act like empty addresses before ArbOS 21.
+	for addr, precompile := range precompiles.EIP2537Precompiles() {
+		vm.PrecompiledContractsArbitrum[addr] = precompile
+	}
+
 	precompileErrors := make(map[[4]byte]abi.Error)
 	for addr, precompile := range precompiles.Precompiles() {
 		for _, errABI := range precompile.Precompile().GetErrorABIs() {
@@ -5,6 +5,7 @@ package gethhook
 
 import (
 	"bytes"
+	"encoding/binary"
 	"math/big"
 	"testing"
 
@@ -116,6 +117,61 @@ func TestEthDepositMessage(t *testing.T) {
 	}
 }
 
+func TestBatchPostingReportWithBlobs(t *testing.T) {
+	chainId := big.NewInt(6456554)
+	extraGas := uint64(100)
+	batchGasCost := uint64(1000)
+	blobGasUsed := uint64(1 << 17)
+	blobBaseFee := big.NewInt(3)
+
+	var report []byte
+	report = append(report, common.BigToHash(big.NewInt(8794561564)).Bytes()...)
+	report = append(report, common.HexToAddress("0x0102030405").Bytes()...)
+	report = append(report, common.Hash{}.Bytes()...)
+	report = append(report, common.BigToHash(big.NewInt(12)).Bytes()...)
+	report = append(report, common.BigToHash(big.NewInt(10)).Bytes()...)
+	report = binary.BigEndian.AppendUint64(report, extraGas)
+	report = binary.BigEndian.AppendUint64(report, blobGasUsed)
+	report = append(report, common.BigToHash(blobBaseFee).Bytes()...)
+	msg := &arbostypes.L1IncomingMessage{
+		Header:       &arbostypes.L1IncomingMessageHeader{Kind: arbostypes.L1MessageType_BatchPostingReport},
+		L2msg:        report,
+		BatchGasCost: &batchGasCost,
+	}
+	parse := func(arbosVersion uint64) []byte {
+		t.Helper()
+		txes, err := arbos.ParseL2Transactions(msg, chainId, arbosVersion, arbostypes.EthDecimals, nil, nil, nil)
+		Require(t, err)
+		if len(txes) != 1 {
+			Fail(t, "unexpected tx count", len(txes))
+		}
+		return txes[0].Data()
+	}
+
+	// the blob fields are ignored before ArbOS 21
+	data := parse(arbostypes.ArbosVersion_BlobPricing - 1)
+	if !bytes.Equal(data[:4], arbos.InternalTxBatchPostingReportMethodID[:]) {
+		Fail(t, "blob fields were read before ArbOS 21")
+	}
+
+	data = parse(arbostypes.ArbosVersion_BlobPricing)
+	if !bytes.Equal(data[:4], arbos.InternalTxBatchPostingReportWithBlobsMethodID[:]) {
+		Fail(t, "report of a batch posted as blobs isn't priced with them")
+	}
+	inputs, err := util.UnpackInternalTxDataBatchPostingReportWithBlobs(data)
+	Require(t, err)
+	// the batch's calldata is still paid for, with its blobs on top
+	if gas := util.SafeMapGet[uint64](inputs, "batchDataGas"); gas != batchGasCost+extraGas {
+		Fail(t, "wrong batch data gas", gas)
+	}
+	if gas := util.SafeMapGet[uint64](inputs, "blobGasUsed"); gas != blobGasUsed {
+		Fail(t, "wrong blob gas used", gas)
+	}
+	if fee := util.SafeMapGet[*big.Int](inputs, "blobBaseFeeWei"); fee.Cmp(blobBaseFee) != 0 {
+		Fail(t, "wrong blob base fee", fee)
+	}
+}
+
 func RunMessagesThroughAPI(t *testing.T, msgs [][]byte, statedb *state.StateDB) {
 	chainId := big.NewInt(6456554)
 	for _, data := range msgs {
@@ -123,7 +179,7 @@ func RunMessagesThroughAPI(t *testing.T, msgs [][]byte, statedb *state.StateDB)
 		if err != nil {
 			t.Error(err)
 		}
-		txes, err := arbos.ParseL2Transactions(msg, chainId, nil)
+		txes, err := arbos.ParseL2Transactions(msg, chainId, 0, arbostypes.EthDecimals, nil, nil, nil)
 		if err != nil {
 			t.Error(err)
 		}
@@ -158,6 +158,11 @@ func (n NodeInterface) EstimateRetryableTicket(
 
 	l1BaseFee, _ := c.State.L1PricingState().PricePerUnit()
 	maxSubmissionFee := retryables.RetryableSubmissionFee(len(data), l1BaseFee)
+	// the submission carries the L1 base fee in the parent chain's currency
+	l1BaseFee, err := c.State.L1PricingState().NativeToParentChain(l1BaseFee, c.State.ArbOSVersion())
+	if err != nil {
+		return err
+	}
 
 	submitTx := &types.ArbitrumSubmitRetryableTx{
 		ChainId:          nil,
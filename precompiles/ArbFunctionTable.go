@@ -6,26 +6,78 @@ package precompiles
 import (
 	"errors"
 	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbos/functiontable"
 )
 
-// ArbFunctionTable  precompile provided aggregator's the ability to manage function tables.
-// Aggregation works differently in Nitro, so these methods have been stubbed and their effects disabled.
-// They are kept for backwards compatibility.
+// ArbFunctionTable precompile manages the selector dictionaries that selector-compressed L2 messages refer to.
+// Each entry is a 4-byte function selector optionally followed by common leading arguments.
+// Before ArbOS 21 these methods were stubs kept for backwards compatibility with classic aggregators.
 type ArbFunctionTable struct {
 	Address addr // 0x68
 }
 
-// Upload does nothing
+// Upload appends an RLP list of entries to the caller's table
 func (con ArbFunctionTable) Upload(c ctx, evm mech, buf []byte) error {
-	return nil
+	if c.State.ArbOSVersion() < arbostypes.ArbosVersion_SelectorCompression {
+		return con._preVersion21_Upload(c, evm, buf)
+	}
+	entries, err := functiontable.DecodeUpload(buf)
+	if err != nil {
+		return err
+	}
+	_, err = c.State.FunctionTable().Append(c.caller, entries)
+	return err
 }
 
-// Size returns the empty table's size, which is 0
+// Size returns the number of entries in an account's table, or the global table's for the zero address
 func (con ArbFunctionTable) Size(c ctx, evm mech, addr addr) (huge, error) {
-	return big.NewInt(0), nil
+	if c.State.ArbOSVersion() < arbostypes.ArbosVersion_SelectorCompression {
+		return con._preVersion21_Size(c, evm, addr)
+	}
+	size, err := c.State.FunctionTable().Size(addr)
+	return new(big.Int).SetUint64(size), err
 }
 
-// Get reverts since the table is empty
+// Get returns the selector of an entry in an account's table.
+// Payability and gas limits aren't tracked, so they're always false and 0.
 func (con ArbFunctionTable) Get(c ctx, evm mech, addr addr, index huge) (huge, bool, huge, error) {
+	if c.State.ArbOSVersion() < arbostypes.ArbosVersion_SelectorCompression {
+		return con._preVersion21_Get(c, evm, addr, index)
+	}
+	entry, err := con.GetEntry(c, evm, addr, index)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	return new(big.Int).SetBytes(entry[:4]), false, common.Big0, nil
+}
+
+// GetEntry returns an entry in an account's table, its selector followed by any arguments
+func (con ArbFunctionTable) GetEntry(c ctx, evm mech, addr addr, index huge) ([]byte, error) {
+	if !index.IsUint64() {
+		return nil, errors.New("invalid index in ArbFunctionTable")
+	}
+	entry, exists, err := c.State.FunctionTable().Get(addr, index.Uint64())
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("index is past the end of the table")
+	}
+	return entry, nil
+}
+
+func (con ArbFunctionTable) _preVersion21_Upload(c ctx, evm mech, buf []byte) error {
+	return nil
+}
+
+func (con ArbFunctionTable) _preVersion21_Size(c ctx, evm mech, addr addr) (huge, error) {
+	return big.NewInt(0), nil
+}
+
+func (con ArbFunctionTable) _preVersion21_Get(c ctx, evm mech, addr addr, index huge) (huge, bool, huge, error) {
 	return nil, false, nil, errors.New("table is empty")
 }
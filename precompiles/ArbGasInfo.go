@@ -9,6 +9,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
+	"github.com/offchainlabs/nitro/arbos/l2pricing"
 	"github.com/offchainlabs/nitro/arbos/storage"
 	"github.com/offchainlabs/nitro/util/arbmath"
 )
@@ -157,6 +158,34 @@ func (con ArbGasInfo) GetL1BaseFeeEstimate(c ctx, evm mech) (huge, error) {
 	return c.State.L1PricingState().PricePerUnit()
 }
 
+// GetL1BlobBaseFeeEstimate gets the blob base fee paid for the last batch posted as blobs
+func (con ArbGasInfo) GetL1BlobBaseFeeEstimate(c ctx, evm mech) (huge, error) {
+	return c.State.L1PricingState().BlobBaseFeeEstimate()
+}
+
+// GetL2BaseFeeHistory gets the minimum, maximum, and average L2 basefee over the last blocks, including this one
+func (con ArbGasInfo) GetL2BaseFeeHistory(c ctx, evm mech, blocks uint64) (huge, huge, huge, error) {
+	stats, _, err := c.State.PriceHistory().Stats(blocks)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return stats.Min, stats.Max, stats.Average, nil
+}
+
+// GetL1PricePerUnitHistory gets the minimum, maximum, and average L1 price per unit over the last blocks, including this one
+func (con ArbGasInfo) GetL1PricePerUnitHistory(c ctx, evm mech, blocks uint64) (huge, huge, huge, error) {
+	_, stats, err := c.State.PriceHistory().Stats(blocks)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return stats.Min, stats.Max, stats.Average, nil
+}
+
+// GetPriceHistoryLength gets how many of the last blocks have their prices recorded, up to 256
+func (con ArbGasInfo) GetPriceHistoryLength(c ctx, evm mech) (uint64, error) {
+	return c.State.PriceHistory().RecordedBlocks()
+}
+
 // GetL1BaseFeeEstimateInertia gets how slowly ArbOS updates its estimate of the L1 basefee
 func (con ArbGasInfo) GetL1BaseFeeEstimateInertia(c ctx, evm mech) (uint64, error) {
 	return c.State.L1PricingState().Inertia()
@@ -244,3 +273,22 @@ func (con ArbGasInfo) GetAmortizedCostCapBips(c ctx, evm mech) (uint64, error) {
 func (con ArbGasInfo) GetL1FeesAvailable(c ctx, evm mech) (huge, error) {
 	return c.State.L1PricingState().L1FeesAvailable()
 }
+
+// GetResourceConstraint gets the target per second, inertia, and backlog of a kind of resource
+// that's priced apart from computation. A zero target means the resource isn't priced separately.
+func (con ArbGasInfo) GetResourceConstraint(c ctx, evm mech, kind uint8) (uint64, uint64, uint64, error) {
+	if !l2pricing.ResourceKind(kind).Valid() {
+		return 0, 0, 0, l2pricing.ErrInvalidResourceKind
+	}
+	constraint := c.State.L2PricingState().ResourceConstraint(l2pricing.ResourceKind(kind))
+	target, err := constraint.Target()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	inertia, err := constraint.Inertia()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	backlog, err := constraint.Backlog()
+	return target, inertia, backlog, err
+}
@@ -10,7 +10,12 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/offchainlabs/nitro/arbos/feedistribution"
+	"github.com/offchainlabs/nitro/arbos/functiontable"
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
+	"github.com/offchainlabs/nitro/arbos/l2pricing"
+	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/util/arbmath"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/params"
@@ -21,9 +26,13 @@ import (
 // which ensures only a chain owner can access these methods. For methods that
 // are safe for non-owners to call, see ArbOwnerOld
 type ArbOwner struct {
-	Address          addr // 0x70
-	OwnerActs        func(ctx, mech, bytes4, addr, []byte) error
-	OwnerActsGasCost func(bytes4, addr, []byte) (uint64, error)
+	Address                         addr // 0x70
+	OwnerActs                       func(ctx, mech, bytes4, addr, []byte) error
+	OwnerActsGasCost                func(bytes4, addr, []byte) (uint64, error)
+	TimelockedActionQueued          func(ctx, mech, bytes32, addr, uint64, []byte) error
+	TimelockedActionQueuedGasCost   func(bytes32, addr, uint64, []byte) (uint64, error)
+	TimelockedActionCanceled        func(ctx, mech, bytes32) error
+	TimelockedActionCanceledGasCost func(bytes32) (uint64, error)
 }
 
 var (
@@ -54,6 +63,65 @@ func (con ArbOwner) GetAllChainOwners(c ctx, evm mech) ([]common.Address, error)
 	return c.State.ChainOwners().AllMembers(65536)
 }
 
+// AddFilteredAddress makes transactions from or to account fail
+func (con ArbOwner) AddFilteredAddress(c ctx, evm mech, account addr) error {
+	return c.State.FilteredAddresses().Add(account)
+}
+
+// RemoveFilteredAddress removes account from the list of filtered addresses
+func (con ArbOwner) RemoveFilteredAddress(c ctx, evm mech, account addr) error {
+	member, err := c.State.FilteredAddresses().IsMember(account)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return errors.New("tried to remove an address that isn't filtered")
+	}
+	return c.State.FilteredAddresses().Remove(account, c.State.ArbOSVersion())
+}
+
+// GetAllFilteredAddresses retrieves the list of filtered addresses
+func (con ArbOwner) GetAllFilteredAddresses(c ctx, evm mech) ([]common.Address, error) {
+	return c.State.FilteredAddresses().AllMembers(65536)
+}
+
+// SetScheduledCallsGasLimit sets how much gas the calls scheduled through ArbScheduler may use in a block
+func (con ArbOwner) SetScheduledCallsGasLimit(c ctx, evm mech, limit uint64) error {
+	return c.State.Scheduler().SetMaxGasPerBlock(limit)
+}
+
+// SetOwnerTimelockDelay sets how many seconds owner calls are queued for before anyone may execute them.
+// A delay of 0 makes owner calls take effect immediately.
+func (con ArbOwner) SetOwnerTimelockDelay(c ctx, evm mech, delay uint64) error {
+	return c.State.OwnerTimelock().SetDelay(delay)
+}
+
+// SetEmergencyMethod sets whether calls to an ArbOwner method bypass the timelock delay
+func (con ArbOwner) SetEmergencyMethod(c ctx, evm mech, selector bytes4, emergency bool) error {
+	return c.State.OwnerTimelock().SetEmergencyMethod(selector, emergency)
+}
+
+// CancelTimelockedAction deletes a queued owner call before it's executed. This is never delayed.
+func (con ArbOwner) CancelTimelockedAction(c ctx, evm mech, id bytes32) error {
+	action, err := c.State.OwnerTimelock().OpenAction(id)
+	if err != nil {
+		return err
+	}
+	if action == nil {
+		return errors.New("no such timelocked action")
+	}
+	if err := action.Delete(); err != nil {
+		return err
+	}
+	return con.TimelockedActionCanceled(c, evm, id)
+}
+
+// SetResourceConstraint sets the target per second and inertia of a kind of resource that's priced apart from computation.
+// A zero target stops pricing the resource separately.
+func (con ArbOwner) SetResourceConstraint(c ctx, evm mech, kind uint8, target uint64, inertia uint64) error {
+	return c.State.L2PricingState().SetResourceConstraint(l2pricing.ResourceKind(kind), target, inertia)
+}
+
 // SetL1BaseFeeEstimateInertia sets how slowly ArbOS updates its estimate of the L1 basefee
 func (con ArbOwner) SetL1BaseFeeEstimateInertia(c ctx, evm mech, inertia uint64) error {
 	return c.State.L1PricingState().SetInertia(inertia)
@@ -142,10 +210,44 @@ func (con ArbOwner) SetAmortizedCostCapBips(c ctx, evm mech, cap uint64) error {
 	return c.State.L1PricingState().SetAmortizedCostCapBips(cap)
 }
 
+// SetNativeTokenExchangeRate sets how much of the native token a unit of the parent chain's currency is worth,
+// scaled by 1e18, for chains whose native token isn't the parent chain's currency
+func (con ArbOwner) SetNativeTokenExchangeRate(c ctx, evm mech, rate huge) error {
+	l1p := c.State.L1PricingState()
+	current, err := l1p.NativeTokenExchangeRate()
+	if err != nil {
+		return err
+	}
+	if current.Sign() == 0 {
+		return errors.New("chain doesn't use a custom native token")
+	}
+	if rate.Sign() <= 0 {
+		return ErrOutOfBounds
+	}
+	return l1p.SetNativeTokenExchangeRate(rate)
+}
+
 func (con ArbOwner) SetBrotliCompressionLevel(c ctx, evm mech, level uint64) error {
 	return c.State.SetBrotliCompressionLevel(level)
 }
 
+// SetAddressTableAutoRegisterThreshold sets how many transactions must be sent to an address before it's added
+// to the address table, letting the sequencer compress it. A threshold of 0 disables automatic registration.
+func (con ArbOwner) SetAddressTableAutoRegisterThreshold(c ctx, evm mech, threshold uint64) error {
+	return c.State.AddressTable().SetAutoRegisterThreshold(threshold)
+}
+
+// UploadGlobalFunctionTable appends an RLP list of entries to the global function table, which any
+// selector-compressed message may refer to. Entries can't be removed once added.
+func (con ArbOwner) UploadGlobalFunctionTable(c ctx, evm mech, buf []byte) error {
+	entries, err := functiontable.DecodeUpload(buf)
+	if err != nil {
+		return err
+	}
+	_, err = c.State.FunctionTable().Append(functiontable.GlobalOwner, entries)
+	return err
+}
+
 func (con ArbOwner) ReleaseL1PricerSurplusFunds(c ctx, evm mech, maxWeiToRelease huge) (huge, error) {
 	balance := evm.StateDB.GetBalance(l1pricing.L1PricerFundsPoolAddress)
 	l1p := c.State.L1PricingState()
@@ -166,6 +268,55 @@ func (con ArbOwner) ReleaseL1PricerSurplusFunds(c ctx, evm mech, maxWeiToRelease
 	return weiToTransfer, nil
 }
 
+// SetFeeDistribution sets the recipients a kind of fee is split between, with their weights in basis points.
+// Whatever isn't assigned to a recipient goes where the fee would otherwise go.
+// Kind 0 is the network fee, 1 the infra fee, and 2 the L1 pricer's surplus.
+func (con ArbOwner) SetFeeDistribution(c ctx, evm mech, kind uint8, recipients []addr, weights []uint64) error {
+	if len(recipients) != len(weights) {
+		return errors.New("recipients and weights have different lengths")
+	}
+	table := make([]feedistribution.Recipient, len(recipients))
+	for i, recipient := range recipients {
+		table[i] = feedistribution.Recipient{Address: recipient, Weight: arbmath.SaturatingCastToBips(weights[i])}
+	}
+	return c.State.FeeDistribution().SetRecipients(feedistribution.FeeKind(kind), table)
+}
+
+// DistributeL1PricerSurplus pays up to maxWeiToDistribute of the L1 pricer's surplus to the L1 surplus recipients
+// by weight, returning the amount paid. The rest of the surplus is kept, lowering the L1 price.
+func (con ArbOwner) DistributeL1PricerSurplus(c ctx, evm mech, maxWeiToDistribute huge) (huge, error) {
+	l1p := c.State.L1PricingState()
+	fundsDueForRefunds, err := l1p.BatchPosterTable().TotalFundsDue()
+	if err != nil {
+		return nil, err
+	}
+	fundsDueForRewards, err := l1p.FundsDueForRewards()
+	if err != nil {
+		return nil, err
+	}
+	haveFunds, err := l1p.L1FeesAvailable()
+	if err != nil {
+		return nil, err
+	}
+	surplus := arbmath.BigSub(haveFunds, arbmath.BigAdd(fundsDueForRefunds, fundsDueForRewards))
+	if surplus.Sign() <= 0 {
+		return common.Big0, nil
+	}
+	payments, _, err := c.State.FeeDistribution().Split(feedistribution.FeeKindL1Surplus, arbmath.BigMin(surplus, maxWeiToDistribute))
+	if err != nil {
+		return nil, err
+	}
+	distributed := new(big.Int)
+	for _, payment := range payments {
+		_, err := l1p.TransferFromL1FeesAvailable(payment.To, payment.Amount, evm, util.TracingDuringEVM, "l1SurplusDistribution")
+		if err != nil {
+			return nil, err
+		}
+		distributed.Add(distributed, payment.Amount)
+	}
+	return distributed, nil
+}
+
 func (con ArbOwner) SetChainConfig(c ctx, evm mech, serializedChainConfig []byte) error {
 	if c == nil {
 		return errors.New("nil context")
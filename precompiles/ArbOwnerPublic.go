@@ -4,16 +4,25 @@
 package precompiles
 
 import (
+	"errors"
+
 	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbos/feedistribution"
 )
 
 // ArbOwnerPublic precompile provides non-owners with info about the current chain owners.
 // The calls to this precompile do not require the sender be a chain owner.
 // For those that are, see ArbOwner
 type ArbOwnerPublic struct {
-	Address                    addr // 0x6b
-	ChainOwnerRectified        func(ctx, mech, addr) error
-	ChainOwnerRectifiedGasCost func(addr) (uint64, error)
+	Address                         addr // 0x6b
+	ChainOwnerRectified             func(ctx, mech, addr) error
+	ChainOwnerRectifiedGasCost      func(addr) (uint64, error)
+	TimelockedActionExecuted        func(ctx, mech, bytes32) error
+	TimelockedActionExecutedGasCost func(bytes32) (uint64, error)
+
+	// makes a queued call to ArbOwner on behalf of the owner who queued it, set once ArbOwner is created
+	executeOwnerAction func(evm mech, owner addr, calldata []byte, gas uint64) error
 }
 
 // GetAllChainOwners retrieves the list of chain owners
@@ -52,3 +61,106 @@ func (con ArbOwnerPublic) GetInfraFeeAccount(c ctx, evm mech) (addr, error) {
 func (con ArbOwnerPublic) GetBrotliCompressionLevel(c ctx, evm mech) (uint64, error) {
 	return c.State.BrotliCompressionLevel()
 }
+
+// GetAddressTableAutoRegisterThreshold gets how many transactions must be sent to an address before it's added
+// to the address table, or 0 if addresses aren't added automatically
+func (con ArbOwnerPublic) GetAddressTableAutoRegisterThreshold(c ctx, evm mech) (uint64, error) {
+	return c.State.AddressTable().AutoRegisterThreshold()
+}
+
+// GetOwnerTimelockDelay gets how many seconds owner calls are queued for, or 0 if they take effect immediately
+func (con ArbOwnerPublic) GetOwnerTimelockDelay(c ctx, evm mech) (uint64, error) {
+	return c.State.OwnerTimelock().Delay()
+}
+
+// IsEmergencyMethod checks if calls to the ArbOwner method bypass the timelock delay
+func (con ArbOwnerPublic) IsEmergencyMethod(c ctx, evm mech, selector bytes4) (bool, error) {
+	return c.State.OwnerTimelock().IsEmergencyMethod(selector)
+}
+
+// GetTimelockedAction gets the owner who queued a call, when it may be executed, and its calldata
+func (con ArbOwnerPublic) GetTimelockedAction(c ctx, evm mech, id bytes32) (addr, uint64, []byte, error) {
+	action, err := c.State.OwnerTimelock().OpenAction(id)
+	if err != nil {
+		return addr{}, 0, nil, err
+	}
+	if action == nil {
+		return addr{}, 0, nil, errors.New("no such timelocked action")
+	}
+	owner, _ := action.Owner()
+	eta, _ := action.Eta()
+	calldata, err := action.Calldata()
+	return owner, eta, calldata, err
+}
+
+// ExecuteTimelockedAction makes a queued owner call whose delay has passed. Anyone may call this,
+// but the owner who queued the call must still be a chain owner.
+func (con ArbOwnerPublic) ExecuteTimelockedAction(c ctx, evm mech, id bytes32) error {
+	action, err := c.State.OwnerTimelock().OpenAction(id)
+	if err != nil {
+		return err
+	}
+	if action == nil {
+		return errors.New("no such timelocked action")
+	}
+	eta, err := action.Eta()
+	if err != nil {
+		return err
+	}
+	if evm.Context.Time < eta {
+		return errors.New("timelocked action isn't ready to be executed")
+	}
+	owner, err := action.Owner()
+	if err != nil {
+		return err
+	}
+	isOwner, err := c.State.ChainOwners().IsMember(owner)
+	if err != nil {
+		return err
+	}
+	if !isOwner {
+		return errors.New("timelocked action was queued by an account that's no longer a chain owner")
+	}
+	calldata, err := action.Calldata()
+	if err != nil {
+		return err
+	}
+	if err := action.Delete(); err != nil {
+		return err
+	}
+	if err := con.executeOwnerAction(evm, owner, calldata, c.gasLeft); err != nil {
+		return err
+	}
+	return con.TimelockedActionExecuted(c, evm, id)
+}
+
+// IsFilteredAddress checks if transactions from or to the account fail
+func (con ArbOwnerPublic) IsFilteredAddress(c ctx, evm mech, account addr) (bool, error) {
+	return c.State.FilteredAddresses().IsMember(account)
+}
+
+// GetAllFilteredAddresses retrieves the list of filtered addresses
+func (con ArbOwnerPublic) GetAllFilteredAddresses(c ctx, evm mech) ([]common.Address, error) {
+	return c.State.FilteredAddresses().AllMembers(65536)
+}
+
+// GetNativeTokenExchangeRate gets how much of the native token a unit of the parent chain's currency is worth,
+// scaled by 1e18, or 0 if the chain's native token is the parent chain's currency
+func (con ArbOwnerPublic) GetNativeTokenExchangeRate(c ctx, evm mech) (huge, error) {
+	return c.State.L1PricingState().NativeTokenExchangeRate()
+}
+
+// GetFeeDistribution gets the recipients a kind of fee is split between, with their weights in basis points
+func (con ArbOwnerPublic) GetFeeDistribution(c ctx, evm mech, kind uint8) ([]addr, []uint64, error) {
+	table, err := c.State.FeeDistribution().Recipients(feedistribution.FeeKind(kind))
+	if err != nil {
+		return nil, nil, err
+	}
+	recipients := make([]addr, len(table))
+	weights := make([]uint64, len(table))
+	for i, recipient := range table {
+		recipients[i] = recipient.Address
+		weights[i] = uint64(recipient.Weight)
+	}
+	return recipients, weights, nil
+}
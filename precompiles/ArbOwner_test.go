@@ -9,15 +9,22 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
 
 	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
 	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/functiontable"
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
+	"github.com/offchainlabs/nitro/arbos/l2pricing"
+	"github.com/offchainlabs/nitro/arbos/timelock"
 	"github.com/offchainlabs/nitro/arbos/util"
 	"github.com/offchainlabs/nitro/util/testhelpers"
 )
@@ -227,3 +234,220 @@ func TestArbInfraFeeAccount(t *testing.T) {
 		t.Fatal()
 	}
 }
+
+func TestArbOwnerFilteredAddresses(t *testing.T) {
+	evm := newMockEVMForTesting()
+	upgradeArbosForTesting(t, evm, arbostypes.ArbosVersion_AddressFilter)
+	caller := common.BytesToAddress(crypto.Keccak256([]byte{})[:20])
+	callCtx := testContext(caller, evm)
+	prec := &ArbOwner{}
+	precPublic := &ArbOwnerPublic{}
+	addr1 := common.BytesToAddress(crypto.Keccak256([]byte{1})[:20])
+	addr2 := common.BytesToAddress(crypto.Keccak256([]byte{2})[:20])
+
+	Require(t, prec.AddFilteredAddress(callCtx, evm, addr1))
+	Require(t, prec.AddFilteredAddress(callCtx, evm, addr2))
+	filtered, err := precPublic.IsFilteredAddress(callCtx, evm, addr1)
+	Require(t, err)
+	if !filtered {
+		Fail(t, "address wasn't filtered")
+	}
+
+	Require(t, prec.RemoveFilteredAddress(callCtx, evm, addr1))
+	if prec.RemoveFilteredAddress(callCtx, evm, addr1) == nil {
+		Fail(t, "removed an address that wasn't filtered")
+	}
+	all, err := precPublic.GetAllFilteredAddresses(callCtx, evm)
+	Require(t, err)
+	if len(all) != 1 || all[0] != addr2 {
+		Fail(t, "unexpected filtered addresses", all)
+	}
+	all, err = prec.GetAllFilteredAddresses(callCtx, evm)
+	Require(t, err)
+	if len(all) != 1 || all[0] != addr2 {
+		Fail(t, "unexpected filtered addresses", all)
+	}
+}
+
+func TestArbOwnerResourceConstraints(t *testing.T) {
+	evm := newMockEVMForTesting()
+	upgradeArbosForTesting(t, evm, arbostypes.ArbosVersion_MultiDimensionalPricing)
+	caller := common.BytesToAddress(crypto.Keccak256([]byte{})[:20])
+	callCtx := testContext(caller, evm)
+	prec := &ArbOwner{}
+	gasInfo := &ArbGasInfo{}
+	kind := uint8(l2pricing.ResourceKindHistoryGrowth)
+
+	target, inertia, backlog, err := gasInfo.GetResourceConstraint(callCtx, evm, kind)
+	Require(t, err)
+	if target != 0 || inertia != 0 || backlog != 0 {
+		Fail(t, "resource constraint enabled by default", target, inertia, backlog)
+	}
+
+	Require(t, prec.SetResourceConstraint(callCtx, evm, kind, 1000, 50))
+	Require(t, callCtx.State.L2PricingState().AddResourceUsage(l2pricing.ResourceKindHistoryGrowth, 4000))
+	target, inertia, backlog, err = gasInfo.GetResourceConstraint(callCtx, evm, kind)
+	Require(t, err)
+	if target != 1000 || inertia != 50 || backlog != 4000 {
+		Fail(t, "unexpected resource constraint", target, inertia, backlog)
+	}
+
+	if prec.SetResourceConstraint(callCtx, evm, kind, 1000, 0) == nil {
+		Fail(t, "enabled a resource constraint without inertia")
+	}
+	if prec.SetResourceConstraint(callCtx, evm, uint8(l2pricing.NumResourceKinds), 1000, 50) == nil {
+		Fail(t, "set a constraint for an invalid resource kind")
+	}
+	if _, _, _, err := gasInfo.GetResourceConstraint(callCtx, evm, uint8(l2pricing.NumResourceKinds)); err == nil {
+		Fail(t, "got a constraint for an invalid resource kind")
+	}
+}
+
+func TestArbOwnerTimelock(t *testing.T) {
+	evm := newMockEVMForTesting()
+	upgradeArbosForTesting(t, evm, arbostypes.ArbosVersion_OwnerTimelock)
+	precompiles := Precompiles()
+	ownerAddress := common.HexToAddress("0x70")
+	publicAddress := common.HexToAddress("0x6b")
+	owner := common.Address{} // the zero address is an owner by default
+	account := common.BytesToAddress(crypto.Keccak256([]byte{1})[:20])
+
+	readABI := func(contract string) abi.ABI {
+		data, err := pendingInterfaces.ReadFile("interfaces/" + contract + ".json")
+		Require(t, err)
+		contractABI, err := abi.JSON(bytes.NewReader(data))
+		Require(t, err)
+		return contractABI
+	}
+	ownerABI := readABI("ArbOwner")
+	publicABI := readABI("ArbOwnerPublic")
+	call := func(address common.Address, contractABI abi.ABI, method string, args ...interface{}) error {
+		t.Helper()
+		input, err := contractABI.Pack(method, args...)
+		Require(t, err)
+		_, _, err = precompiles[address].Call(input, address, address, owner, common.Big0, false, 10_000_000, evm)
+		return err
+	}
+	isFiltered := func() bool {
+		t.Helper()
+		filtered, err := (&ArbOwnerPublic{}).IsFilteredAddress(testContext(owner, evm), evm, account)
+		Require(t, err)
+		return filtered
+	}
+
+	Require(t, call(ownerAddress, ownerABI, "setOwnerTimelockDelay", uint64(100)))
+	Require(t, call(ownerAddress, ownerABI, "addFilteredAddress", account))
+	if isFiltered() {
+		Fail(t, "owner call took effect before its delay")
+	}
+
+	id := timelock.ActionId(0) // setting the delay took effect immediately, so this is the first queued call
+	if call(publicAddress, publicABI, "executeTimelockedAction", id) == nil {
+		Fail(t, "executed an owner call before its delay passed")
+	}
+	evm.Context.Time += 100
+	Require(t, call(publicAddress, publicABI, "executeTimelockedAction", id))
+	if !isFiltered() {
+		Fail(t, "executed owner call didn't take effect")
+	}
+	if call(publicAddress, publicABI, "executeTimelockedAction", id) == nil {
+		Fail(t, "executed an owner call twice")
+	}
+}
+
+func TestArbOwnerFeeDistribution(t *testing.T) {
+	evm := newMockEVMForTesting()
+	upgradeArbosForTesting(t, evm, arbostypes.ArbosVersion_FeeDistribution)
+	caller := common.BytesToAddress(crypto.Keccak256([]byte{})[:20])
+	callCtx := testContext(caller, evm)
+	prec := &ArbOwner{}
+	precPublic := &ArbOwnerPublic{}
+	addr1 := common.BytesToAddress(crypto.Keccak256([]byte{1})[:20])
+	addr2 := common.BytesToAddress(crypto.Keccak256([]byte{2})[:20])
+
+	if err := prec.SetFeeDistribution(callCtx, evm, 2, []common.Address{addr1}, []uint64{1, 2}); err == nil {
+		Fail(t, "mismatched recipients and weights were accepted")
+	}
+	if err := prec.SetFeeDistribution(callCtx, evm, 3, nil, nil); err == nil {
+		Fail(t, "invalid fee kind was accepted")
+	}
+	Require(t, prec.SetFeeDistribution(callCtx, evm, 2, []common.Address{addr1, addr2}, []uint64{5000, 2000}))
+	recipients, weights, err := precPublic.GetFeeDistribution(callCtx, evm, 2)
+	Require(t, err)
+	if len(recipients) != 2 || recipients[0] != addr1 || recipients[1] != addr2 || weights[0] != 5000 || weights[1] != 2000 {
+		Fail(t, "wrong fee distribution", recipients, weights)
+	}
+
+	// there's no surplus to distribute until the pool holds more than it owes
+	distributed, err := prec.DistributeL1PricerSurplus(callCtx, evm, big.NewInt(1e18))
+	Require(t, err)
+	if distributed.Sign() != 0 {
+		Fail(t, "distributed a surplus that doesn't exist", distributed)
+	}
+	l1p := callCtx.State.L1PricingState()
+	surplus := big.NewInt(1000)
+	pool := l1pricing.L1PricerFundsPoolAddress
+	util.MintBalance(&pool, surplus, evm, util.TracingBeforeEVM, "test")
+	_, err = l1p.AddToL1FeesAvailable(surplus)
+	Require(t, err)
+
+	distributed, err = prec.DistributeL1PricerSurplus(callCtx, evm, big.NewInt(500))
+	Require(t, err)
+	if distributed.Int64() != 350 {
+		Fail(t, "wrong amount distributed", distributed)
+	}
+	if evm.StateDB.GetBalance(addr1).Int64() != 250 || evm.StateDB.GetBalance(addr2).Int64() != 100 {
+		Fail(t, "wrong shares", evm.StateDB.GetBalance(addr1), evm.StateDB.GetBalance(addr2))
+	}
+	available, err := l1p.L1FeesAvailable()
+	Require(t, err)
+	if available.Int64() != 650 || evm.StateDB.GetBalance(pool).Int64() != 650 {
+		Fail(t, "the pool wasn't debited", available, evm.StateDB.GetBalance(pool))
+	}
+}
+
+// upgradeArbosForTesting upgrades the mock EVM's ArbOS state to the given version
+func upgradeArbosForTesting(t *testing.T, evm *vm.EVM, version uint64) {
+	t.Helper()
+	state, err := arbosState.OpenArbosState(evm.StateDB, burn.NewSystemBurner(nil, false))
+	Require(t, err)
+	Require(t, state.UpgradeArbosVersion(version, false, evm.StateDB, evm.ChainConfig()))
+}
+
+func TestArbOwnerGlobalFunctionTable(t *testing.T) {
+	evm := newMockEVMForTesting()
+	upgradeArbosForTesting(t, evm, arbostypes.ArbosVersion_SelectorCompression)
+	caller := common.BytesToAddress(crypto.Keccak256([]byte{})[:20])
+	callCtx := testContext(caller, evm)
+	prec := &ArbOwner{}
+	table := &ArbFunctionTable{}
+
+	transfer := []byte{0xa9, 0x05, 0x9c, 0xbb}
+	approve := append([]byte{0x09, 0x5e, 0xa7, 0xb3}, common.LeftPadBytes(caller.Bytes(), 32)...)
+	upload, err := rlp.EncodeToBytes([][]byte{transfer, approve})
+	Require(t, err)
+	Require(t, prec.UploadGlobalFunctionTable(callCtx, evm, upload))
+	if err := prec.UploadGlobalFunctionTable(callCtx, evm, []byte{0x01}); err == nil {
+		Fail(t, "an upload that isn't an RLP list was accepted")
+	}
+
+	// the global table belongs to the zero address, and the caller's own table is untouched
+	size, err := table.Size(callCtx, evm, functiontable.GlobalOwner)
+	Require(t, err)
+	if size.Uint64() != 2 {
+		Fail(t, "wrong global table size", size)
+	}
+	size, err = table.Size(callCtx, evm, caller)
+	Require(t, err)
+	if size.Sign() != 0 {
+		Fail(t, "the owner's upload went to its own table", size)
+	}
+	entry, err := table.GetEntry(callCtx, evm, functiontable.GlobalOwner, big.NewInt(1))
+	Require(t, err)
+	if !bytes.Equal(entry, approve) {
+		Fail(t, "wrong global table entry", entry)
+	}
+	if _, err := table.GetEntry(callCtx, evm, functiontable.GlobalOwner, big.NewInt(2)); err == nil {
+		Fail(t, "got an entry past the end of the global table")
+	}
+}
@@ -28,6 +28,9 @@ type ArbRetryableTx struct {
 	RedeemScheduledGasCost  func(bytes32, bytes32, uint64, uint64, addr, huge, huge) (uint64, error)
 	CanceledGasCost         func(bytes32) (uint64, error)
 
+	BeneficiaryTransferred        func(ctx, mech, bytes32, addr, addr) error
+	BeneficiaryTransferredGasCost func(bytes32, addr, addr) (uint64, error)
+
 	// deprecated event
 	Redeemed        func(ctx, mech, bytes32) error
 	RedeemedGasCost func(bytes32) (uint64, error)
@@ -38,6 +41,8 @@ type ArbRetryableTx struct {
 
 var ErrSelfModifyingRetryable = errors.New("retryable cannot modify itself")
 
+const MaxBatchRedeems = 64 // bounds the work done by a single RedeemBatch call
+
 func (con ArbRetryableTx) oldNotFoundError(c ctx) error {
 	if c.State.ArbOSVersion() >= 3 {
 		return con.NoTicketWithIDError()
@@ -47,89 +52,230 @@ func (con ArbRetryableTx) oldNotFoundError(c ctx) error {
 
 // Redeem schedules an attempt to redeem the retryable, donating all of the call's gas to the redeem attempt
 func (con ArbRetryableTx) Redeem(c ctx, evm mech, ticketId bytes32) (bytes32, error) {
+	maxRefund := new(big.Int).Exp(common.Big2, common.Big256, nil)
+	maxRefund.Sub(maxRefund, common.Big1)
+	retryTxInner, err := con.makeRetryTx(c, evm, ticketId, maxRefund)
+	if err != nil {
+		return hash{}, err
+	}
+	nonce := retryTxInner.Nonce
+
+	// figure out how much gas the event issuance will cost, and reduce the donated gas amount in the event
+	//     by that much, so that we'll donate the correct amount of gas
+	eventCost, err := con.RedeemScheduledGasCost(hash{}, hash{}, 0, 0, addr{}, common.Big0, common.Big0)
+	if err != nil {
+		return hash{}, err
+	}
+	// Result is 32 bytes long which is 1 word
+	gasCostToReturnResult := params.CopyGas
+	gasPoolUpdateCost := storage.StorageReadCost + storage.StorageWriteCost
+	futureGasCosts := eventCost + gasCostToReturnResult + gasPoolUpdateCost
+	if c.gasLeft < futureGasCosts {
+		return hash{}, c.Burn(futureGasCosts) // this will error
+	}
+	gasToDonate := c.gasLeft - futureGasCosts
+	if gasToDonate < params.TxGas {
+		return hash{}, errors.New("not enough gas to run redeem attempt")
+	}
+
+	// fix up the gas in the retry
+	retryTxInner.Gas = gasToDonate
+
+	retryTx := types.NewTx(retryTxInner)
+	retryTxHash := retryTx.Hash()
+
+	err = con.RedeemScheduled(c, evm, ticketId, retryTxHash, nonce, gasToDonate, c.caller, maxRefund, common.Big0)
+	if err != nil {
+		return hash{}, err
+	}
+
+	// To prepare for the enqueued retry event, we burn gas here, adding it back to the pool right before retrying.
+	// The gas payer for this tx will get a credit for the wei they paid for this gas when retrying.
+	// We burn as much gas as we can, leaving only enough to pay for copying out the return data.
+	if err := c.Burn(gasToDonate); err != nil {
+		return hash{}, err
+	}
+
+	// Add the gasToDonate back to the gas pool: the retryable attempt will then consume it.
+	// This ensures that the gas pool has enough gas to run the retryable attempt.
+	return retryTxHash, c.State.L2PricingState().AddToGasPool(arbmath.SaturatingCast(gasToDonate))
+}
+
+// makeRetryTx charges for reading the retryable and makes its next retry, leaving the retry's gas for the caller to fill in
+func (con ArbRetryableTx) makeRetryTx(c ctx, evm mech, ticketId bytes32, maxRefund huge) (*types.ArbitrumRetryTx, error) {
 	if c.txProcessor.CurrentRetryable != nil && ticketId == *c.txProcessor.CurrentRetryable {
-		return bytes32{}, ErrSelfModifyingRetryable
+		return nil, ErrSelfModifyingRetryable
 	}
 	retryableState := c.State.RetryableState()
 	byteCount, err := retryableState.RetryableSizeBytes(ticketId, evm.Context.Time)
 	if err != nil {
-		return hash{}, err
+		return nil, err
 	}
 	writeBytes := arbmath.WordsForBytes(byteCount)
 	if err := c.Burn(params.SloadGas * writeBytes); err != nil {
-		return hash{}, err
+		return nil, err
 	}
 
 	retryable, err := retryableState.OpenRetryable(ticketId, evm.Context.Time)
 	if err != nil {
-		return hash{}, err
+		return nil, err
 	}
 	if retryable == nil {
-		return hash{}, con.oldNotFoundError(c)
+		return nil, con.oldNotFoundError(c)
 	}
 	nextNonce, err := retryable.IncrementNumTries()
 	if err != nil {
-		return hash{}, err
+		return nil, err
 	}
-	nonce := nextNonce - 1
-
-	maxRefund := new(big.Int).Exp(common.Big2, common.Big256, nil)
-	maxRefund.Sub(maxRefund, common.Big1)
-	retryTxInner, err := retryable.MakeTx(
+	return retryable.MakeTx(
 		evm.ChainConfig().ChainID,
-		nonce,
+		nextNonce-1,
 		evm.Context.BaseFee,
-		0, // will fill this in below
+		0, // filled in by the caller
 		ticketId,
 		c.caller,
 		maxRefund,
 		common.Big0,
 	)
+}
+
+// RedeemWithGasLimit schedules an attempt to redeem the retryable with the given gas limit.
+// As much of the call's gas as needed is donated to the attempt, and the callvalue pays for the rest
+// at the current basefee. Any callvalue that isn't needed is returned to the caller.
+func (con ArbRetryableTx) RedeemWithGasLimit(c ctx, evm mech, value huge, ticketId bytes32, gasLimit uint64) (bytes32, error) {
+	if gasLimit < params.TxGas {
+		return hash{}, errors.New("gas limit is too low to run redeem attempt")
+	}
+	maxRefund := new(big.Int).Exp(common.Big2, common.Big256, nil)
+	maxRefund.Sub(maxRefund, common.Big1)
+	retryTxInner, err := con.makeRetryTx(c, evm, ticketId, maxRefund)
 	if err != nil {
 		return hash{}, err
 	}
 
-	// figure out how much gas the event issuance will cost, and reduce the donated gas amount in the event
-	//     by that much, so that we'll donate the correct amount of gas
 	eventCost, err := con.RedeemScheduledGasCost(hash{}, hash{}, 0, 0, addr{}, common.Big0, common.Big0)
 	if err != nil {
 		return hash{}, err
 	}
-	// Result is 32 bytes long which is 1 word
-	gasCostToReturnResult := params.CopyGas
 	gasPoolUpdateCost := storage.StorageReadCost + storage.StorageWriteCost
-	futureGasCosts := eventCost + gasCostToReturnResult + gasPoolUpdateCost
+	futureGasCosts := eventCost + params.CopyGas + gasPoolUpdateCost
 	if c.gasLeft < futureGasCosts {
 		return hash{}, c.Burn(futureGasCosts) // this will error
 	}
-	gasToDonate := c.gasLeft - futureGasCosts
-	if gasToDonate < params.TxGas {
-		return hash{}, errors.New("not enough gas to run redeem attempt")
-	}
+	gasToDonate := arbmath.MinInt(gasLimit, c.gasLeft-futureGasCosts)
 
-	// fix up the gas in the retry
-	retryTxInner.Gas = gasToDonate
-
-	retryTx := types.NewTx(retryTxInner)
-	retryTxHash := retryTx.Hash()
+	// the gas the call doesn't donate is bought at the basefee, and its fees collected as if the caller had paid them
+	gasToBuy := gasLimit - gasToDonate
+	if err := con.collectRetryGasFees(c, evm, value, gasToBuy); err != nil {
+		return hash{}, err
+	}
 
-	err = con.RedeemScheduled(c, evm, ticketId, retryTxHash, nonce, gasToDonate, c.caller, maxRefund, common.Big0)
+	retryTxInner.Gas = gasLimit
+	retryTxHash := types.NewTx(retryTxInner).Hash()
+	err = con.RedeemScheduled(c, evm, ticketId, retryTxHash, retryTxInner.Nonce, gasLimit, c.caller, maxRefund, common.Big0)
 	if err != nil {
 		return hash{}, err
 	}
-
-	// To prepare for the enqueued retry event, we burn gas here, adding it back to the pool right before retrying.
-	// The gas payer for this tx will get a credit for the wei they paid for this gas when retrying.
-	// We burn as much gas as we can, leaving only enough to pay for copying out the return data.
 	if err := c.Burn(gasToDonate); err != nil {
 		return hash{}, err
 	}
-
-	// Add the gasToDonate back to the gas pool: the retryable attempt will then consume it.
-	// This ensures that the gas pool has enough gas to run the retryable attempt.
+	// only the donated gas was removed from the gas pool by the caller's tx
 	return retryTxHash, c.State.L2PricingState().AddToGasPool(arbmath.SaturatingCast(gasToDonate))
 }
 
+// collectRetryGasFees pays the fee collectors for gas bought with the callvalue, returning any excess to the caller.
+// Retries refund their unused gas from these same accounts.
+func (con ArbRetryableTx) collectRetryGasFees(c ctx, evm mech, value huge, gas uint64) error {
+	baseFee := evm.Context.BaseFee
+	cost := arbmath.BigMulByUint(baseFee, gas)
+	if arbmath.BigLessThan(value, cost) {
+		return errors.New("insufficient callvalue to pay for the redeem attempt's gas")
+	}
+	networkCost := cost
+	infraFeeAccount, err := c.State.InfraFeeAccount()
+	if err != nil {
+		return err
+	}
+	if infraFeeAccount != (common.Address{}) {
+		minBaseFee, err := c.State.L2PricingState().MinBaseFeeWei()
+		if err != nil {
+			return err
+		}
+		infraCost := arbmath.BigMulByUint(arbmath.BigMin(minBaseFee, baseFee), gas)
+		if err := util.TransferBalance(&con.Address, &infraFeeAccount, infraCost, evm, util.TracingDuringEVM, "feeCollection"); err != nil {
+			return err
+		}
+		networkCost = arbmath.BigSub(networkCost, infraCost)
+	}
+	networkFeeAccount, err := c.State.NetworkFeeAccount()
+	if err != nil {
+		return err
+	}
+	if err := util.TransferBalance(&con.Address, &networkFeeAccount, networkCost, evm, util.TracingDuringEVM, "feeCollection"); err != nil {
+		return err
+	}
+	return util.TransferBalance(&con.Address, &c.caller, arbmath.BigSub(value, cost), evm, util.TracingDuringEVM, "refund")
+}
+
+// RedeemBatch schedules an attempt to redeem each of the retryables, splitting the call's gas evenly between them
+func (con ArbRetryableTx) RedeemBatch(c ctx, evm mech, ticketIds []bytes32) ([]bytes32, error) {
+	if len(ticketIds) == 0 || len(ticketIds) > MaxBatchRedeems {
+		return nil, errors.New("number of retryables to redeem is out of range")
+	}
+	// a ticket listed twice would be scheduled twice, so reject the whole batch
+	seen := make(map[bytes32]struct{}, len(ticketIds))
+	for _, ticketId := range ticketIds {
+		if _, ok := seen[ticketId]; ok {
+			return nil, errors.New("retryable appears more than once in the batch")
+		}
+		seen[ticketId] = struct{}{}
+	}
+	maxRefund := new(big.Int).Exp(common.Big2, common.Big256, nil)
+	maxRefund.Sub(maxRefund, common.Big1)
+	retryTxInners := make([]*types.ArbitrumRetryTx, 0, len(ticketIds))
+	for _, ticketId := range ticketIds {
+		retryTxInner, err := con.makeRetryTx(c, evm, ticketId, maxRefund)
+		if err != nil {
+			return nil, err
+		}
+		retryTxInners = append(retryTxInners, retryTxInner)
+	}
+
+	count := uint64(len(ticketIds))
+	eventCost, err := con.RedeemScheduledGasCost(hash{}, hash{}, 0, 0, addr{}, common.Big0, common.Big0)
+	if err != nil {
+		return nil, err
+	}
+	// the result is an offset, a length, and a word per retry tx hash
+	gasCostToReturnResult := params.CopyGas * (count + 2)
+	gasPoolUpdateCost := storage.StorageReadCost + storage.StorageWriteCost
+	futureGasCosts := eventCost*count + gasCostToReturnResult + gasPoolUpdateCost
+	if c.gasLeft < futureGasCosts {
+		return nil, c.Burn(futureGasCosts) // this will error
+	}
+	gasPerRetry := (c.gasLeft - futureGasCosts) / count
+	if gasPerRetry < params.TxGas {
+		return nil, errors.New("not enough gas to run redeem attempts")
+	}
+
+	retryTxHashes := make([]bytes32, 0, count)
+	for i, retryTxInner := range retryTxInners {
+		retryTxInner.Gas = gasPerRetry
+		retryTxHash := types.NewTx(retryTxInner).Hash()
+		err := con.RedeemScheduled(c, evm, ticketIds[i], retryTxHash, retryTxInner.Nonce, gasPerRetry, c.caller, maxRefund, common.Big0)
+		if err != nil {
+			return nil, err
+		}
+		retryTxHashes = append(retryTxHashes, retryTxHash)
+	}
+
+	gasToDonate := gasPerRetry * count
+	if err := c.Burn(gasToDonate); err != nil {
+		return nil, err
+	}
+	return retryTxHashes, c.State.L2PricingState().AddToGasPool(arbmath.SaturatingCast(gasToDonate))
+}
+
 // GetLifetime gets the default lifetime period a retryable has at creation
 func (con ArbRetryableTx) GetLifetime(c ctx, evm mech) (huge, error) {
 	return big.NewInt(retryables.RetryableLifetimeSeconds), nil
@@ -193,6 +339,32 @@ func (con ArbRetryableTx) GetBeneficiary(c ctx, evm mech, ticketId bytes32) (add
 	return retryable.Beneficiary()
 }
 
+// TransferBeneficiary makes another account the ticket's beneficiary, which receives its callvalue if it's
+// canceled or expires. Only the current beneficiary may do this.
+func (con ArbRetryableTx) TransferBeneficiary(c ctx, evm mech, ticketId bytes32, newBeneficiary addr) error {
+	if c.txProcessor.CurrentRetryable != nil && ticketId == *c.txProcessor.CurrentRetryable {
+		return ErrSelfModifyingRetryable
+	}
+	retryable, err := c.State.RetryableState().OpenRetryable(ticketId, evm.Context.Time)
+	if err != nil {
+		return err
+	}
+	if retryable == nil {
+		return con.NoTicketWithIDError()
+	}
+	beneficiary, err := retryable.Beneficiary()
+	if err != nil {
+		return err
+	}
+	if c.caller != beneficiary {
+		return errors.New("only the beneficiary may transfer a retryable")
+	}
+	if err := retryable.SetBeneficiary(newBeneficiary); err != nil {
+		return err
+	}
+	return con.BeneficiaryTransferred(c, evm, ticketId, beneficiary, newBeneficiary)
+}
+
 // Cancel the ticket and refund its callvalue to its beneficiary
 func (con ArbRetryableTx) Cancel(c ctx, evm mech, ticketId bytes32) error {
 	if c.txProcessor.CurrentRetryable != nil && ticketId == *c.txProcessor.CurrentRetryable {
@@ -4,12 +4,18 @@
 package precompiles
 
 import (
+	"bytes"
 	"math/big"
 	"testing"
 
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
 	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/util/arbmath"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
 	templates "github.com/offchainlabs/nitro/solgen/go/precompilesgen"
 )
 
@@ -63,3 +69,133 @@ func TestRetryableRedeem(t *testing.T) {
 		Fail(t, "didn't consume all the expected gas")
 	}
 }
+
+// newRetryableExtensionsTest makes a mock EVM that supports the ArbOS 21 retryable methods, and a helper to call them
+func newRetryableExtensionsTest(t *testing.T) (*vm.EVM, func(caller common.Address, value *big.Int, method string, args ...interface{}) ([]interface{}, error)) {
+	t.Helper()
+	evm := newMockEVMForTesting()
+	upgradeArbosForTesting(t, evm, arbostypes.ArbosVersion_RetryableExtensions)
+
+	data, err := pendingInterfaces.ReadFile("interfaces/ArbRetryableTx.json")
+	Require(t, err)
+	retryABI, err := abi.JSON(bytes.NewReader(data))
+	Require(t, err)
+	retryAddress := common.HexToAddress("6e")
+	call := func(caller common.Address, value *big.Int, method string, args ...interface{}) ([]interface{}, error) {
+		t.Helper()
+		input, err := retryABI.Pack(method, args...)
+		Require(t, err)
+		if value.Sign() > 0 {
+			// the EVM moves the value to the precompile before calling it
+			evm.StateDB.AddBalance(retryAddress, value)
+		}
+		output, _, err := Precompiles()[retryAddress].Call(input, retryAddress, retryAddress, caller, value, false, 1000000, evm)
+		if err != nil {
+			return nil, err
+		}
+		return retryABI.Unpack(method, output)
+	}
+	return evm, call
+}
+
+func createRetryableForTesting(t *testing.T, evm *vm.EVM, id common.Hash, beneficiary common.Address) {
+	t.Helper()
+	to := common.HexToAddress("0x06070809")
+	_, err := testContext(common.Address{}, evm).State.RetryableState().CreateRetryable(
+		id,
+		evm.Context.Time+10000000,
+		common.HexToAddress("0x030405"),
+		&to,
+		big.NewInt(0),
+		beneficiary,
+		[]byte{},
+	)
+	Require(t, err)
+}
+
+func TestRetryableTransferBeneficiary(t *testing.T) {
+	evm, call := newRetryableExtensionsTest(t)
+	id := common.BigToHash(big.NewInt(978645611143))
+	beneficiary := common.HexToAddress("0x0301040105090206")
+	newBeneficiary := common.HexToAddress("0x0201070108020801")
+	createRetryableForTesting(t, evm, id, beneficiary)
+
+	transfer := func(caller common.Address) error {
+		_, err := call(caller, common.Big0, "transferBeneficiary", id, newBeneficiary)
+		return err
+	}
+	if transfer(newBeneficiary) == nil {
+		Fail(t, "someone other than the beneficiary transferred the retryable")
+	}
+	Require(t, transfer(beneficiary))
+
+	retryable, err := testContext(common.Address{}, evm).State.RetryableState().OpenRetryable(id, evm.Context.Time)
+	Require(t, err)
+	current, err := retryable.Beneficiary()
+	Require(t, err)
+	if current != newBeneficiary {
+		Fail(t, "beneficiary wasn't transferred", current)
+	}
+	if transfer(beneficiary) == nil {
+		Fail(t, "the previous beneficiary still controls the retryable")
+	}
+}
+
+func TestRetryableRedeemBatch(t *testing.T) {
+	evm, call := newRetryableExtensionsTest(t)
+	beneficiary := common.HexToAddress("0x0301040105090206")
+	first := common.BigToHash(big.NewInt(978645611144))
+	second := common.BigToHash(big.NewInt(978645611145))
+	createRetryableForTesting(t, evm, first, beneficiary)
+	createRetryableForTesting(t, evm, second, beneficiary)
+
+	if _, err := call(common.Address{}, common.Big0, "redeemBatch", [][32]byte{first, second, first}); err == nil {
+		Fail(t, "redeemed a retryable twice in one batch")
+	}
+	if _, err := call(common.Address{}, common.Big0, "redeemBatch", [][32]byte{}); err == nil {
+		Fail(t, "redeemed an empty batch")
+	}
+	results, err := call(common.Address{}, common.Big0, "redeemBatch", [][32]byte{first, second})
+	Require(t, err)
+	hashes := results[0].([][32]byte)
+	if len(hashes) != 2 || hashes[0] == hashes[1] {
+		Fail(t, "unexpected retry tx hashes", hashes)
+	}
+
+	retryables := testContext(common.Address{}, evm).State.RetryableState()
+	for _, id := range []common.Hash{first, second} {
+		retryable, err := retryables.OpenRetryable(id, evm.Context.Time)
+		Require(t, err)
+		tries, err := retryable.NumTries()
+		Require(t, err)
+		if tries != 1 {
+			Fail(t, "retryable wasn't scheduled exactly once", id, tries)
+		}
+	}
+}
+
+func TestRetryableRedeemWithGasLimit(t *testing.T) {
+	evm, call := newRetryableExtensionsTest(t)
+	evm.Context.BaseFee = big.NewInt(params.GWei)
+	id := common.BigToHash(big.NewInt(978645611146))
+	createRetryableForTesting(t, evm, id, common.HexToAddress("0x0301040105090206"))
+	caller := common.HexToAddress("0x0102030405")
+
+	// the call is given a million gas, so most of this limit has to be bought
+	gasLimit := uint64(10_000_000)
+	if _, err := call(caller, common.Big1, "redeemWithGasLimit", id, gasLimit); err == nil {
+		Fail(t, "bought gas for a redeem without paying for it")
+	}
+	evm.StateDB.SubBalance(common.HexToAddress("6e"), common.Big1)
+
+	value := arbmath.BigMulByUint(evm.Context.BaseFee, gasLimit)
+	_, err := call(caller, value, "redeemWithGasLimit", id, gasLimit)
+	Require(t, err)
+	refund := evm.StateDB.GetBalance(caller)
+	if refund.Sign() <= 0 || refund.Cmp(value) >= 0 {
+		Fail(t, "donated gas wasn't refunded to the caller", refund)
+	}
+	if evm.StateDB.GetBalance(common.HexToAddress("6e")).Sign() != 0 {
+		Fail(t, "callvalue was left in the precompile")
+	}
+}
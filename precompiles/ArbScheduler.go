@@ -0,0 +1,127 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/offchainlabs/nitro/arbos/scheduler"
+	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+// ArbScheduler lets contracts schedule calls that ArbOS makes on their behalf at the start of a future block
+type ArbScheduler struct {
+	Address              addr // 0x72
+	CallScheduled        func(ctx, mech, bytes32, addr, addr, uint64, uint64) error
+	CallScheduledGasCost func(bytes32, addr, addr, uint64, uint64) (uint64, error)
+	CallCanceled         func(ctx, mech, bytes32) error
+	CallCanceledGasCost  func(bytes32) (uint64, error)
+
+	NoScheduledCallError     func(bytes32) error
+	IncorrectPrepaymentError func(huge, huge) error
+}
+
+// Schedule escrows the call's value and prepays its gas at maxFeePerGas, returning the call's id.
+// The call is made from the caller's address once both the L2 block notBeforeBlock and notBeforeTime
+// are reached, and the L2 basefee is no more than maxFeePerGas. Any prepaid gas that isn't used is
+// refunded to the caller.
+func (con ArbScheduler) Schedule(
+	c ctx, evm mech, value huge, to addr, callvalue huge, gas uint64, maxFeePerGas huge,
+	notBeforeBlock uint64, notBeforeTime uint64, data []byte,
+) (bytes32, error) {
+	sched := c.State.Scheduler()
+	maxGasPerBlock, err := sched.MaxGasPerBlock()
+	if err != nil {
+		return bytes32{}, err
+	}
+	if gas < params.TxGas || gas > maxGasPerBlock {
+		return bytes32{}, errors.New("scheduled call gas is out of range")
+	}
+	minBaseFee, err := c.State.L2PricingState().MinBaseFeeWei()
+	if err != nil {
+		return bytes32{}, err
+	}
+	if arbmath.BigLessThan(maxFeePerGas, minBaseFee) {
+		return bytes32{}, errors.New("scheduled call max fee per gas is below the minimum basefee")
+	}
+	prepayment := scheduler.ScheduledCallPrepayment(callvalue, gas, maxFeePerGas)
+	if !arbmath.BigEquals(value, prepayment) {
+		return bytes32{}, con.IncorrectPrepaymentError(value, prepayment)
+	}
+
+	call, err := sched.ScheduleCall(
+		c.caller, to, callvalue, gas, maxFeePerGas, notBeforeBlock, notBeforeTime, data,
+		evm.Context.BlockNumber.Uint64(), evm.Context.Time,
+	)
+	if err != nil {
+		return bytes32{}, err
+	}
+
+	// move the prepayment, which was deposited to this precompile's account, into escrow
+	escrow := scheduler.ScheduledCallEscrowAddress(call.Id())
+	if err := util.TransferBalance(&con.Address, &escrow, value, evm, util.TracingDuringEVM, "escrow"); err != nil {
+		return bytes32{}, err
+	}
+	return call.Id(), con.CallScheduled(c, evm, call.Id(), c.caller, to, notBeforeBlock, notBeforeTime)
+}
+
+// Cancel deletes a call that hasn't run yet, refunding its escrow to the caller who scheduled it
+func (con ArbScheduler) Cancel(c ctx, evm mech, id bytes32) error {
+	sched := c.State.Scheduler()
+	call, err := sched.OpenCall(id)
+	if err != nil {
+		return err
+	}
+	if call == nil {
+		return con.NoScheduledCallError(id)
+	}
+	from, err := call.From()
+	if err != nil {
+		return err
+	}
+	if c.caller != from {
+		return errors.New("only the scheduler may cancel a scheduled call")
+	}
+	if err := sched.CancelCall(id, evm, util.TracingDuringEVM); err != nil {
+		return err
+	}
+	return con.CallCanceled(c, evm, id)
+}
+
+// GetScheduledCall gets the parameters of a call that hasn't run yet
+func (con ArbScheduler) GetScheduledCall(c ctx, evm mech, id bytes32) (addr, addr, huge, uint64, huge, uint64, uint64, []byte, error) {
+	call, err := c.State.Scheduler().OpenCall(id)
+	if err != nil {
+		return addr{}, addr{}, nil, 0, nil, 0, 0, nil, err
+	}
+	if call == nil {
+		return addr{}, addr{}, nil, 0, nil, 0, 0, nil, con.NoScheduledCallError(id)
+	}
+	from, _ := call.From()
+	to, _ := call.To()
+	callvalue, _ := call.Callvalue()
+	gas, _ := call.Gas()
+	maxFeePerGas, _ := call.MaxFeePerGas()
+	notBeforeBlock, _ := call.NotBeforeBlock()
+	notBeforeTime, _ := call.NotBeforeTime()
+	data, err := call.Calldata()
+	return from, to, callvalue, gas, maxFeePerGas, notBeforeBlock, notBeforeTime, data, err
+}
+
+// IsDue checks whether the call is being run in the current block
+func (con ArbScheduler) IsDue(c ctx, evm mech, id bytes32) (bool, error) {
+	return c.State.Scheduler().IsDue(id)
+}
+
+// GetEscrowAddress gets the account holding the call's value and prepaid gas
+func (con ArbScheduler) GetEscrowAddress(c ctx, evm mech, id bytes32) (addr, error) {
+	return scheduler.ScheduledCallEscrowAddress(id), nil
+}
+
+// GetMaxGasPerBlock gets the amount of gas scheduled calls may use in a block
+func (con ArbScheduler) GetMaxGasPerBlock(c ctx, evm mech) (uint64, error) {
+	return c.State.Scheduler().MaxGasPerBlock()
+}
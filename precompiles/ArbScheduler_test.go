@@ -0,0 +1,97 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbos/scheduler"
+)
+
+func TestArbScheduler(t *testing.T) {
+	evm := newMockEVMForTesting()
+	upgradeArbosForTesting(t, evm, arbostypes.ArbosVersion_ScheduledCalls)
+	precompile := Precompiles()[scheduler.PrecompileAddress]
+
+	data, err := pendingInterfaces.ReadFile("interfaces/ArbScheduler.json")
+	Require(t, err)
+	schedulerABI, err := abi.JSON(bytes.NewReader(data))
+	Require(t, err)
+
+	caller := common.HexToAddress("0x0102030405")
+	other := common.HexToAddress("0x0504030201")
+	call := func(from common.Address, value *big.Int, method string, args ...interface{}) ([]interface{}, error) {
+		t.Helper()
+		input, err := schedulerABI.Pack(method, args...)
+		Require(t, err)
+		if value.Sign() > 0 {
+			// the EVM moves the value to the precompile before calling it
+			evm.StateDB.AddBalance(scheduler.PrecompileAddress, value)
+		}
+		output, _, err := precompile.Call(
+			input, scheduler.PrecompileAddress, scheduler.PrecompileAddress, from, value, false, 10_000_000, evm,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return schedulerABI.Unpack(method, output)
+	}
+
+	to := common.HexToAddress("0x0a0b0c")
+	callvalue := big.NewInt(1000)
+	gas := uint64(100_000)
+	maxFeePerGas := big.NewInt(params.GWei)
+	prepayment := scheduler.ScheduledCallPrepayment(callvalue, gas, maxFeePerGas)
+	scheduleArgs := []interface{}{to, callvalue, gas, maxFeePerGas, uint64(0), uint64(3600), []byte{0xab}}
+
+	underpayment := new(big.Int).Sub(prepayment, common.Big1)
+	if _, err := call(caller, underpayment, "schedule", scheduleArgs...); err == nil {
+		Fail(t, "scheduled a call with too small a prepayment")
+	}
+	evm.StateDB.SubBalance(scheduler.PrecompileAddress, underpayment)
+
+	results, err := call(caller, prepayment, "schedule", scheduleArgs...)
+	Require(t, err)
+	id := common.Hash(results[0].([32]byte))
+	escrow := scheduler.ScheduledCallEscrowAddress(id)
+	if evm.StateDB.GetBalance(escrow).Cmp(prepayment) != 0 {
+		Fail(t, "prepayment wasn't escrowed", evm.StateDB.GetBalance(escrow))
+	}
+	results, err = call(caller, common.Big0, "getEscrowAddress", id)
+	Require(t, err)
+	if results[0].(common.Address) != escrow {
+		Fail(t, "unexpected escrow address", results[0])
+	}
+	results, err = call(other, common.Big0, "getScheduledCall", id)
+	Require(t, err)
+	if results[0].(common.Address) != caller || results[1].(common.Address) != to || results[3].(uint64) != gas {
+		Fail(t, "unexpected scheduled call", results)
+	}
+
+	if _, err := call(other, common.Big0, "cancel", id); err == nil {
+		Fail(t, "canceled someone else's call")
+	}
+	_, err = call(caller, common.Big0, "cancel", id)
+	Require(t, err)
+	if evm.StateDB.GetBalance(caller).Cmp(prepayment) != 0 || evm.StateDB.GetBalance(escrow).Sign() != 0 {
+		Fail(t, "escrow wasn't refunded", evm.StateDB.GetBalance(caller))
+	}
+	if _, err := call(caller, common.Big0, "getScheduledCall", id); err == nil {
+		Fail(t, "call still exists after being canceled")
+	}
+
+	owner := &ArbOwner{}
+	Require(t, owner.SetScheduledCallsGasLimit(testContext(caller, evm), evm, 2*gas))
+	results, err = call(caller, common.Big0, "getMaxGasPerBlock")
+	Require(t, err)
+	if results[0].(uint64) != 2*gas {
+		Fail(t, "unexpected scheduled calls gas limit", results[0])
+	}
+}
@@ -24,3 +24,35 @@ func (con ArbStatistics) GetStats(c ctx, evm mech) (huge, huge, huge, huge, huge
 	classicNumContracts := big.NewInt(0) // TODO: hardcode the final value from Arbitrum Classic
 	return blockNum, classicNumAccounts, classicStorageSum, classicGasSum, classicNumTxes, classicNumContracts, nil
 }
+
+// GetTransactionCount gets the number of transactions the chain has processed since ArbOS 21,
+// not counting the internal transactions ArbOS makes at the start of every block
+func (con ArbStatistics) GetTransactionCount(c ctx, evm mech) (uint64, error) {
+	return c.State.Statistics().NumTransactions()
+}
+
+// GetContractsCreatedCount gets the number of contracts deployed by transactions since ArbOS 21.
+// Contracts created by other contracts aren't counted.
+func (con ArbStatistics) GetContractsCreatedCount(c ctx, evm mech) (uint64, error) {
+	return c.State.Statistics().NumContractsCreated()
+}
+
+// GetRetryablesCreatedCount gets the number of retryables submitted from L1 since ArbOS 21
+func (con ArbStatistics) GetRetryablesCreatedCount(c ctx, evm mech) (uint64, error) {
+	return c.State.Statistics().NumRetryablesCreated()
+}
+
+// GetRetryablesRedeemedCount gets the number of retryables successfully redeemed since ArbOS 21
+func (con ArbStatistics) GetRetryablesRedeemedCount(c ctx, evm mech) (uint64, error) {
+	return c.State.Statistics().NumRetryablesRedeemed()
+}
+
+// GetL2ToL1MessageCount gets the number of L2-to-L1 messages sent through ArbSys since genesis
+func (con ArbStatistics) GetL2ToL1MessageCount(c ctx, evm mech) (uint64, error) {
+	return c.State.SendMerkleAccumulator().Size()
+}
+
+// GetL1FeesCollected gets the total L1 fees paid by transactions since ArbOS 21, in wei
+func (con ArbStatistics) GetL1FeesCollected(c ctx, evm mech) (huge, error) {
+	return c.State.Statistics().L1FeesCollected()
+}
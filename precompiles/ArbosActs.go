@@ -18,3 +18,7 @@ func (con ArbosActs) StartBlock(c ctx, evm mech, l1BaseFee huge, l1BlockNumber,
 func (con ArbosActs) BatchPostingReport(c ctx, evm mech, batchTimestamp huge, batchPosterAddress addr, batchNumber uint64, batchDataGas uint64, l1BaseFeeWei huge) error {
 	return con.CallerNotArbOSError()
 }
+
+func (con ArbosActs) BatchPostingReportWithBlobs(c ctx, evm mech, batchTimestamp huge, batchPosterAddress addr, batchNumber uint64, batchDataGas uint64, l1BaseFeeWei huge, blobGasUsed uint64, blobBaseFeeWei huge) error {
+	return con.CallerNotArbOSError()
+}
@@ -0,0 +1,347 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+)
+
+// The BLS12-381 curve operations of EIP-2537, at the addresses and prices the EIP assigns them.
+// Unlike the ArbOS precompiles, these take the EIP's raw encodings rather than ABI-encoded calls.
+
+const (
+	bls12381G1AddGas          uint64 = 375
+	bls12381G2AddGas          uint64 = 600
+	bls12381G1MulGas          uint64 = 12000
+	bls12381G2MulGas          uint64 = 22500
+	bls12381PairingBaseGas    uint64 = 37700
+	bls12381PairingPerPairGas uint64 = 32600
+	bls12381MapG1Gas          uint64 = 5500
+	bls12381MapG2Gas          uint64 = 23800
+)
+
+const (
+	bls12381FieldElementLength = 64
+	bls12381G1PointLength      = 2 * bls12381FieldElementLength
+	bls12381G2PointLength      = 4 * bls12381FieldElementLength
+	bls12381ScalarLength       = 32
+	bls12381G1MsmPairLength    = bls12381G1PointLength + bls12381ScalarLength
+	bls12381G2MsmPairLength    = bls12381G2PointLength + bls12381ScalarLength
+	bls12381PairingPairLength  = bls12381G1PointLength + bls12381G2PointLength
+)
+
+// The per-point discounts in thousandths for multi-scalar multiplications of up to 128 points.
+// Larger multiplications get the last discount.
+var bls12381G1MsmDiscounts = [128]uint64{
+	1000, 949, 848, 797, 764, 750, 738, 728, 719, 712, 705, 698, 692, 687, 682, 677,
+	673, 669, 665, 661, 658, 654, 651, 648, 645, 642, 640, 637, 635, 632, 630, 627,
+	625, 623, 621, 619, 617, 615, 613, 611, 609, 608, 606, 604, 603, 601, 599, 598,
+	596, 595, 593, 592, 591, 589, 588, 586, 585, 584, 582, 581, 580, 579, 577, 576,
+	575, 574, 573, 572, 570, 569, 568, 567, 566, 565, 564, 563, 562, 561, 560, 559,
+	558, 557, 556, 555, 554, 553, 552, 551, 550, 549, 548, 547, 547, 546, 545, 544,
+	543, 542, 541, 540, 540, 539, 538, 537, 536, 536, 535, 534, 533, 532, 532, 531,
+	530, 529, 528, 528, 527, 526, 525, 525, 524, 523, 522, 522, 521, 520, 520, 519,
+}
+
+var bls12381G2MsmDiscounts = [128]uint64{
+	1000, 1000, 923, 884, 855, 832, 812, 796, 782, 770, 759, 749, 740, 732, 724, 717,
+	711, 704, 699, 693, 688, 683, 679, 674, 670, 666, 663, 659, 655, 652, 649, 646,
+	643, 640, 637, 634, 632, 629, 627, 624, 622, 620, 618, 615, 613, 611, 609, 607,
+	606, 604, 602, 600, 598, 597, 595, 593, 592, 590, 589, 587, 586, 584, 583, 582,
+	580, 579, 578, 576, 575, 574, 573, 571, 570, 569, 568, 567, 566, 565, 563, 562,
+	561, 560, 559, 558, 557, 556, 555, 554, 553, 552, 552, 551, 550, 549, 548, 547,
+	546, 545, 545, 544, 543, 542, 541, 541, 540, 539, 538, 537, 537, 536, 535, 535,
+	534, 533, 532, 532, 531, 530, 530, 529, 528, 528, 527, 526, 526, 525, 524, 524,
+}
+
+var (
+	ErrBLS12381InvalidInputLength          = errors.New("invalid input length")
+	ErrBLS12381InvalidFieldElementTopBytes = errors.New("invalid field element top bytes")
+	ErrBLS12381G1PointSubgroup             = errors.New("g1 point is not on correct subgroup")
+	ErrBLS12381G2PointSubgroup             = errors.New("g2 point is not on correct subgroup")
+)
+
+// EIP2537Precompiles returns the BLS12-381 precompiles by address, each of which only exists from ArbOS 21
+func EIP2537Precompiles() map[addr]vm.AdvancedPrecompile {
+	gated := func(inner vm.PrecompiledContract) vm.AdvancedPrecompile {
+		return versionGatedPrecompile{inner, arbostypes.ArbosVersion_BLS12381Precompiles}
+	}
+	return map[addr]vm.AdvancedPrecompile{
+		common.BytesToAddress([]byte{0x0b}): gated(&bls12381G1Add{}),
+		common.BytesToAddress([]byte{0x0c}): gated(&bls12381G1MultiExp{}),
+		common.BytesToAddress([]byte{0x0d}): gated(&bls12381G2Add{}),
+		common.BytesToAddress([]byte{0x0e}): gated(&bls12381G2MultiExp{}),
+		common.BytesToAddress([]byte{0x0f}): gated(&bls12381Pairing{}),
+		common.BytesToAddress([]byte{0x10}): gated(&bls12381MapG1{}),
+		common.BytesToAddress([]byte{0x11}): gated(&bls12381MapG2{}),
+	}
+}
+
+// versionGatedPrecompile runs a plain precompile once ArbOS reaches a version,
+// and before then acts like an address without code.
+type versionGatedPrecompile struct {
+	inner        vm.PrecompiledContract
+	arbosVersion uint64
+}
+
+func (p versionGatedPrecompile) RequiredGas(input []byte) uint64 {
+	panic("Non-advanced precompile method called")
+}
+
+func (p versionGatedPrecompile) Run(input []byte) ([]byte, error) {
+	panic("Non-advanced precompile method called")
+}
+
+func (p versionGatedPrecompile) RunAdvanced(
+	input []byte,
+	gasSupplied uint64,
+	info *vm.AdvancedPrecompileCall,
+) (ret []byte, gasLeft uint64, err error) {
+	if arbosState.ArbOSVersion(info.Evm.StateDB) < p.arbosVersion {
+		return []byte{}, gasSupplied, nil
+	}
+	cost := p.inner.RequiredGas(input)
+	if gasSupplied < cost {
+		return nil, 0, vm.ErrOutOfGas
+	}
+	output, err := p.inner.Run(input)
+	return output, gasSupplied - cost, err
+}
+
+func bls12381MsmGas(input []byte, pairLength int, mulGas uint64, discounts *[128]uint64) uint64 {
+	k := uint64(len(input) / pairLength)
+	if k == 0 {
+		return 0
+	}
+	discount := discounts[len(discounts)-1]
+	if k <= uint64(len(discounts)) {
+		discount = discounts[k-1]
+	}
+	return k * mulGas * discount / 1000
+}
+
+type bls12381G1Add struct{}
+
+func (c *bls12381G1Add) RequiredGas(input []byte) uint64 {
+	return bls12381G1AddGas
+}
+
+// Run adds two G1 points, which must be on the curve but needn't be in the subgroup
+func (c *bls12381G1Add) Run(input []byte) ([]byte, error) {
+	if len(input) != 2*bls12381G1PointLength {
+		return nil, ErrBLS12381InvalidInputLength
+	}
+	g := bls12381.NewG1()
+	p0, err := g.DecodePoint(input[:bls12381G1PointLength])
+	if err != nil {
+		return nil, err
+	}
+	p1, err := g.DecodePoint(input[bls12381G1PointLength:])
+	if err != nil {
+		return nil, err
+	}
+	r := g.New()
+	g.Add(r, p0, p1)
+	return g.EncodePoint(r), nil
+}
+
+type bls12381G1MultiExp struct{}
+
+func (c *bls12381G1MultiExp) RequiredGas(input []byte) uint64 {
+	return bls12381MsmGas(input, bls12381G1MsmPairLength, bls12381G1MulGas, &bls12381G1MsmDiscounts)
+}
+
+// Run computes the sum of G1 points multiplied by scalars, where each point must be in the subgroup
+func (c *bls12381G1MultiExp) Run(input []byte) ([]byte, error) {
+	if len(input) == 0 || len(input)%bls12381G1MsmPairLength != 0 {
+		return nil, ErrBLS12381InvalidInputLength
+	}
+	k := len(input) / bls12381G1MsmPairLength
+	g := bls12381.NewG1()
+	points := make([]*bls12381.PointG1, k)
+	scalars := make([]*big.Int, k)
+	for i := 0; i < k; i++ {
+		pair := input[i*bls12381G1MsmPairLength : (i+1)*bls12381G1MsmPairLength]
+		point, err := g.DecodePoint(pair[:bls12381G1PointLength])
+		if err != nil {
+			return nil, err
+		}
+		if !g.InCorrectSubgroup(point) {
+			return nil, ErrBLS12381G1PointSubgroup
+		}
+		points[i] = point
+		scalars[i] = new(big.Int).SetBytes(pair[bls12381G1PointLength:])
+	}
+	r := g.New()
+	if _, err := g.MultiExp(r, points, scalars); err != nil {
+		return nil, err
+	}
+	return g.EncodePoint(r), nil
+}
+
+type bls12381G2Add struct{}
+
+func (c *bls12381G2Add) RequiredGas(input []byte) uint64 {
+	return bls12381G2AddGas
+}
+
+// Run adds two G2 points, which must be on the curve but needn't be in the subgroup
+func (c *bls12381G2Add) Run(input []byte) ([]byte, error) {
+	if len(input) != 2*bls12381G2PointLength {
+		return nil, ErrBLS12381InvalidInputLength
+	}
+	g := bls12381.NewG2()
+	p0, err := g.DecodePoint(input[:bls12381G2PointLength])
+	if err != nil {
+		return nil, err
+	}
+	p1, err := g.DecodePoint(input[bls12381G2PointLength:])
+	if err != nil {
+		return nil, err
+	}
+	r := g.New()
+	g.Add(r, p0, p1)
+	return g.EncodePoint(r), nil
+}
+
+type bls12381G2MultiExp struct{}
+
+func (c *bls12381G2MultiExp) RequiredGas(input []byte) uint64 {
+	return bls12381MsmGas(input, bls12381G2MsmPairLength, bls12381G2MulGas, &bls12381G2MsmDiscounts)
+}
+
+// Run computes the sum of G2 points multiplied by scalars, where each point must be in the subgroup
+func (c *bls12381G2MultiExp) Run(input []byte) ([]byte, error) {
+	if len(input) == 0 || len(input)%bls12381G2MsmPairLength != 0 {
+		return nil, ErrBLS12381InvalidInputLength
+	}
+	k := len(input) / bls12381G2MsmPairLength
+	g := bls12381.NewG2()
+	points := make([]*bls12381.PointG2, k)
+	scalars := make([]*big.Int, k)
+	for i := 0; i < k; i++ {
+		pair := input[i*bls12381G2MsmPairLength : (i+1)*bls12381G2MsmPairLength]
+		point, err := g.DecodePoint(pair[:bls12381G2PointLength])
+		if err != nil {
+			return nil, err
+		}
+		if !g.InCorrectSubgroup(point) {
+			return nil, ErrBLS12381G2PointSubgroup
+		}
+		points[i] = point
+		scalars[i] = new(big.Int).SetBytes(pair[bls12381G2PointLength:])
+	}
+	r := g.New()
+	if _, err := g.MultiExp(r, points, scalars); err != nil {
+		return nil, err
+	}
+	return g.EncodePoint(r), nil
+}
+
+type bls12381Pairing struct{}
+
+func (c *bls12381Pairing) RequiredGas(input []byte) uint64 {
+	return bls12381PairingBaseGas + uint64(len(input)/bls12381PairingPairLength)*bls12381PairingPerPairGas
+}
+
+// Run checks whether the product of the pairings of each G1 and G2 point is one, returning a 32-byte boolean
+func (c *bls12381Pairing) Run(input []byte) ([]byte, error) {
+	if len(input) == 0 || len(input)%bls12381PairingPairLength != 0 {
+		return nil, ErrBLS12381InvalidInputLength
+	}
+	k := len(input) / bls12381PairingPairLength
+	e := bls12381.NewPairingEngine()
+	for i := 0; i < k; i++ {
+		pair := input[i*bls12381PairingPairLength : (i+1)*bls12381PairingPairLength]
+		p1, err := e.G1.DecodePoint(pair[:bls12381G1PointLength])
+		if err != nil {
+			return nil, err
+		}
+		p2, err := e.G2.DecodePoint(pair[bls12381G1PointLength:])
+		if err != nil {
+			return nil, err
+		}
+		if !e.G1.InCorrectSubgroup(p1) {
+			return nil, ErrBLS12381G1PointSubgroup
+		}
+		if !e.G2.InCorrectSubgroup(p2) {
+			return nil, ErrBLS12381G2PointSubgroup
+		}
+		e.AddPair(p1, p2)
+	}
+	output := make([]byte, 32)
+	if e.Check() {
+		output[31] = 1
+	}
+	return output, nil
+}
+
+type bls12381MapG1 struct{}
+
+func (c *bls12381MapG1) RequiredGas(input []byte) uint64 {
+	return bls12381MapG1Gas
+}
+
+// Run maps a base field element to a G1 point
+func (c *bls12381MapG1) Run(input []byte) ([]byte, error) {
+	if len(input) != bls12381FieldElementLength {
+		return nil, ErrBLS12381InvalidInputLength
+	}
+	fe, err := decodeBLS12381FieldElement(input)
+	if err != nil {
+		return nil, err
+	}
+	g := bls12381.NewG1()
+	r, err := g.MapToCurve(fe)
+	if err != nil {
+		return nil, err
+	}
+	return g.EncodePoint(r), nil
+}
+
+type bls12381MapG2 struct{}
+
+func (c *bls12381MapG2) RequiredGas(input []byte) uint64 {
+	return bls12381MapG2Gas
+}
+
+// Run maps an element of the quadratic extension field, encoded as c0 then c1, to a G2 point
+func (c *bls12381MapG2) Run(input []byte) ([]byte, error) {
+	if len(input) != 2*bls12381FieldElementLength {
+		return nil, ErrBLS12381InvalidInputLength
+	}
+	c0, err := decodeBLS12381FieldElement(input[:bls12381FieldElementLength])
+	if err != nil {
+		return nil, err
+	}
+	c1, err := decodeBLS12381FieldElement(input[bls12381FieldElementLength:])
+	if err != nil {
+		return nil, err
+	}
+	// the curve library takes c1 first
+	fe := append(c1, c0...)
+	g := bls12381.NewG2()
+	r, err := g.MapToCurve(fe)
+	if err != nil {
+		return nil, err
+	}
+	return g.EncodePoint(r), nil
+}
+
+// decodeBLS12381FieldElement strips the 16 zero bytes padding a 48-byte field element
+func decodeBLS12381FieldElement(input []byte) ([]byte, error) {
+	for _, b := range input[:16] {
+		if b != 0 {
+			return nil, ErrBLS12381InvalidFieldElementTopBytes
+		}
+	}
+	return common.CopyBytes(input[16:]), nil
+}
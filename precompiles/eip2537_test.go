@@ -0,0 +1,133 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+)
+
+func TestBLS12381G1(t *testing.T) {
+	g := bls12381.NewG1()
+	one := g.EncodePoint(g.One())
+	times := func(scalar int64) []byte {
+		r := g.New()
+		g.MulScalar(r, g.One(), big.NewInt(scalar))
+		return g.EncodePoint(r)
+	}
+	scalar := func(value int64) []byte {
+		return common.BigToHash(big.NewInt(value)).Bytes()
+	}
+
+	sum, err := (&bls12381G1Add{}).Run(append(common.CopyBytes(one), one...))
+	Require(t, err)
+	if !bytes.Equal(sum, times(2)) {
+		Fail(t, "wrong G1 sum")
+	}
+
+	msm := &bls12381G1MultiExp{}
+	input := append(append(append(common.CopyBytes(one), scalar(2)...), one...), scalar(3)...)
+	product, err := msm.Run(input)
+	Require(t, err)
+	if !bytes.Equal(product, times(5)) {
+		Fail(t, "wrong G1 multi-scalar multiplication")
+	}
+	if gas := msm.RequiredGas(input); gas != 2*12000*949/1000 {
+		Fail(t, "wrong G1 multi-scalar multiplication gas", gas)
+	}
+	if gas := msm.RequiredGas(make([]byte, 200*bls12381G1MsmPairLength)); gas != 200*12000*519/1000 {
+		Fail(t, "wrong G1 multi-scalar multiplication gas for many points", gas)
+	}
+	if _, err := msm.Run(input[1:]); !errors.Is(err, ErrBLS12381InvalidInputLength) {
+		Fail(t, "accepted a truncated input", err)
+	}
+
+	mapped, err := (&bls12381MapG1{}).Run(append(make([]byte, 63), 7))
+	Require(t, err)
+	point, err := g.DecodePoint(mapped)
+	Require(t, err)
+	if !g.InCorrectSubgroup(point) {
+		Fail(t, "mapped point isn't in the subgroup")
+	}
+	padded := make([]byte, bls12381FieldElementLength)
+	padded[0] = 1
+	if _, err := (&bls12381MapG1{}).Run(padded); !errors.Is(err, ErrBLS12381InvalidFieldElementTopBytes) {
+		Fail(t, "accepted a field element with nonzero padding", err)
+	}
+}
+
+func TestBLS12381G2AndPairing(t *testing.T) {
+	g1 := bls12381.NewG1()
+	g2 := bls12381.NewG2()
+	one := g2.EncodePoint(g2.One())
+	two := g2.New()
+	g2.MulScalar(two, g2.One(), big.NewInt(2))
+
+	sum, err := (&bls12381G2Add{}).Run(append(common.CopyBytes(one), one...))
+	Require(t, err)
+	if !bytes.Equal(sum, g2.EncodePoint(two)) {
+		Fail(t, "wrong G2 sum")
+	}
+
+	mapped, err := (&bls12381MapG2{}).Run(append(append(make([]byte, 63), 5), append(make([]byte, 63), 9)...))
+	Require(t, err)
+	point, err := g2.DecodePoint(mapped)
+	Require(t, err)
+	if !g2.InCorrectSubgroup(point) {
+		Fail(t, "mapped point isn't in the subgroup")
+	}
+
+	negated := g1.New()
+	g1.Neg(negated, g1.One())
+	pairing := &bls12381Pairing{}
+	balanced := append(append(append(g1.EncodePoint(g1.One()), one...), g1.EncodePoint(negated)...), one...)
+	output, err := pairing.Run(balanced)
+	Require(t, err)
+	if output[31] != 1 {
+		Fail(t, "pairing check of e(P, Q) * e(-P, Q) failed")
+	}
+	if gas := pairing.RequiredGas(balanced); gas != 37700+2*32600 {
+		Fail(t, "wrong pairing gas", gas)
+	}
+	output, err = pairing.Run(balanced[:bls12381PairingPairLength])
+	Require(t, err)
+	if output[31] != 0 {
+		Fail(t, "pairing check of e(P, Q) passed")
+	}
+}
+
+func TestBLS12381ActivatesWithArbOS(t *testing.T) {
+	evm := newMockEVMForTesting()
+	precompile := EIP2537Precompiles()[common.BytesToAddress([]byte{0x0b})]
+	g := bls12381.NewG1()
+	one := g.EncodePoint(g.One())
+	input := append(common.CopyBytes(one), one...)
+	info := &vm.AdvancedPrecompileCall{Evm: evm}
+
+	state, err := arbosState.OpenSystemArbosState(evm.StateDB, nil, false)
+	Require(t, err)
+	Require(t, state.BackingStorage().SetUint64ByUint64(0 /*versionOffset*/, 20))
+	output, gasLeft, err := precompile.RunAdvanced(input, 1000, info)
+	Require(t, err)
+	if len(output) != 0 || gasLeft != 1000 {
+		Fail(t, "precompile ran before it was activated", output, gasLeft)
+	}
+
+	Require(t, state.BackingStorage().SetUint64ByUint64(0 /*versionOffset*/, 21))
+	output, gasLeft, err = precompile.RunAdvanced(input, 1000, info)
+	Require(t, err)
+	if len(output) != bls12381G1PointLength || gasLeft != 1000-bls12381G1AddGas {
+		Fail(t, "precompile didn't run once activated", output, gasLeft)
+	}
+	if _, _, err := precompile.RunAdvanced(input, bls12381G1AddGas-1, info); !errors.Is(err, vm.ErrOutOfGas) {
+		Fail(t, "precompile ran without enough gas", err)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+// The interfaces directory holds, for each precompile, the ABI of any methods, events and errors
+// implemented here that its Solidity interface in the contracts submodule doesn't declare yet.
+// Files are named after the precompile and use solc's ABI output format. Once the contracts
+// declare an entry, it must be removed from here, as declaring it twice fails.
+//
+//go:embed interfaces/*.json
+var pendingInterfaces embed.FS
+
+// pendingContractMetaData is used to make precompiles the contracts submodule has no interface for at all,
+// whose entire ABI comes from their pending interface.
+var pendingContractMetaData = &bind.MetaData{ABI: "[]"}
+
+// mergePendingInterface adds the entries of the contract's pending interface, if it has one, to its ABI.
+func mergePendingInterface(source *abi.ABI, contract string) error {
+	data, err := pendingInterfaces.ReadFile("interfaces/" + contract + ".json")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	pending, err := abi.JSON(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("bad pending interface for %v: %w", contract, err)
+	}
+	for name, method := range pending.Methods {
+		if _, exists := source.Methods[name]; exists {
+			return fmt.Errorf("%v's method %v is already declared by its Solidity interface", contract, name)
+		}
+		source.Methods[name] = method
+	}
+	for name, event := range pending.Events {
+		if _, exists := source.Events[name]; exists {
+			return fmt.Errorf("%v's event %v is already declared by its Solidity interface", contract, name)
+		}
+		source.Events[name] = event
+	}
+	for name, solErr := range pending.Errors {
+		if _, exists := source.Errors[name]; exists {
+			return fmt.Errorf("%v's error %v is already declared by its Solidity interface", contract, name)
+		}
+		source.Errors[name] = solErr
+	}
+	return nil
+}
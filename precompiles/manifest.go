@@ -0,0 +1,470 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// The manifest describes every precompile's ABI along with the ArbOS version each part of it appeared in.
+// Released versions' entries must never change, so comparing manifests catches ABI changes lacking a version gate.
+type Manifest struct {
+	Precompiles []ManifestPrecompile `json:"precompiles"`
+}
+
+type ManifestPrecompile struct {
+	Name         string           `json:"name"`
+	Address      common.Address   `json:"address"`
+	Access       string           `json:"access"`
+	ArbOSVersion uint64           `json:"arbosVersion"`
+	Methods      []ManifestMethod `json:"methods"`
+	Events       []ManifestEvent  `json:"events,omitempty"`
+	Errors       []ManifestError  `json:"errors,omitempty"`
+}
+
+type ManifestMethod struct {
+	Name            string             `json:"name"`
+	Signature       string             `json:"signature"`
+	Selector        hexutil.Bytes      `json:"selector"`
+	StateMutability string             `json:"stateMutability"`
+	Inputs          []ManifestArgument `json:"inputs"`
+	Outputs         []ManifestArgument `json:"outputs"`
+	ArbOSVersion    uint64             `json:"arbosVersion"`
+	// The versions whose upgrades changed the method's behavior, as implemented by its _preVersionN_ variants
+	BehaviorChanges []uint64 `json:"behaviorChanges,omitempty"`
+}
+
+type ManifestEvent struct {
+	Name      string             `json:"name"`
+	Signature string             `json:"signature"`
+	Topic     common.Hash        `json:"topic"`
+	Inputs    []ManifestArgument `json:"inputs"`
+	Anonymous bool               `json:"anonymous,omitempty"`
+}
+
+type ManifestError struct {
+	Name      string             `json:"name"`
+	Signature string             `json:"signature"`
+	Selector  hexutil.Bytes      `json:"selector"`
+	Inputs    []ManifestArgument `json:"inputs"`
+}
+
+// ManifestArgument is formatted like the arguments of a solidity ABI
+type ManifestArgument struct {
+	Name       string             `json:"name"`
+	Type       string             `json:"type"`
+	Indexed    bool               `json:"indexed,omitempty"`
+	Components []ManifestArgument `json:"components,omitempty"`
+}
+
+// AbiEntry is an entry of a solidity ABI
+type AbiEntry struct {
+	Type            string             `json:"type"`
+	Name            string             `json:"name"`
+	Inputs          []ManifestArgument `json:"inputs"`
+	Outputs         []ManifestArgument `json:"outputs,omitempty"`
+	StateMutability string             `json:"stateMutability,omitempty"`
+	Anonymous       bool               `json:"anonymous,omitempty"`
+}
+
+var preVersionMethodRegex = regexp.MustCompile(`^_pre[vV]ersion(\d+)_(\w+)$`)
+
+// PreVersionMethods parses the precompiles' sources in a directory, finding the _preVersionN_ variants of methods.
+// The result maps each precompile and method name to the versions before which a variant applies.
+func PreVersionMethods(dir string) (map[string]map[string][]uint64, error) {
+	notTest := func(info fs.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}
+	packages, err := parser.ParseDir(token.NewFileSet(), dir, notTest, 0)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]map[string][]uint64)
+	for _, pkg := range packages {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv == nil || len(fn.Recv.List) != 1 {
+					continue
+				}
+				matches := preVersionMethodRegex.FindStringSubmatch(fn.Name.Name)
+				if matches == nil {
+					continue
+				}
+				receiver := fn.Recv.List[0].Type
+				if star, ok := receiver.(*ast.StarExpr); ok {
+					receiver = star.X
+				}
+				ident, ok := receiver.(*ast.Ident)
+				if !ok {
+					continue
+				}
+				version, err := strconv.ParseUint(matches[1], 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("bad version in %v.%v: %w", ident.Name, fn.Name.Name, err)
+				}
+				if result[ident.Name] == nil {
+					result[ident.Name] = make(map[string][]uint64)
+				}
+				result[ident.Name][matches[2]] = append(result[ident.Name][matches[2]], version)
+			}
+		}
+	}
+	for _, methods := range result {
+		for _, versions := range methods {
+			sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+		}
+	}
+	return result, nil
+}
+
+// BuildManifest describes the precompiles, noting the behavior changes found by PreVersionMethods.
+// A _preVersionN_ variant of a method the precompile doesn't have is an error.
+func BuildManifest(behaviorChanges map[string]map[string][]uint64) (*Manifest, error) {
+	manifest := &Manifest{}
+	found := make(map[string]bool)
+	for address, impl := range Precompiles() {
+		precompile := impl.Precompile()
+		access := "public"
+		switch impl.(type) {
+		case *OwnerPrecompile:
+			access = "owner"
+		case *DebugPrecompile:
+			access = "debug"
+		}
+		entry := ManifestPrecompile{
+			Name:         precompile.name,
+			Address:      address,
+			Access:       access,
+			ArbOSVersion: precompile.arbosVersion,
+		}
+		for name, method := range precompile.methodsByName {
+			entry.Methods = append(entry.Methods, ManifestMethod{
+				Name:            method.template.RawName,
+				Signature:       method.template.Sig,
+				Selector:        common.CopyBytes(method.template.ID),
+				StateMutability: method.purity.String(),
+				Inputs:          manifestArguments(method.template.Inputs),
+				Outputs:         manifestArguments(method.template.Outputs),
+				ArbOSVersion:    method.arbosVersion,
+				BehaviorChanges: behaviorChanges[precompile.name][name],
+			})
+			if _, ok := behaviorChanges[precompile.name][name]; ok {
+				found[precompile.name+"."+name] = true
+			}
+		}
+		for _, event := range precompile.events {
+			entry.Events = append(entry.Events, ManifestEvent{
+				Name:      event.template.RawName,
+				Signature: event.template.Sig,
+				Topic:     event.template.ID,
+				Inputs:    manifestArguments(event.template.Inputs),
+				Anonymous: event.template.Anonymous,
+			})
+		}
+		for _, solErr := range precompile.errors {
+			entry.Errors = append(entry.Errors, ManifestError{
+				Name:      solErr.template.Name,
+				Signature: solErr.template.Sig,
+				Selector:  common.CopyBytes(solErr.template.ID[:4]),
+				Inputs:    manifestArguments(solErr.template.Inputs),
+			})
+		}
+		sort.Slice(entry.Methods, func(i, j int) bool { return entry.Methods[i].Signature < entry.Methods[j].Signature })
+		sort.Slice(entry.Events, func(i, j int) bool { return entry.Events[i].Signature < entry.Events[j].Signature })
+		sort.Slice(entry.Errors, func(i, j int) bool { return entry.Errors[i].Signature < entry.Errors[j].Signature })
+		manifest.Precompiles = append(manifest.Precompiles, entry)
+	}
+	sort.Slice(manifest.Precompiles, func(i, j int) bool {
+		return bytes.Compare(manifest.Precompiles[i].Address[:], manifest.Precompiles[j].Address[:]) < 0
+	})
+
+	for contract, methods := range behaviorChanges {
+		for method := range methods {
+			if !found[contract+"."+method] {
+				return nil, fmt.Errorf("%v has a _preVersionN_ variant of %v but no such method", contract, method)
+			}
+		}
+	}
+	return manifest, nil
+}
+
+func (p purity) String() string {
+	switch p {
+	case pure:
+		return "pure"
+	case view:
+		return "view"
+	case write:
+		return "nonpayable"
+	case payable:
+		return "payable"
+	default:
+		return "unknown"
+	}
+}
+
+func manifestArguments(args abi.Arguments) []ManifestArgument {
+	result := make([]ManifestArgument, 0, len(args))
+	for _, arg := range args {
+		entry := manifestArgument(arg.Name, arg.Type)
+		entry.Indexed = arg.Indexed
+		result = append(result, entry)
+	}
+	return result
+}
+
+func manifestArgument(name string, argType abi.Type) ManifestArgument {
+	arg := ManifestArgument{Name: name, Type: argType.String()}
+	elem := &argType
+	suffix := ""
+	for elem.T == abi.SliceTy || elem.T == abi.ArrayTy {
+		if elem.T == abi.SliceTy {
+			suffix = "[]" + suffix
+		} else {
+			suffix = fmt.Sprintf("[%d]", elem.Size) + suffix
+		}
+		elem = elem.Elem
+	}
+	if elem.T == abi.TupleTy {
+		arg.Type = "tuple" + suffix
+		for i, component := range elem.TupleElems {
+			arg.Components = append(arg.Components, manifestArgument(elem.TupleRawNames[i], *component))
+		}
+	}
+	return arg
+}
+
+// Versions lists the ArbOS versions at which the precompiles' ABIs changed
+func (m *Manifest) Versions() []uint64 {
+	seen := map[uint64]bool{0: true}
+	for _, precompile := range m.Precompiles {
+		seen[precompile.ArbOSVersion] = true
+		for _, method := range precompile.Methods {
+			seen[method.ArbOSVersion] = true
+		}
+	}
+	versions := make([]uint64, 0, len(seen))
+	for version := range seen {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions
+}
+
+// ABIsAt returns the ABI of each precompile available at an ArbOS version, keyed by name
+func (m *Manifest) ABIsAt(version uint64) map[string][]AbiEntry {
+	abis := make(map[string][]AbiEntry)
+	for _, precompile := range m.Precompiles {
+		if precompile.ArbOSVersion > version {
+			continue
+		}
+		entries := []AbiEntry{}
+		for _, method := range precompile.Methods {
+			if method.ArbOSVersion > version {
+				continue
+			}
+			entries = append(entries, AbiEntry{
+				Type:            "function",
+				Name:            method.Name,
+				Inputs:          method.Inputs,
+				Outputs:         method.Outputs,
+				StateMutability: method.StateMutability,
+			})
+		}
+		for _, event := range precompile.Events {
+			entries = append(entries, AbiEntry{Type: "event", Name: event.Name, Inputs: event.Inputs, Anonymous: event.Anonymous})
+		}
+		for _, solErr := range precompile.Errors {
+			entries = append(entries, AbiEntry{Type: "error", Name: solErr.Name, Inputs: solErr.Inputs})
+		}
+		abis[precompile.Name] = entries
+	}
+	return abis
+}
+
+// Markdown renders the manifest as a reference of every precompile's methods, events, and errors
+func (m *Manifest) Markdown() string {
+	var builder strings.Builder
+	builder.WriteString("# Precompiles\n\n")
+	builder.WriteString("This file is generated by `make precompile-abi` and shouldn't be edited by hand.\n")
+	for _, precompile := range m.Precompiles {
+		fmt.Fprintf(&builder, "\n## %v\n\n", precompile.Name)
+		fmt.Fprintf(&builder, "Address `%v`, %v access, available from ArbOS %v.\n", precompile.Address, precompile.Access, precompile.ArbOSVersion)
+		if len(precompile.Methods) > 0 {
+			builder.WriteString("\n| Method | Selector | Mutability | Since ArbOS | Behavior changed in ArbOS |\n")
+			builder.WriteString("| --- | --- | --- | --- | --- |\n")
+			for _, method := range precompile.Methods {
+				changes := make([]string, 0, len(method.BehaviorChanges))
+				for _, version := range method.BehaviorChanges {
+					changes = append(changes, strconv.FormatUint(version, 10))
+				}
+				fmt.Fprintf(
+					&builder, "| `%v` | `%v` | %v | %v | %v |\n",
+					method.Signature, method.Selector, method.StateMutability, method.ArbOSVersion, strings.Join(changes, ", "),
+				)
+			}
+		}
+		if len(precompile.Events) > 0 {
+			builder.WriteString("\nEvents:\n\n")
+			for _, event := range precompile.Events {
+				fmt.Fprintf(&builder, "- `%v`\n", event.Signature)
+			}
+		}
+		if len(precompile.Errors) > 0 {
+			builder.WriteString("\nErrors:\n\n")
+			for _, solErr := range precompile.Errors {
+				fmt.Fprintf(&builder, "- `%v` (`%v`)\n", solErr.Signature, solErr.Selector)
+			}
+		}
+	}
+	return builder.String()
+}
+
+// CheckManifestCompatibility compares the current manifest against a previously generated one.
+// Everything available at or before the last released ArbOS version must be unchanged,
+// and anything new must be gated behind a later version.
+func CheckManifestCompatibility(previous, current *Manifest, releasedVersion uint64) []error {
+	var problems []error
+	currentByAddress := make(map[common.Address]*ManifestPrecompile)
+	for i := range current.Precompiles {
+		currentByAddress[current.Precompiles[i].Address] = &current.Precompiles[i]
+	}
+	previousByAddress := make(map[common.Address]*ManifestPrecompile)
+	for i := range previous.Precompiles {
+		previousByAddress[previous.Precompiles[i].Address] = &previous.Precompiles[i]
+	}
+
+	for _, old := range previous.Precompiles {
+		if old.ArbOSVersion > releasedVersion {
+			continue
+		}
+		now := currentByAddress[old.Address]
+		if now == nil {
+			problems = append(problems, fmt.Errorf("released precompile %v at %v was removed", old.Name, old.Address))
+			continue
+		}
+		if now.Name != old.Name || now.Access != old.Access || now.ArbOSVersion != old.ArbOSVersion {
+			problems = append(problems, fmt.Errorf("released precompile %v at %v changed", old.Name, old.Address))
+		}
+		methods := make(map[string]*ManifestMethod)
+		for i := range now.Methods {
+			methods[now.Methods[i].Selector.String()] = &now.Methods[i]
+		}
+		for _, method := range old.Methods {
+			if method.ArbOSVersion > releasedVersion {
+				continue
+			}
+			newMethod := methods[method.Selector.String()]
+			if newMethod == nil {
+				problems = append(problems, fmt.Errorf("released method %v.%v was removed", old.Name, method.Signature))
+				continue
+			}
+			if !sameMethod(&method, newMethod) {
+				problems = append(problems, fmt.Errorf("released method %v.%v changed", old.Name, method.Signature))
+			}
+			for _, version := range newMethod.BehaviorChanges {
+				if version <= releasedVersion && !containsVersion(method.BehaviorChanges, version) {
+					problems = append(problems, fmt.Errorf(
+						"%v.%v changed behavior in released ArbOS version %v", old.Name, method.Signature, version,
+					))
+				}
+			}
+		}
+		events := make(map[common.Hash]bool)
+		for _, event := range now.Events {
+			events[event.Topic] = true
+		}
+		for _, event := range old.Events {
+			if !events[event.Topic] {
+				problems = append(problems, fmt.Errorf("event %v.%v was removed or changed", old.Name, event.Signature))
+			}
+		}
+		solErrs := make(map[string]bool)
+		for _, solErr := range now.Errors {
+			solErrs[solErr.Signature] = true
+		}
+		for _, solErr := range old.Errors {
+			if !solErrs[solErr.Signature] {
+				problems = append(problems, fmt.Errorf("error %v.%v was removed or changed", old.Name, solErr.Signature))
+			}
+		}
+	}
+
+	for _, now := range current.Precompiles {
+		old := previousByAddress[now.Address]
+		if old == nil || old.ArbOSVersion > releasedVersion {
+			if now.ArbOSVersion <= releasedVersion {
+				problems = append(problems, fmt.Errorf(
+					"new precompile %v needs a version gate after ArbOS %v but has %v", now.Name, releasedVersion, now.ArbOSVersion,
+				))
+			}
+			continue
+		}
+		released := make(map[string]bool)
+		for _, method := range old.Methods {
+			if method.ArbOSVersion <= releasedVersion {
+				released[method.Selector.String()] = true
+			}
+		}
+		for _, method := range now.Methods {
+			if !released[method.Selector.String()] && method.ArbOSVersion <= releasedVersion {
+				problems = append(problems, fmt.Errorf(
+					"new method %v.%v needs a version gate after ArbOS %v but has %v",
+					now.Name, method.Signature, releasedVersion, method.ArbOSVersion,
+				))
+			}
+		}
+	}
+	return problems
+}
+
+func sameMethod(a, b *ManifestMethod) bool {
+	if a.Signature != b.Signature || a.StateMutability != b.StateMutability || a.ArbOSVersion != b.ArbOSVersion {
+		return false
+	}
+	if len(a.Outputs) != len(b.Outputs) {
+		return false
+	}
+	for i := range a.Outputs {
+		if !sameArgumentType(&a.Outputs[i], &b.Outputs[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameArgumentType(a, b *ManifestArgument) bool {
+	if a.Type != b.Type || a.Indexed != b.Indexed || len(a.Components) != len(b.Components) {
+		return false
+	}
+	for i := range a.Components {
+		if !sameArgumentType(&a.Components[i], &b.Components[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsVersion(versions []uint64, version uint64) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
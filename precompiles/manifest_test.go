@@ -0,0 +1,102 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPrecompileManifest(t *testing.T) {
+	behaviorChanges, err := PreVersionMethods(".")
+	Require(t, err)
+	if versions := behaviorChanges["ArbGasInfo"]["GetL1PricingSurplus"]; len(versions) != 1 || versions[0] != 10 {
+		Fail(t, "didn't find the pre-version 10 variant of GetL1PricingSurplus", versions)
+	}
+	manifest, err := BuildManifest(behaviorChanges)
+	Require(t, err)
+
+	findMethod := func(manifest *Manifest, contract, name string) *ManifestMethod {
+		for i := range manifest.Precompiles {
+			precompile := &manifest.Precompiles[i]
+			if precompile.Name != contract {
+				continue
+			}
+			for j := range precompile.Methods {
+				if precompile.Methods[j].Name == name {
+					return &precompile.Methods[j]
+				}
+			}
+		}
+		Fail(t, "method not in manifest", contract, name)
+		return nil
+	}
+	if method := findMethod(manifest, "ArbGasInfo", "getL1FeesAvailable"); method.ArbOSVersion != 10 {
+		Fail(t, "wrong version for getL1FeesAvailable", method.ArbOSVersion)
+	}
+	if method := findMethod(manifest, "ArbGasInfo", "getL1PricingSurplus"); len(method.BehaviorChanges) != 1 {
+		Fail(t, "behavior change of getL1PricingSurplus not recorded", method.BehaviorChanges)
+	}
+	for _, precompile := range manifest.Precompiles {
+		if precompile.Name == "ArbOwner" && precompile.Access != "owner" {
+			Fail(t, "ArbOwner isn't owner only", precompile.Access)
+		}
+	}
+
+	hasMethod := func(entries []AbiEntry, name string) bool {
+		for _, entry := range entries {
+			if entry.Type == "function" && entry.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+	if hasMethod(manifest.ABIsAt(9)["ArbGasInfo"], "getL1FeesAvailable") {
+		Fail(t, "ABI includes a method before its version")
+	}
+	if !hasMethod(manifest.ABIsAt(10)["ArbGasInfo"], "getL1FeesAvailable") {
+		Fail(t, "ABI is missing a method from its version")
+	}
+	if _, ok := manifest.ABIsAt(20)["ArbScheduler"]; ok {
+		Fail(t, "ABI includes a precompile before its version")
+	}
+	if !strings.Contains(manifest.Markdown(), "getL1FeesAvailable()") {
+		Fail(t, "markdown is missing a method")
+	}
+
+	// round trip the manifest through json to get an independent copy
+	copyManifest := func() *Manifest {
+		data, err := json.Marshal(manifest)
+		Require(t, err)
+		var copied Manifest
+		Require(t, json.Unmarshal(data, &copied))
+		return &copied
+	}
+	if problems := CheckManifestCompatibility(copyManifest(), manifest, 20); len(problems) != 0 {
+		Fail(t, "unchanged manifest isn't compatible", problems)
+	}
+
+	changed := copyManifest()
+	findMethod(changed, "ArbGasInfo", "getL1BlobBaseFeeEstimate").ArbOSVersion = 20
+	if problems := CheckManifestCompatibility(manifest, changed, 20); len(problems) != 1 {
+		Fail(t, "ungating a new method wasn't caught", problems)
+	}
+	if problems := CheckManifestCompatibility(manifest, changed, 21); len(problems) != 1 {
+		Fail(t, "changing a released method's version wasn't caught", problems)
+	}
+
+	changed = copyManifest()
+	findMethod(changed, "ArbGasInfo", "getL1FeesAvailable").StateMutability = "nonpayable"
+	if problems := CheckManifestCompatibility(manifest, changed, 20); len(problems) != 1 {
+		Fail(t, "changing a released method's mutability wasn't caught", problems)
+	}
+
+	changed = copyManifest()
+	method := findMethod(changed, "ArbGasInfo", "getL1PricingSurplus")
+	method.BehaviorChanges = append(method.BehaviorChanges, 15)
+	if problems := CheckManifestCompatibility(manifest, changed, 20); len(problems) != 1 {
+		Fail(t, "changing a released version's behavior wasn't caught", problems)
+	}
+}
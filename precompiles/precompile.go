@@ -14,6 +14,7 @@ import (
 
 	"github.com/offchainlabs/nitro/arbos"
 	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/scheduler"
 	"github.com/offchainlabs/nitro/arbos/util"
 	templates "github.com/offchainlabs/nitro/solgen/go/precompilesgen"
 	"github.com/offchainlabs/nitro/util/arbmath"
@@ -126,6 +127,10 @@ func MakePrecompile(metadata *bind.MetaData, implementer interface{}) (addr, *Pr
 	implementerType := reflect.TypeOf(implementer)
 	contract := implementerType.Elem().Name()
 
+	if err := mergePendingInterface(&source, contract); err != nil {
+		log.Crit("Bad pending interface", "err", err)
+	}
+
 	_, ok := implementerType.Elem().FieldByName("Address")
 	if !ok {
 		log.Crit("Implementer for precompile ", contract, " is missing an Address field")
@@ -532,14 +537,26 @@ func Precompiles() map[addr]ArbosPrecompile {
 	insert(MakePrecompile(templates.ArbInfoMetaData, &ArbInfo{Address: hex("65")}))
 	insert(MakePrecompile(templates.ArbAddressTableMetaData, &ArbAddressTable{Address: hex("66")}))
 	insert(MakePrecompile(templates.ArbBLSMetaData, &ArbBLS{Address: hex("67")}))
-	insert(MakePrecompile(templates.ArbFunctionTableMetaData, &ArbFunctionTable{Address: hex("68")}))
+	ArbFunctionTable := insert(MakePrecompile(templates.ArbFunctionTableMetaData, &ArbFunctionTable{Address: hex("68")}))
+	ArbFunctionTable.methodsByName["GetEntry"].arbosVersion = 21
 	insert(MakePrecompile(templates.ArbosTestMetaData, &ArbosTest{Address: hex("69")}))
 	ArbGasInfo := insert(MakePrecompile(templates.ArbGasInfoMetaData, &ArbGasInfo{Address: hex("6c")}))
 	ArbGasInfo.methodsByName["GetL1FeesAvailable"].arbosVersion = 10
 	ArbGasInfo.methodsByName["GetL1RewardRate"].arbosVersion = 11
 	ArbGasInfo.methodsByName["GetL1RewardRecipient"].arbosVersion = 11
+	ArbGasInfo.methodsByName["GetResourceConstraint"].arbosVersion = 21
+	ArbGasInfo.methodsByName["GetL1BlobBaseFeeEstimate"].arbosVersion = 21
+	ArbGasInfo.methodsByName["GetL2BaseFeeHistory"].arbosVersion = 21
+	ArbGasInfo.methodsByName["GetL1PricePerUnitHistory"].arbosVersion = 21
+	ArbGasInfo.methodsByName["GetPriceHistoryLength"].arbosVersion = 21
 	insert(MakePrecompile(templates.ArbAggregatorMetaData, &ArbAggregator{Address: hex("6d")}))
-	insert(MakePrecompile(templates.ArbStatisticsMetaData, &ArbStatistics{Address: hex("6f")}))
+	ArbStatistics := insert(MakePrecompile(templates.ArbStatisticsMetaData, &ArbStatistics{Address: hex("6f")}))
+	ArbStatistics.methodsByName["GetTransactionCount"].arbosVersion = 21
+	ArbStatistics.methodsByName["GetContractsCreatedCount"].arbosVersion = 21
+	ArbStatistics.methodsByName["GetRetryablesCreatedCount"].arbosVersion = 21
+	ArbStatistics.methodsByName["GetRetryablesRedeemedCount"].arbosVersion = 21
+	ArbStatistics.methodsByName["GetL2ToL1MessageCount"].arbosVersion = 21
+	ArbStatistics.methodsByName["GetL1FeesCollected"].arbosVersion = 21
 
 	eventCtx := func(gasLimit uint64, err error) *Context {
 		if err != nil {
@@ -551,13 +568,26 @@ func Precompiles() map[addr]ArbosPrecompile {
 		}
 	}
 
-	ArbOwnerPublic := insert(MakePrecompile(templates.ArbOwnerPublicMetaData, &ArbOwnerPublic{Address: hex("6b")}))
+	ArbOwnerPublicImpl := &ArbOwnerPublic{Address: hex("6b")}
+	ArbOwnerPublic := insert(MakePrecompile(templates.ArbOwnerPublicMetaData, ArbOwnerPublicImpl))
 	ArbOwnerPublic.methodsByName["GetInfraFeeAccount"].arbosVersion = 5
 	ArbOwnerPublic.methodsByName["RectifyChainOwner"].arbosVersion = 11
 	ArbOwnerPublic.methodsByName["GetBrotliCompressionLevel"].arbosVersion = 20
+	ArbOwnerPublic.methodsByName["IsFilteredAddress"].arbosVersion = 21
+	ArbOwnerPublic.methodsByName["GetAllFilteredAddresses"].arbosVersion = 21
+	ArbOwnerPublic.methodsByName["GetNativeTokenExchangeRate"].arbosVersion = 21
+	ArbOwnerPublic.methodsByName["GetAddressTableAutoRegisterThreshold"].arbosVersion = 21
+	ArbOwnerPublic.methodsByName["GetOwnerTimelockDelay"].arbosVersion = 21
+	ArbOwnerPublic.methodsByName["IsEmergencyMethod"].arbosVersion = 21
+	ArbOwnerPublic.methodsByName["GetTimelockedAction"].arbosVersion = 21
+	ArbOwnerPublic.methodsByName["ExecuteTimelockedAction"].arbosVersion = 21
+	ArbOwnerPublic.methodsByName["GetFeeDistribution"].arbosVersion = 21
 
 	ArbRetryableImpl := &ArbRetryableTx{Address: types.ArbRetryableTxAddress}
 	ArbRetryable := insert(MakePrecompile(templates.ArbRetryableTxMetaData, ArbRetryableImpl))
+	ArbRetryable.methodsByName["RedeemWithGasLimit"].arbosVersion = 21
+	ArbRetryable.methodsByName["RedeemBatch"].arbosVersion = 21
+	ArbRetryable.methodsByName["TransferBeneficiary"].arbosVersion = 21
 	arbos.ArbRetryableTxAddress = ArbRetryable.address
 	arbos.RedeemScheduledEventID = ArbRetryable.events["RedeemScheduled"].template.ID
 	arbos.EmitReedeemScheduledEvent = func(
@@ -575,6 +605,9 @@ func Precompiles() map[addr]ArbosPrecompile {
 		return ArbRetryableImpl.TicketCreated(context, evm, ticketId)
 	}
 
+	ArbScheduler := insert(MakePrecompile(pendingContractMetaData, &ArbScheduler{Address: scheduler.PrecompileAddress}))
+	ArbScheduler.arbosVersion = 21
+
 	ArbSys := insert(MakePrecompile(templates.ArbSysMetaData, &ArbSys{Address: types.ArbSysAddress}))
 	arbos.ArbSysAddress = ArbSys.address
 	arbos.L2ToL1TransactionEventID = ArbSys.events["L2ToL1Transaction"].template.ID
@@ -591,13 +624,41 @@ func Precompiles() map[addr]ArbosPrecompile {
 	ArbOwner.methodsByName["ReleaseL1PricerSurplusFunds"].arbosVersion = 10
 	ArbOwner.methodsByName["SetChainConfig"].arbosVersion = 11
 	ArbOwner.methodsByName["SetBrotliCompressionLevel"].arbosVersion = 20
-
-	insert(ownerOnly(ArbOwnerImpl.Address, ArbOwner, emitOwnerActs))
+	ArbOwner.methodsByName["AddFilteredAddress"].arbosVersion = 21
+	ArbOwner.methodsByName["RemoveFilteredAddress"].arbosVersion = 21
+	ArbOwner.methodsByName["GetAllFilteredAddresses"].arbosVersion = 21
+	ArbOwner.methodsByName["SetNativeTokenExchangeRate"].arbosVersion = 21
+	ArbOwner.methodsByName["SetScheduledCallsGasLimit"].arbosVersion = 21
+	ArbOwner.methodsByName["SetResourceConstraint"].arbosVersion = 21
+	ArbOwner.methodsByName["SetAddressTableAutoRegisterThreshold"].arbosVersion = 21
+	ArbOwner.methodsByName["SetFeeDistribution"].arbosVersion = 21
+	ArbOwner.methodsByName["DistributeL1PricerSurplus"].arbosVersion = 21
+	ArbOwner.methodsByName["UploadGlobalFunctionTable"].arbosVersion = 21
+
+	ArbOwner.methodsByName["SetOwnerTimelockDelay"].arbosVersion = 21
+	ArbOwner.methodsByName["SetEmergencyMethod"].arbosVersion = 21
+	ArbOwner.methodsByName["CancelTimelockedAction"].arbosVersion = 21
+
+	emitActionQueued := func(evm mech, id bytes32, owner addr, eta uint64, data []byte) error {
+		context := eventCtx(ArbOwnerImpl.TimelockedActionQueuedGasCost(id, owner, eta, data))
+		return ArbOwnerImpl.TimelockedActionQueued(context, evm, id, owner, eta, data)
+	}
+	insert(ownerOnly(ArbOwnerImpl.Address, ArbOwner, emitOwnerActs, emitActionQueued))
+	ArbOwnerPublicImpl.executeOwnerAction = func(evm mech, owner addr, calldata []byte, gas uint64) error {
+		_, _, err := ArbOwner.Call(calldata, ArbOwner.address, ArbOwner.address, owner, common.Big0, false, gas, evm)
+		if err != nil {
+			return err
+		}
+		return emitOwnerActs(evm, *(*[4]byte)(calldata[:4]), owner, calldata)
+	}
 	insert(debugOnly(MakePrecompile(templates.ArbDebugMetaData, &ArbDebug{Address: hex("ff")})))
 
 	ArbosActs := insert(MakePrecompile(templates.ArbosActsMetaData, &ArbosActs{Address: types.ArbosAddress}))
 	arbos.InternalTxStartBlockMethodID = ArbosActs.GetMethodID("StartBlock")
 	arbos.InternalTxBatchPostingReportMethodID = ArbosActs.GetMethodID("BatchPostingReport")
+	arbos.InternalTxBatchPostingReportWithBlobsMethodID = ArbosActs.GetMethodID("BatchPostingReportWithBlobs")
+	util.PackInternalTxDataBatchPostingReportWithBlobs, util.UnpackInternalTxDataBatchPostingReportWithBlobs =
+		util.MethodCallParser(ArbosActs.methodsByName["BatchPostingReportWithBlobs"].template)
 
 	return contracts
 }
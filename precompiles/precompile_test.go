@@ -6,10 +6,12 @@ package precompiles
 import (
 	"bytes"
 	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/core/state"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
@@ -198,3 +200,28 @@ func (burner FatalBurner) Burn(amount uint64) error {
 	burner.gasLeft -= amount
 	return nil
 }
+
+func TestPendingInterfaces(t *testing.T) {
+	files, err := pendingInterfaces.ReadDir("interfaces")
+	Require(t, err)
+	contracts := make(map[string]ArbosPrecompile)
+	for _, precompile := range Precompiles() {
+		contracts[precompile.Precompile().name] = precompile
+	}
+	for _, file := range files {
+		contract := strings.TrimSuffix(file.Name(), ".json")
+		precompile, ok := contracts[contract]
+		if !ok {
+			Fail(t, "pending interface", file.Name(), "doesn't belong to a precompile")
+		}
+		data, err := pendingInterfaces.ReadFile("interfaces/" + file.Name())
+		Require(t, err)
+		pending, err := abi.JSON(bytes.NewReader(data))
+		Require(t, err)
+		for _, method := range pending.Methods {
+			if _, ok := precompile.Precompile().methods[[4]byte(method.ID)]; !ok {
+				Fail(t, contract, "is missing pending method", method.Name)
+			}
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"math/big"
 
 	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
 	"github.com/offchainlabs/nitro/arbos/util"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -50,16 +51,24 @@ func (wrapper *DebugPrecompile) Precompile() *Precompile {
 	return wrapper.precompile.Precompile()
 }
 
-// OwnerPrecompile is a precompile wrapper for those only chain owners may use
+// OwnerPrecompile is a precompile wrapper for those only chain owners may use.
+// While the chain has an owner timelock, calls that change state are queued instead of made.
 type OwnerPrecompile struct {
 	precompile  ArbosPrecompile
 	emitSuccess func(mech, bytes4, addr, []byte) error
+	emitQueued  func(mech, bytes32, addr, uint64, []byte) error
 }
 
-func ownerOnly(address addr, impl ArbosPrecompile, emit func(mech, bytes4, addr, []byte) error) (addr, ArbosPrecompile) {
+func ownerOnly(
+	address addr,
+	impl ArbosPrecompile,
+	emit func(mech, bytes4, addr, []byte) error,
+	emitQueued func(mech, bytes32, addr, uint64, []byte) error,
+) (addr, ArbosPrecompile) {
 	return address, &OwnerPrecompile{
 		precompile:  impl,
 		emitSuccess: emit,
+		emitQueued:  emitQueued,
 	}
 }
 
@@ -95,13 +104,20 @@ func (wrapper *OwnerPrecompile) Call(
 		return nil, burner.gasLeft, errors.New("unauthorized caller to access-controlled method")
 	}
 
+	version := state.ArbOSVersion()
+	if !readOnly && version >= arbostypes.ArbosVersion_OwnerTimelock {
+		queued, err := wrapper.queueIfTimelocked(state, input, caller, version, evm)
+		if queued || err != nil {
+			return nil, gasSupplied, err // we don't deduct gas since we don't want to charge the owner
+		}
+	}
+
 	output, _, err := con.Call(input, precompileAddress, actingAsAddress, caller, value, readOnly, gasSupplied, evm)
 
 	if err != nil {
 		return output, gasSupplied, err // we don't deduct gas since we don't want to charge the owner
 	}
 
-	version := arbosState.ArbOSVersion(evm.StateDB)
 	if !readOnly || version < 11 {
 		// log that the owner operation succeeded
 		if err := wrapper.emitSuccess(evm, *(*[4]byte)(input[:4]), caller, input); err != nil {
@@ -112,6 +128,36 @@ func (wrapper *OwnerPrecompile) Call(
 	return output, gasSupplied, err // we don't deduct gas since we don't want to charge the owner
 }
 
+// queueIfTimelocked queues calls that change state while the owner timelock's delay is non-zero, returning
+// whether the call was queued. Canceling a queued call and methods marked as emergencies are never delayed.
+// Calls the precompile would reject aren't queued, so that they fail immediately.
+func (wrapper *OwnerPrecompile) queueIfTimelocked(
+	state *arbosState.ArbosState, input []byte, caller addr, arbosVersion uint64, evm mech,
+) (bool, error) {
+	timelock := state.OwnerTimelock()
+	delay, err := timelock.Delay()
+	if delay == 0 || err != nil || len(input) < 4 {
+		return false, err
+	}
+	selector := *(*[4]byte)(input)
+	method, ok := wrapper.precompile.Precompile().methods[selector]
+	if !ok || arbosVersion < method.arbosVersion || method.purity < write || method.name == "CancelTimelockedAction" {
+		return false, nil
+	}
+	if _, err := method.template.Inputs.Unpack(input[4:]); err != nil {
+		return false, nil
+	}
+	emergency, err := timelock.IsEmergencyMethod(selector)
+	if emergency || err != nil {
+		return false, err
+	}
+	id, eta, err := timelock.Queue(caller, input, evm.Context.Time)
+	if err != nil {
+		return false, err
+	}
+	return true, wrapper.emitQueued(evm, id, caller, eta, input)
+}
+
 func (wrapper *OwnerPrecompile) Precompile() *Precompile {
 	con := wrapper.precompile
 	return con.Precompile()
@@ -0,0 +1,233 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package statetransfer
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	JsonInitFileName        = "init.json"
+	JsonChecksumsFileName   = "checksums.sha256"
+	jsonAddressTableFile    = "addresstable.json"
+	jsonRetryableDataFile   = "retryables.json"
+	jsonAccountDataFileName = "accounts.json"
+)
+
+// JsonInitDataWriter writes state in the format read by JsonInitDataReader.
+// Lists are streamed to disk one element at a time, and a sha256sum compatible
+// checksum file covering every written file is produced on Close.
+type JsonInitDataWriter struct {
+	basePath  string
+	data      ArbosInitFileContents
+	checksums []string
+	writers   []*JsonListWriter
+}
+
+func NewJsonInitDataWriter(basePath string) (*JsonInitDataWriter, error) {
+	if err := os.MkdirAll(basePath, 0755); err != nil {
+		return nil, err
+	}
+	return &JsonInitDataWriter{
+		basePath: basePath,
+	}, nil
+}
+
+type JsonListWriter struct {
+	fileName string
+	file     *os.File
+	buffer   *bufio.Writer
+	hasher   hash.Hash
+	output   *json.Encoder
+	count    uint64
+}
+
+func (w *JsonInitDataWriter) getListWriter(fileName string) (*JsonListWriter, error) {
+	file, err := os.OpenFile(path.Join(w.basePath, fileName), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	hasher := sha256.New()
+	buffer := bufio.NewWriter(io.MultiWriter(file, hasher))
+	res := &JsonListWriter{
+		fileName: fileName,
+		file:     file,
+		buffer:   buffer,
+		hasher:   hasher,
+		output:   json.NewEncoder(buffer),
+	}
+	w.writers = append(w.writers, res)
+	return res, nil
+}
+
+func (l *JsonListWriter) write(elem interface{}) error {
+	if l.output == nil {
+		return errors.New("writing to closed list")
+	}
+	l.count++
+	return l.output.Encode(elem)
+}
+
+// Count returns the number of elements written so far.
+func (l *JsonListWriter) Count() uint64 {
+	return l.count
+}
+
+func (l *JsonListWriter) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	l.output = nil
+	if err := l.buffer.Flush(); err != nil {
+		return err
+	}
+	if err := l.file.Sync(); err != nil {
+		return err
+	}
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	l.file = nil
+	return nil
+}
+
+func (l *JsonListWriter) checksum() string {
+	return hex.EncodeToString(l.hasher.Sum(nil)) + "  " + l.fileName
+}
+
+type JsonAddressWriter struct {
+	*JsonListWriter
+}
+
+func (w *JsonAddressWriter) Write(addr common.Address) error {
+	return w.write(addr)
+}
+
+func (w *JsonInitDataWriter) GetAddressTableWriter() (*JsonAddressWriter, error) {
+	listWriter, err := w.getListWriter(jsonAddressTableFile)
+	if err != nil {
+		return nil, err
+	}
+	w.data.AddressTableContentsPath = jsonAddressTableFile
+	return &JsonAddressWriter{listWriter}, nil
+}
+
+type JsonRetryableDataWriter struct {
+	*JsonListWriter
+}
+
+func (w *JsonRetryableDataWriter) Write(r *InitializationDataForRetryable) error {
+	return w.write(&InitializationDataForRetryableJson{
+		Id:          r.Id,
+		Timeout:     r.Timeout,
+		From:        r.From,
+		To:          r.To,
+		Callvalue:   r.Callvalue.String(),
+		Beneficiary: r.Beneficiary,
+		Calldata:    r.Calldata,
+	})
+}
+
+func (w *JsonInitDataWriter) GetRetryableDataWriter() (*JsonRetryableDataWriter, error) {
+	listWriter, err := w.getListWriter(jsonRetryableDataFile)
+	if err != nil {
+		return nil, err
+	}
+	w.data.RetryableDataPath = jsonRetryableDataFile
+	return &JsonRetryableDataWriter{listWriter}, nil
+}
+
+type JsonAccountDataWriter struct {
+	*JsonListWriter
+}
+
+func (w *JsonAccountDataWriter) Write(a *AccountInitializationInfo) error {
+	return w.write(&AccountInitializationInfoJson{
+		Addr:         a.Addr,
+		Nonce:        a.Nonce,
+		Balance:      a.EthBalance.String(),
+		ContractInfo: a.ContractInfo,
+		ClassicHash:  a.ClassicHash,
+	})
+}
+
+func (w *JsonInitDataWriter) GetAccountDataWriter() (*JsonAccountDataWriter, error) {
+	listWriter, err := w.getListWriter(jsonAccountDataFileName)
+	if err != nil {
+		return nil, err
+	}
+	w.data.AccountsPath = jsonAccountDataFileName
+	return &JsonAccountDataWriter{listWriter}, nil
+}
+
+func (w *JsonInitDataWriter) SetNextBlockNumber(blockNum uint64) {
+	w.data.NextBlockNumber = blockNum
+}
+
+// Close closes any open list writers, then writes the init file and the checksum file.
+// Returns the path of the init file, which can be passed to NewJsonInitDataReader.
+func (w *JsonInitDataWriter) Close() (string, error) {
+	var checksums []string
+	for _, listWriter := range w.writers {
+		if err := listWriter.Close(); err != nil {
+			return "", err
+		}
+		checksums = append(checksums, listWriter.checksum())
+	}
+	initData, err := json.MarshalIndent(&w.data, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	initPath := path.Join(w.basePath, JsonInitFileName)
+	if err := os.WriteFile(initPath, initData, 0644); err != nil {
+		return "", err
+	}
+	initHash := sha256.Sum256(initData)
+	checksums = append(checksums, hex.EncodeToString(initHash[:])+"  "+JsonInitFileName)
+	checksumData := []byte(strings.Join(checksums, "\n") + "\n")
+	if err := os.WriteFile(path.Join(w.basePath, JsonChecksumsFileName), checksumData, 0644); err != nil {
+		return "", err
+	}
+	return initPath, nil
+}
+
+// VerifyJsonInitDataChecksums checks the files in basePath against the checksum file written by JsonInitDataWriter.
+func VerifyJsonInitDataChecksums(basePath string) error {
+	checksumData, err := os.ReadFile(path.Join(basePath, JsonChecksumsFileName))
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(checksumData)), "\n") {
+		expected, fileName, found := strings.Cut(line, "  ")
+		if !found {
+			return fmt.Errorf("invalid checksum line %q", line)
+		}
+		file, err := os.Open(path.Join(basePath, fileName))
+		if err != nil {
+			return err
+		}
+		hasher := sha256.New()
+		_, err = io.Copy(hasher, file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+		if actual := hex.EncodeToString(hasher.Sum(nil)); actual != expected {
+			return fmt.Errorf("checksum mismatch for %v: expected %v got %v", fileName, expected, actual)
+		}
+	}
+	return nil
+}
@@ -3,11 +3,15 @@ package arbtest
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/execution/gethexec"
 )
 
 func TestDebugAPI(t *testing.T) {
@@ -36,3 +40,45 @@ func TestDebugAPI(t *testing.T) {
 	Require(t, err)
 
 }
+
+func TestL1PricingModelDebugAPI(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, true)
+	builder.nodeConfig.DelayedSequencer.FinalizeDistance = 1
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	// The simulated L1 produces blocks in the future, so the batch poster mustn't wait for them.
+	builder.nodeConfig.BatchPoster.MaxDelay = -time.Hour
+
+	l2rpc := builder.L2.Stack.Attach()
+
+	// batch posting reports reach L2 through the delayed inbox once their batch is posted
+	var history gethexec.L1PricingModelHistory
+	var report *gethexec.BatchPostingReport
+	for i := 0; i < 128 && report == nil; i++ {
+		builder.L2.TransferBalance(t, "Owner", "Owner", common.Big1, builder.L2Info)
+		builder.L1.TransferBalance(t, "Faucet", "Faucet", common.Big1, builder.L1Info)
+
+		err := l2rpc.CallContext(ctx, &history, "arbdebug_l1PricingModel", rpc.BlockNumber(0), rpc.LatestBlockNumber)
+		Require(t, err)
+		report = history.LastBatchPostingReport[len(history.LastBatchPostingReport)-1]
+	}
+	if report == nil {
+		Fatal(t, "no batch posting report was found")
+	}
+
+	samples := len(history.Timestamp)
+	if samples == 0 || len(history.PricePerUnit) != samples || len(history.LastBatchPostingReport) != samples {
+		Fatal(t, "inconsistent number of samples", samples, len(history.PricePerUnit), len(history.LastBatchPostingReport))
+	}
+	if report.BatchPosterAddress == (common.Address{}) || report.BlockNumber > history.End {
+		Fatal(t, "unexpected batch posting report", report)
+	}
+	for i, price := range history.PricePerUnit {
+		if price == nil || price.Sign() <= 0 {
+			Fatal(t, "unexpected L1 price per unit", price, "at sample", i)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package arbtest
 
 import (
 	"context"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -117,3 +119,77 @@ func TestPruning(t *testing.T) {
 	_, err = builder.L2.EnsureTxSucceeded(tx)
 	Require(t, err)
 }
+
+func TestLivePruning(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, true)
+	// commit state to disk frequently so there is something to prune
+	builder.execConfig.Caching.TrieTimeLimit = time.Nanosecond
+	// live pruning can't evict deleted nodes from the clean cache
+	builder.execConfig.Caching.TrieCleanCache = 0
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	builder.L2Info.GenerateAccount("User2")
+	for i := 0; i < 200; i++ {
+		tx := builder.L2Info.PrepareTx("Owner", "User2", builder.L2Info.TransferGas, common.Big1, nil)
+		err := builder.L2.Client.SendTransaction(ctx, tx)
+		Require(t, err)
+		_, err = builder.L2.EnsureTxSucceeded(tx)
+		Require(t, err)
+	}
+
+	execNode := builder.L2.ExecNode
+	chainDb := execNode.ChainDB
+	prand := testhelpers.NewPseudoRandomDataSource(t, 1)
+	var testKeys [][]byte
+	for i := 0; i < 100; i++ {
+		// generate test keys with length of hash to emulate legacy state trie nodes
+		testKeys = append(testKeys, prand.GetHash().Bytes())
+	}
+	for _, key := range testKeys {
+		err := chainDb.Put(key, common.FromHex("0xdeadbeef"))
+		Require(t, err)
+	}
+	entriesBeforePruning := countStateEntries(chainDb)
+
+	config := gethexec.DefaultStatePrunerConfig
+	config.Enable = true
+	config.RetainBlocks = 16
+	config.BatchSize = 100
+	config.BatchDelay = 0
+	config.BloomSize = 16
+	config.Verify = true
+	bc := execNode.Backend.ArbInterface().BlockChain()
+	pruner := gethexec.NewStatePruner(bc, chainDb, execNode.ExecEngine, func() *gethexec.StatePrunerConfig { return &config })
+	Require(t, pruner.Prune(ctx))
+	// a second cycle must start from a fresh bloom filter
+	Require(t, pruner.Prune(ctx))
+
+	for _, key := range testKeys {
+		if has, _ := chainDb.Has(key); has {
+			Fatal(t, "test key hasn't been pruned as expected")
+		}
+	}
+	entriesAfterPruning := countStateEntries(chainDb)
+	t.Log("db entries pre-pruning:", entriesBeforePruning)
+	t.Log("db entries post-pruning:", entriesAfterPruning)
+	if entriesAfterPruning >= entriesBeforePruning {
+		Fatal(t, "The db doesn't have less entries after pruning then before. Before:", entriesBeforePruning, "After:", entriesAfterPruning)
+	}
+
+	// the retained state must still be usable
+	head := bc.CurrentBlock()
+	for num := head.Number.Uint64() - config.RetainBlocks; num <= head.Number.Uint64(); num++ {
+		if _, err := builder.L2.Client.BalanceAt(ctx, builder.L2Info.GetAddress("User2"), new(big.Int).SetUint64(num)); err != nil {
+			Fatal(t, "failed to read retained state at block", num, "err", err)
+		}
+	}
+	tx := builder.L2Info.PrepareTx("Owner", "User2", builder.L2Info.TransferGas, common.Big1, nil)
+	err := builder.L2.Client.SendTransaction(ctx, tx)
+	Require(t, err)
+	_, err = builder.L2.EnsureTxSucceeded(tx)
+	Require(t, err)
+}
@@ -72,7 +72,7 @@ func retryableSetup(t *testing.T) (
 			if !msgTypes[message.Message.Header.Kind] {
 				continue
 			}
-			txs, err := arbos.ParseL2Transactions(message.Message, params.ArbitrumDevTestChainConfig().ChainID, nil)
+			txs, err := arbos.ParseL2Transactions(message.Message, params.ArbitrumDevTestChainConfig().ChainID, 0, arbostypes.EthDecimals, nil, nil, nil)
 			Require(t, err)
 			for _, tx := range txs {
 				if txTypes[tx.Type()] {
@@ -0,0 +1,105 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/execution/gethexec"
+)
+
+func TestArbSimulate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, false)
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	builder.L2Info.GenerateAccount("User")
+	builder.L2Info.GenerateAccount("Unfunded")
+	owner := builder.L2Info.GetAddress("Owner")
+	user := builder.L2Info.GetAddress("User")
+	unfunded := builder.L2Info.GetAddress("Unfunded")
+	startBalance, err := builder.L2.Client.BalanceAt(ctx, user, nil)
+	Require(t, err)
+
+	l1BaseFeeEstimate := big.NewInt(5 * params.GWei)
+	timestamp := hexutil.Uint64(1 << 40)
+	l1BlockNumber := hexutil.Uint64(1 << 20)
+	value := (*hexutil.Big)(big.NewInt(1e12))
+	gasFeeCap := (*hexutil.Big)(big.NewInt(params.GWei))
+	blocks := []gethexec.SimulateBlock{
+		{
+			Overrides: gethexec.SimulateOverrides{
+				Timestamp:         &timestamp,
+				L1BlockNumber:     &l1BlockNumber,
+				L1BaseFeeEstimate: (*hexutil.Big)(l1BaseFeeEstimate),
+			},
+			Calls: []gethexec.SimulateCall{
+				{From: owner, To: &user, Value: value, GasFeeCap: gasFeeCap},
+				{From: owner, To: &user, Value: value, GasFeeCap: gasFeeCap},
+				// without a fee cap, the sender needn't be funded, as with eth_call
+				{From: unfunded, To: &user},
+			},
+		},
+		{
+			Calls: []gethexec.SimulateCall{
+				{
+					From:  owner,
+					To:    &user,
+					Value: value,
+					Retryable: &gethexec.SimulateRetryable{
+						Deposit:          (*hexutil.Big)(big.NewInt(1e18)),
+						MaxSubmissionFee: (*hexutil.Big)(big.NewInt(1e16)),
+					},
+				},
+			},
+		},
+	}
+
+	l2rpc := builder.L2.Stack.Attach()
+	var results []gethexec.SimulateBlockResult
+	err = l2rpc.CallContext(ctx, &results, "arb_simulate", rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), blocks)
+	Require(t, err)
+
+	if len(results) != len(blocks) {
+		Fatal(t, "unexpected number of simulated blocks", len(results))
+	}
+	first := results[0]
+	if first.Timestamp != timestamp || first.L1BlockNumber != l1BlockNumber {
+		Fatal(t, "overrides not applied", first.Timestamp, first.L1BlockNumber)
+	}
+	for i, call := range first.Calls {
+		if call.Error != "" || call.Status != hexutil.Uint64(types.ReceiptStatusSuccessful) {
+			Fatal(t, "simulated call", i, "failed:", call.Error)
+		}
+		if call.GasUsed != call.GasUsedForL1+call.GasUsedForL2 {
+			Fatal(t, "unexpected gas split", call.GasUsed, call.GasUsedForL1, call.GasUsedForL2)
+		}
+		if i < 2 && call.GasUsedForL1 == 0 {
+			Fatal(t, "unexpected gas split", call.GasUsed, call.GasUsedForL1, call.GasUsedForL2)
+		}
+	}
+	if results[1].Number != first.Number+1 {
+		Fatal(t, "simulated blocks aren't consecutive")
+	}
+	retryable := results[1].Calls[0].Retryable
+	if retryable == nil || !retryable.Created || !retryable.Redeemed || len(retryable.Redeems) != 1 {
+		Fatal(t, "unexpected retryable outcome", retryable)
+	}
+
+	// nothing simulated may be persisted
+	endBalance, err := builder.L2.Client.BalanceAt(ctx, user, nil)
+	Require(t, err)
+	if endBalance.Cmp(startBalance) != 0 {
+		Fatal(t, "simulation changed the chain's state")
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/offchainlabs/nitro/execution/gethexec"
+)
+
+func TestUpgradeRehearsal(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, false)
+	builder.chainConfig.ArbitrumChainParams.InitialArbOSVersion = 20
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	builder.L2Info.GenerateAccount("User")
+	startBlock, err := builder.L2.Client.BlockNumber(ctx)
+	Require(t, err)
+	const blocks = 4
+	for i := 0; i < blocks; i++ {
+		builder.L2.TransferBalance(t, "Owner", "User", big.NewInt(1e12), builder.L2Info)
+	}
+	head, err := builder.L2.Client.BlockNumber(ctx)
+	Require(t, err)
+	if head-startBlock != blocks {
+		Fatal(t, "expected one block per transfer but got", head-startBlock)
+	}
+
+	chainDb := builder.L2.ExecNode.ChainDB
+	chainConfig := builder.L2.ExecNode.ArbInterface.BlockChain().Config()
+	readMessage := builder.L2.ConsensusNode.TxStreamer.GetMessage
+
+	// re-executing the blocks without an upgrade reproduces the chain
+	report, err := gethexec.RehearseArbOSUpgrade(ctx, chainDb, chainConfig, readMessage, &gethexec.UpgradeRehearsalConfig{
+		TargetVersion: 20,
+		StartBlock:    startBlock,
+		Blocks:        blocks,
+	})
+	Require(t, err)
+	if len(report.Blocks) != blocks {
+		Fatal(t, "unexpected number of re-executed blocks", len(report.Blocks))
+	}
+	if report.RootMismatches != 0 || report.FailedTxs != 0 || report.GasUsedDelta != 0 {
+		Fatal(t, "re-executing without an upgrade changed the chain", report)
+	}
+	for _, block := range report.Blocks {
+		if len(block.Txs) != 0 {
+			Fatal(t, "re-executing without an upgrade changed transactions", block)
+		}
+	}
+
+	// upgrading changes the state, but the transfers still succeed
+	report, err = gethexec.RehearseArbOSUpgrade(ctx, chainDb, chainConfig, readMessage, &gethexec.UpgradeRehearsalConfig{
+		TargetVersion: 21,
+		StartBlock:    startBlock,
+		Blocks:        blocks,
+	})
+	Require(t, err)
+	if report.FromVersion != 20 || report.Blocks[0].ArbOSVersion != 21 {
+		Fatal(t, "upgrade wasn't applied", report.FromVersion, report.Blocks[0].ArbOSVersion)
+	}
+	if report.RootMismatches != blocks {
+		Fatal(t, "expected every block's root to change, got", report.RootMismatches)
+	}
+	if report.FailedTxs != 0 {
+		Fatal(t, "transactions failed after the upgrade", report)
+	}
+
+	// the chain itself is unchanged
+	current, err := builder.L2.Client.BlockNumber(ctx)
+	Require(t, err)
+	if current != head {
+		Fatal(t, "rehearsal changed the chain head from", head, "to", current)
+	}
+	for _, block := range report.Blocks {
+		written, err := chainDb.Has(block.RehearsalRoot.Bytes())
+		Require(t, err)
+		if written {
+			Fatal(t, "rehearsal wrote the state of block", block.Number, "to the database")
+		}
+	}
+}